@@ -0,0 +1,161 @@
+package servicegraph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/VictoriaMetrics/metrics"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// serviceGraphMetricsDroppedRelations is the number of relations, which weren't counted in service graph metrics
+// because of -servicegraph.metricsMaxRelations limit.
+var serviceGraphMetricsDroppedRelations = metrics.NewCounter(`vt_service_graph_metrics_dropped_relations_total`)
+
+// sgm contains Tempo-compatible service graph metrics, which are exposed at /metrics page.
+//
+// See https://grafana.com/docs/tempo/latest/metrics-generator/service_graphs/#metrics
+var sgm = func() *serviceGraphMetrics {
+	m := newServiceGraphMetrics()
+	metrics.RegisterSet(m.s)
+	return m
+}()
+
+// serviceGraphMetrics tracks the series of service graph metrics per relation, so the series of the relations,
+// which aren't seen for a long time, could be removed from the set.
+type serviceGraphMetrics struct {
+	s *metrics.Set
+
+	mu sync.Mutex
+	// relations maps the labels of the relation to the series of the relation.
+	relations map[string]*relationSeries
+}
+
+type relationSeries struct {
+	// names contains the full names of the series of the relation.
+	names    map[string]struct{}
+	lastSeen time.Time
+}
+
+func newServiceGraphMetrics() *serviceGraphMetrics {
+	return &serviceGraphMetrics{
+		s:         metrics.NewSet(),
+		relations: make(map[string]*relationSeries),
+	}
+}
+
+// update increments service graph metrics by the relations calculated by the service graph task at the given time.
+//
+// The client and server labels contain the parent and the child of the relation.
+// The vm_account_id and vm_project_id labels are added for non-default tenants.
+//
+// The relations, which aren't tracked yet, are dropped if there are maxRelations tracked relations.
+func (m *serviceGraphMetrics) update(tenantID logstorage.TenantID, rows [][]logstorage.Field, now time.Time, maxRelations int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, row := range rows {
+		var parent, child string
+		for _, f := range row {
+			switch f.Name {
+			case otelpb.ServiceGraphParentFieldName:
+				parent = f.Value
+			case otelpb.ServiceGraphChildFieldName:
+				child = f.Value
+			}
+		}
+		labels := fmt.Sprintf(`client="%s",server="%s"`, escapeLabelValue(parent), escapeLabelValue(child))
+		if tenantID.AccountID != 0 || tenantID.ProjectID != 0 {
+			labels += fmt.Sprintf(`,vm_account_id="%d",vm_project_id="%d"`, tenantID.AccountID, tenantID.ProjectID)
+		}
+
+		rs, ok := m.relations[labels]
+		if !ok {
+			if len(m.relations) >= maxRelations {
+				serviceGraphMetricsDroppedRelations.Inc()
+				continue
+			}
+			rs = &relationSeries{
+				names: make(map[string]struct{}),
+			}
+			m.relations[labels] = rs
+		}
+		rs.lastSeen = now
+
+		for _, f := range row {
+			switch {
+			case f.Name == otelpb.ServiceGraphCallCountFieldName:
+				n := parseUint(f.Value)
+				m.addCounter(rs, "traces_service_graph_request_total", labels, n)
+				m.addCounter(rs, "traces_service_graph_request_client_seconds_count", labels, n)
+				m.addCounter(rs, "traces_service_graph_request_client_seconds_bucket", labels+`,le="+Inf"`, n)
+			case f.Name == otelpb.ServiceGraphServerCallCountFieldName:
+				// the calls to virtual nodes have no server span, so they aren't counted in the server latency histogram.
+				n := parseUint(f.Value)
+				m.addCounter(rs, "traces_service_graph_request_server_seconds_count", labels, n)
+				m.addCounter(rs, "traces_service_graph_request_server_seconds_bucket", labels+`,le="+Inf"`, n)
+			case f.Name == otelpb.ServiceGraphFailedCountFieldName:
+				m.addCounter(rs, "traces_service_graph_request_failed_total", labels, parseUint(f.Value))
+			case f.Name == otelpb.ServiceGraphClientSecondsSumFieldName:
+				m.addFloatCounter(rs, "traces_service_graph_request_client_seconds_sum", labels, parseFloat(f.Value))
+			case f.Name == otelpb.ServiceGraphServerSecondsSumFieldName:
+				m.addFloatCounter(rs, "traces_service_graph_request_server_seconds_sum", labels, parseFloat(f.Value))
+			case strings.HasPrefix(f.Name, otelpb.ServiceGraphClientSecondsBucketFieldPrefix):
+				le := strings.TrimPrefix(f.Name, otelpb.ServiceGraphClientSecondsBucketFieldPrefix)
+				m.addCounter(rs, "traces_service_graph_request_client_seconds_bucket", labels+`,le="`+le+`"`, parseUint(f.Value))
+			case strings.HasPrefix(f.Name, otelpb.ServiceGraphServerSecondsBucketFieldPrefix):
+				le := strings.TrimPrefix(f.Name, otelpb.ServiceGraphServerSecondsBucketFieldPrefix)
+				m.addCounter(rs, "traces_service_graph_request_server_seconds_bucket", labels+`,le="`+le+`"`, parseUint(f.Value))
+			}
+		}
+	}
+}
+
+// removeStale removes the series of the relations, which weren't seen since deadline.
+func (m *serviceGraphMetrics) removeStale(deadline time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for labels, rs := range m.relations {
+		if !rs.lastSeen.Before(deadline) {
+			continue
+		}
+		for name := range rs.names {
+			m.s.UnregisterMetric(name)
+		}
+		delete(m.relations, labels)
+	}
+}
+
+func (m *serviceGraphMetrics) addCounter(rs *relationSeries, name, labels string, n uint64) {
+	name += "{" + labels + "}"
+	rs.names[name] = struct{}{}
+	m.s.GetOrCreateCounter(name).AddInt64(int64(n))
+}
+
+func (m *serviceGraphMetrics) addFloatCounter(rs *relationSeries, name, labels string, f float64) {
+	name += "{" + labels + "}"
+	rs.names[name] = struct{}{}
+	m.s.GetOrCreateFloatCounter(name).Add(f)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func parseUint(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
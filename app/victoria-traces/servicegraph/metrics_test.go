@@ -0,0 +1,67 @@
+package servicegraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestServiceGraphMetrics(t *testing.T) {
+	newRow := func(parent, child, callCount string) []logstorage.Field {
+		return []logstorage.Field{
+			{Name: otelpb.ServiceGraphParentFieldName, Value: parent},
+			{Name: otelpb.ServiceGraphChildFieldName, Value: child},
+			{Name: otelpb.ServiceGraphCallCountFieldName, Value: callCount},
+		}
+	}
+	f := func(m *serviceGraphMetrics, resultExpected string) {
+		t.Helper()
+
+		var bb bytes.Buffer
+		m.s.WritePrometheus(&bb)
+		var lines []string
+		for _, line := range strings.Split(bb.String(), "\n") {
+			if strings.HasPrefix(line, "traces_service_graph_request_total{") {
+				lines = append(lines, line)
+			}
+		}
+		result := strings.Join(lines, "\n")
+		if result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	tenantID := logstorage.TenantID{}
+	t0 := time.Unix(1700000000, 0)
+	m := newServiceGraphMetrics()
+
+	// the counters are incremented on every update
+	m.update(tenantID, [][]logstorage.Field{newRow("a", "b", "3")}, t0, 2)
+	m.update(tenantID, [][]logstorage.Field{newRow("a", "b", "2")}, t0.Add(time.Minute), 2)
+	f(m, `traces_service_graph_request_total{client="a",server="b"} 5`)
+
+	// the relations exceeding the limit are dropped
+	m.update(tenantID, [][]logstorage.Field{newRow("a", "c", "1"), newRow("a", "d", "1")}, t0.Add(2*time.Minute), 2)
+	f(m, `traces_service_graph_request_total{client="a",server="b"} 5
+traces_service_graph_request_total{client="a",server="c"} 1`)
+
+	// the stale relations are removed, so the new relations could be tracked
+	m.removeStale(t0.Add(90 * time.Second))
+	f(m, `traces_service_graph_request_total{client="a",server="c"} 1`)
+	m.update(tenantID, [][]logstorage.Field{newRow("a", "d", "4")}, t0.Add(3*time.Minute), 2)
+	f(m, `traces_service_graph_request_total{client="a",server="c"} 1
+traces_service_graph_request_total{client="a",server="d"} 4`)
+
+	// all the series of the relation are removed
+	m.removeStale(t0.Add(time.Hour))
+	var bb bytes.Buffer
+	m.s.WritePrometheus(&bb)
+	if bb.Len() > 0 {
+		t.Fatalf("expecting no series after removing all the stale relations; got\n%s", bb.String())
+	}
+}
@@ -3,6 +3,7 @@ package servicegraph
 import (
 	"context"
 	"flag"
+	"strings"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
@@ -15,11 +16,22 @@ import (
 )
 
 var (
-	enableServiceGraphTask     = flag.Bool("servicegraph.enableTask", false, "Whether to enable background task for generating service graph. It should only be enabled on VictoriaTraces single-node or vtstorage.")
+	enableServiceGraphTask = flag.Bool("servicegraph.enableTask", false, "Whether to enable background task for generating service graph. It should be enabled on VictoriaTraces single-node, on every vtstorage node, "+
+		"or on a single vtselect node in VictoriaTraces cluster. The task at vtselect joins the spans of all the -storageNode nodes, so the relations between spans "+
+		"stored at different nodes aren't lost. See https://docs.victoriametrics.com/victoriatraces/cluster/#service-graph")
 	serviceGraphTaskInterval   = flag.Duration("servicegraph.taskInterval", time.Minute, "The background task interval for generating service graph data. It requires setting -servicegraph.enableTask=true.")
 	serviceGraphTaskTimeout    = flag.Duration("servicegraph.taskTimeout", 30*time.Second, "The background task timeout duration for generating service graph data. It requires setting -servicegraph.enableTask=true.")
 	serviceGraphTaskLookbehind = flag.Duration("servicegraph.taskLookbehind", time.Minute, "The lookbehind window for each time service graph background task run. It requires setting -servicegraph.enableTask=true.")
 	serviceGraphTaskLimit      = flag.Uint64("servicegraph.taskLimit", 1000, "How many service graph relations each task could fetch for each tenant. It requires setting -servicegraph.enableTask=true.")
+	serviceGraphPeerAttributes = flag.String("servicegraph.peerAttributes", "", "Comma-separated list of span attributes, "+
+		"which are used for naming virtual nodes for client and producer spans without the matching server or consumer span, e.g. for calls to databases, queues and third-party APIs. "+
+		"The first non-empty attribute is used. Virtual nodes aren't generated if the list is empty. For example, -servicegraph.peerAttributes=peer.service,db.name,db.system. "+
+		"It requires setting -servicegraph.enableTask=true.")
+	serviceGraphMetricsMaxRelations = flag.Int("servicegraph.metricsMaxRelations", 10000, "The maximum number of (client, server) relations, "+
+		"for which service graph metrics are exposed at /metrics page. The relations exceeding the limit are dropped and counted in vt_service_graph_metrics_dropped_relations_total metric. "+
+		"It requires setting -servicegraph.enableTask=true.")
+	serviceGraphMetricsStaleTimeout = flag.Duration("servicegraph.metricsStaleTimeout", 15*time.Minute, "The series of service graph metrics for the (client, server) relation "+
+		"are removed from /metrics page if the relation isn't seen by the service graph background task during this time. It requires setting -servicegraph.enableTask=true.")
 )
 
 var (
@@ -82,22 +94,32 @@ func GenerateServiceGraphTimeRange(ctx context.Context) {
 		return
 	}
 
+	sgm.removeStale(endTime.Add(-*serviceGraphMetricsStaleTimeout))
+
 	commonFields := []logstorage.Field{
 		{Name: otelpb.ServiceGraphStreamName, Value: "-"},
 	}
 	commonFieldLen := len(commonFields)
 
+	var peerAttributes []string
+	for _, attr := range strings.Split(*serviceGraphPeerAttributes, ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			peerAttributes = append(peerAttributes, attr)
+		}
+	}
+
 	// query and persist operations are executed sequentially, which helps not to consume excessive resources.
 	for _, tenantID := range tenantIDs {
 		// query service graph relations
-		rows, err := vtselect.GetServiceGraphTimeRange(ctx, tenantID, startTime, endTime, *serviceGraphTaskLimit)
+		rows, err := vtselect.GetServiceGraphTimeRange(ctx, tenantID, startTime, endTime, *serviceGraphTaskLimit, peerAttributes)
 		if err != nil {
 			logger.Errorf("cannot get service graph for time range [%d, %d]: %s", startTime.Unix(), endTime.Unix(), err)
 			return
 		}
 		if len(rows) == 0 {
-			return
+			continue
 		}
+		sgm.update(tenantID, rows, endTime, *serviceGraphMetricsMaxRelations)
 		commonFields = commonFields[:commonFieldLen]
 		// persist service graph relations
 		commonFields, err = vtinsert.PersistServiceGraph(ctx, tenantID, commonFields, rows, endTime)
@@ -0,0 +1,86 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/protoparserutil"
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtinsert/insertutil"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtinsert/opentelemetry"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+var maxRequestSize = flagutil.NewBytes("jaeger.json.maxRequestSize", 64*1024*1024, "The maximum size in bytes of a single Jaeger JSON import request at /insert/jaeger/json.")
+
+var (
+	requestsJSONTotal   = metrics.NewCounter(`vt_http_requests_total{path="/insert/jaeger/json"}`)
+	errorsJSONTotal     = metrics.NewCounter(`vt_http_errors_total{path="/insert/jaeger/json"}`)
+	requestJSONDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/jaeger/json"}`)
+)
+
+// RequestHandler processes Jaeger insert requests
+func RequestHandler(path string, w http.ResponseWriter, r *http.Request) bool {
+	switch path {
+	case "/insert/jaeger/json":
+		handleJSONRequest(r, w)
+		return true
+	default:
+		return false
+	}
+}
+
+// handleJSONRequest imports traces in the format of Jaeger query service JSON API, e.g. exported from Jaeger UI:
+//
+//	{"data":[{"traceID":"...","spans":[...],"processes":{...}}]}
+//
+// The traces are converted to OTLP, so they are stored in the same way as the traces ingested via OTLP.
+func handleJSONRequest(r *http.Request, w http.ResponseWriter) {
+	startTime := time.Now()
+	requestsJSONTotal.Inc()
+
+	cp, err := insertutil.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot parse common params from request: %s", err)
+		return
+	}
+	// stream fields must contain the service name and span name.
+	// by using arguments and headers, users can also add other fields as stream fields
+	// for potentially better efficiency.
+	cp.StreamFields = append([]string{otelpb.ResourceAttrServiceName, otelpb.NameField}, cp.StreamFields...)
+
+	if err = insertutil.CanWriteData(); err != nil {
+		httpserver.Errorf(w, r, "%s", err)
+		return
+	}
+
+	encoding := r.Header.Get("Content-Encoding")
+	err = protoparserutil.ReadUncompressedData(r.Body, encoding, maxRequestSize, func(data []byte) error {
+		var resp response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			errorsJSONTotal.Inc()
+			return fmt.Errorf("cannot unmarshal Jaeger JSON from %d bytes: %w", len(data), err)
+		}
+		req, err := toExportTraceServiceRequest(resp.Data)
+		if err != nil {
+			errorsJSONTotal.Inc()
+			return err
+		}
+
+		lmp := cp.NewLogMessageProcessor("jaeger_json", false)
+		err = opentelemetry.PushExportTraceServiceRequest(req, lmp)
+		lmp.MustClose()
+		return err
+	})
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot read Jaeger JSON data: %s", err)
+		return
+	}
+	// update requestJSONDuration only for successfully parsed requests
+	requestJSONDuration.UpdateDuration(startTime)
+}
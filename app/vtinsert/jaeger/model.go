@@ -0,0 +1,287 @@
+package jaeger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// response is the response of Jaeger query service JSON API.
+//
+// See https://github.com/jaegertracing/jaeger-idl/blob/main/swagger/api_v2/query.swagger.json
+type response struct {
+	Data []*trace `json:"data"`
+}
+
+type trace struct {
+	TraceID   string              `json:"traceID"`
+	Spans     []*span             `json:"spans"`
+	Processes map[string]*process `json:"processes"`
+}
+
+type process struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []*keyValue `json:"tags"`
+}
+
+type span struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	OperationName string      `json:"operationName"`
+	References    []*spanRef  `json:"references"`
+	Flags         uint32      `json:"flags"`
+	StartTime     int64       `json:"startTime"`
+	Duration      int64       `json:"duration"`
+	Tags          []*keyValue `json:"tags"`
+	Logs          []*log      `json:"logs"`
+	ProcessID     string      `json:"processID"`
+	// Process is set instead of ProcessID in some exports.
+	Process *process `json:"process"`
+}
+
+type spanRef struct {
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+	RefType string `json:"refType"`
+}
+
+type keyValue struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type log struct {
+	Timestamp int64       `json:"timestamp"`
+	Fields    []*keyValue `json:"fields"`
+}
+
+// spanKinds maps the values of `span.kind` tag to OTLP span kinds.
+var spanKinds = map[string]otelpb.SpanKind{
+	"internal": 1,
+	"server":   2,
+	"client":   3,
+	"producer": 4,
+	"consumer": 5,
+}
+
+// toExportTraceServiceRequest converts Jaeger traces to OTLP request.
+//
+// It is the inverse of the conversion made by Jaeger HTTP APIs of vtselect:
+//   - process service name and tags are converted to resource attributes.
+//   - `otel.scope.name`, `otel.scope.version` and `scope_attr:*` tags are converted to the instrumentation scope.
+//   - `span.kind`, `error`, `otel.status_code`, `otel.status_description` and `w3c.tracestate` tags are converted to the corresponding span fields.
+//   - the rest of tags are converted to span attributes.
+//   - logs are converted to span events. The `event` field is used as the event name.
+//   - CHILD_OF reference to the span of the same trace is converted to the parent span ID, and the rest of references are converted to span links.
+func toExportTraceServiceRequest(traces []*trace) (*otelpb.ExportTraceServiceRequest, error) {
+	req := &otelpb.ExportTraceServiceRequest{}
+	for _, t := range traces {
+		resourceSpans := make(map[*process]*otelpb.ResourceSpans)
+		scopeSpans := make(map[*otelpb.ResourceSpans]map[string]*otelpb.ScopeSpans)
+		for _, sp := range t.Spans {
+			p := sp.Process
+			if p == nil {
+				p = t.Processes[sp.ProcessID]
+			}
+			if p == nil {
+				return nil, fmt.Errorf("cannot find process %q of span %q in trace %q", sp.ProcessID, sp.SpanID, t.TraceID)
+			}
+
+			rs, ok := resourceSpans[p]
+			if !ok {
+				rs = &otelpb.ResourceSpans{
+					Resource: otelpb.Resource{
+						Attributes: toResourceAttributes(p),
+					},
+				}
+				resourceSpans[p] = rs
+				scopeSpans[rs] = make(map[string]*otelpb.ScopeSpans)
+				req.ResourceSpans = append(req.ResourceSpans, rs)
+			}
+
+			scope, otelSpan, err := toSpan(t.TraceID, sp)
+			if err != nil {
+				return nil, err
+			}
+			scopeKey := getScopeKey(scope)
+			ss, ok := scopeSpans[rs][scopeKey]
+			if !ok {
+				ss = &otelpb.ScopeSpans{
+					Scope: *scope,
+				}
+				scopeSpans[rs][scopeKey] = ss
+				rs.ScopeSpans = append(rs.ScopeSpans, ss)
+			}
+			ss.Spans = append(ss.Spans, otelSpan)
+		}
+	}
+	return req, nil
+}
+
+func toResourceAttributes(p *process) []*otelpb.KeyValue {
+	attrs := make([]*otelpb.KeyValue, 0, len(p.Tags)+1)
+	attrs = append(attrs, newStringKeyValue("service.name", p.ServiceName))
+	for _, kv := range p.Tags {
+		if kv.Key == "service.name" {
+			continue
+		}
+		attrs = append(attrs, toKeyValue(kv.Key, kv))
+	}
+	return attrs
+}
+
+// toSpan converts Jaeger span to OTLP span and its instrumentation scope.
+func toSpan(traceID string, sp *span) (*otelpb.InstrumentationScope, *otelpb.Span, error) {
+	if sp.TraceID != "" {
+		traceID = sp.TraceID
+	}
+	if traceID == "" || sp.SpanID == "" {
+		return nil, nil, fmt.Errorf("missing traceID or spanID in span %q of trace %q", sp.SpanID, traceID)
+	}
+
+	scope := &otelpb.InstrumentationScope{}
+	otelSpan := &otelpb.Span{
+		TraceID:           traceID,
+		SpanID:            sp.SpanID,
+		Flags:             sp.Flags,
+		Name:              sp.OperationName,
+		StartTimeUnixNano: uint64(sp.StartTime) * 1000,
+		EndTimeUnixNano:   uint64(sp.StartTime+sp.Duration) * 1000,
+	}
+
+	for _, kv := range sp.Tags {
+		v := kv.stringValue()
+		switch {
+		case kv.Key == "otel.scope.name":
+			scope.Name = v
+		case kv.Key == "otel.scope.version":
+			scope.Version = v
+		case strings.HasPrefix(kv.Key, otelpb.InstrumentationScopeAttrPrefix):
+			scope.Attributes = append(scope.Attributes, toKeyValue(strings.TrimPrefix(kv.Key, otelpb.InstrumentationScopeAttrPrefix), kv))
+		case kv.Key == "w3c.tracestate":
+			otelSpan.TraceState = v
+		case kv.Key == "otel.status_description":
+			otelSpan.Status.Message = v
+		case kv.Key == "span.kind" && spanKinds[v] != 0:
+			otelSpan.Kind = spanKinds[v]
+		case kv.Key == "error" && (v == "true" || v == "false" || v == "unset"):
+			// `error` tag is set to `unset`, `false` and `true` for the unset, ok and error status codes by vtselect.
+			switch v {
+			case "true":
+				otelSpan.Status.Code = 2
+			case "false":
+				otelSpan.Status.Code = 1
+			}
+		case kv.Key == "otel.status_code" && (v == "OK" || v == "ERROR"):
+			if v == "ERROR" {
+				otelSpan.Status.Code = 2
+			} else {
+				otelSpan.Status.Code = 1
+			}
+		default:
+			otelSpan.Attributes = append(otelSpan.Attributes, toKeyValue(kv.Key, kv))
+		}
+	}
+
+	for _, l := range sp.Logs {
+		event := &otelpb.SpanEvent{
+			TimeUnixNano: uint64(l.Timestamp) * 1000,
+		}
+		for _, kv := range l.Fields {
+			if kv.Key == "event" {
+				event.Name = kv.stringValue()
+				continue
+			}
+			event.Attributes = append(event.Attributes, toKeyValue(kv.Key, kv))
+		}
+		otelSpan.Events = append(otelSpan.Events, event)
+	}
+
+	for _, ref := range sp.References {
+		if ref.RefType == "CHILD_OF" && ref.TraceID == traceID && otelSpan.ParentSpanID == "" {
+			otelSpan.ParentSpanID = ref.SpanID
+			continue
+		}
+		link := &otelpb.SpanLink{
+			TraceID: ref.TraceID,
+			SpanID:  ref.SpanID,
+		}
+		if ref.RefType == "CHILD_OF" {
+			// vtselect returns the links with this attribute as CHILD_OF references.
+			link.Attributes = append(link.Attributes, newStringKeyValue("opentracing.ref_type", "child_of"))
+		}
+		otelSpan.Links = append(otelSpan.Links, link)
+	}
+
+	return scope, otelSpan, nil
+}
+
+// getScopeKey returns the key for grouping spans with the same instrumentation scope.
+func getScopeKey(scope *otelpb.InstrumentationScope) string {
+	var sb strings.Builder
+	sb.WriteString(strconv.Quote(scope.Name))
+	sb.WriteString(strconv.Quote(scope.Version))
+	for _, kv := range scope.Attributes {
+		sb.WriteString(strconv.Quote(kv.Key))
+		sb.WriteString(strconv.Quote(kv.Value.FormatString(true)))
+	}
+	return sb.String()
+}
+
+func newStringKeyValue(key, value string) *otelpb.KeyValue {
+	return &otelpb.KeyValue{
+		Key: key,
+		Value: &otelpb.AnyValue{
+			StringValue: &value,
+		},
+	}
+}
+
+// toKeyValue converts the value of Jaeger tag to OTLP attribute with the given key according to the tag type.
+//
+// The value is stored as string if it doesn't match the tag type.
+func toKeyValue(key string, kv *keyValue) *otelpb.KeyValue {
+	v := kv.stringValue()
+	av := &otelpb.AnyValue{}
+	switch strings.ToLower(kv.Type) {
+	case "bool":
+		if b, err := strconv.ParseBool(v); err == nil {
+			av.BoolValue = &b
+			return &otelpb.KeyValue{Key: key, Value: av}
+		}
+	case "int64":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			av.IntValue = &n
+			return &otelpb.KeyValue{Key: key, Value: av}
+		}
+	case "float64":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			av.DoubleValue = &f
+			return &otelpb.KeyValue{Key: key, Value: av}
+		}
+	case "binary":
+		if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+			av.BytesValue = &b
+			return &otelpb.KeyValue{Key: key, Value: av}
+		}
+	}
+	av.StringValue = &v
+	return &otelpb.KeyValue{Key: key, Value: av}
+}
+
+// stringValue returns the tag value as string. JSON strings are unquoted, and other JSON values are returned as is.
+func (kv *keyValue) stringValue() string {
+	if len(kv.Value) == 0 || string(kv.Value) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(kv.Value, &s); err == nil {
+		return s
+	}
+	return string(kv.Value)
+}
@@ -0,0 +1,154 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestToExportTraceServiceRequest(t *testing.T) {
+	f := func(data string, want *otelpb.ExportTraceServiceRequest, errorMsg string) {
+		t.Helper()
+
+		var resp response
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			t.Fatalf("cannot unmarshal test data: %s", err)
+		}
+		var errMsgGot string
+		got, err := toExportTraceServiceRequest(resp.Data)
+		if err != nil {
+			errMsgGot = err.Error()
+		}
+		if errMsgGot != errorMsg {
+			t.Fatalf("toExportTraceServiceRequest() error = %v, want err: %v", err, errorMsg)
+		}
+		if !cmp.Equal(got, want) {
+			t.Fatalf("toExportTraceServiceRequest() diff = %v", cmp.Diff(got, want))
+		}
+	}
+
+	str := func(s string) *otelpb.AnyValue {
+		return &otelpb.AnyValue{StringValue: &s}
+	}
+	i64 := func(n int64) *otelpb.AnyValue {
+		return &otelpb.AnyValue{IntValue: &n}
+	}
+	boolean := func(b bool) *otelpb.AnyValue {
+		return &otelpb.AnyValue{BoolValue: &b}
+	}
+
+	// empty
+	f(`{"data":[]}`, &otelpb.ExportTraceServiceRequest{}, "")
+
+	// missing process
+	f(`{"data":[{"traceID":"t1","spans":[{"spanID":"s1","processID":"p1"}]}]}`, nil, `cannot find process "p1" of span "s1" in trace "t1"`)
+
+	// missing span ID
+	f(`{"data":[{"traceID":"t1","spans":[{"processID":"p1"}],"processes":{"p1":{"serviceName":"svc"}}}]}`, nil, `missing traceID or spanID in span "" of trace "t1"`)
+
+	// all the fields
+	f(`{"data":[{"traceID":"t1","spans":[
+		{"traceID":"t1","spanID":"s1","operationName":"GET /","startTime":1000,"duration":20,"processID":"p1",
+			"references":[{"refType":"CHILD_OF","traceID":"t1","spanID":"s0"},{"refType":"FOLLOWS_FROM","traceID":"t2","spanID":"s2"},{"refType":"CHILD_OF","traceID":"t3","spanID":"s3"}],
+			"tags":[
+				{"key":"otel.scope.name","type":"string","value":"scope"},
+				{"key":"otel.scope.version","type":"string","value":"v1"},
+				{"key":"scope_attr:foo","type":"string","value":"bar"},
+				{"key":"span.kind","type":"string","value":"server"},
+				{"key":"error","type":"bool","value":true},
+				{"key":"otel.status_description","type":"string","value":"oops"},
+				{"key":"w3c.tracestate","type":"string","value":"ts"},
+				{"key":"http.status_code","type":"int64","value":500},
+				{"key":"retry","type":"bool","value":"false"},
+				{"key":"payload","type":"int64","value":"abc"}
+			],
+			"logs":[{"timestamp":1005,"fields":[{"key":"event","type":"string","value":"exception"},{"key":"exception.message","type":"string","value":"boom"}]}]},
+		{"traceID":"t1","spanID":"s4","operationName":"SELECT","startTime":1001,"duration":5,"processID":"p2",
+			"tags":[{"key":"span.kind","type":"string","value":"client"},{"key":"error","type":"string","value":"unset"}]}
+	],"processes":{
+		"p1":{"serviceName":"frontend","tags":[{"key":"host.name","type":"string","value":"h1"}]},
+		"p2":{"serviceName":"db","tags":[{"key":"service.name","type":"string","value":"db"}]}
+	}}]}`, &otelpb.ExportTraceServiceRequest{
+		ResourceSpans: []*otelpb.ResourceSpans{
+			{
+				Resource: otelpb.Resource{
+					Attributes: []*otelpb.KeyValue{
+						{Key: "service.name", Value: str("frontend")},
+						{Key: "host.name", Value: str("h1")},
+					},
+				},
+				ScopeSpans: []*otelpb.ScopeSpans{
+					{
+						Scope: otelpb.InstrumentationScope{
+							Name:    "scope",
+							Version: "v1",
+							Attributes: []*otelpb.KeyValue{
+								{Key: "foo", Value: str("bar")},
+							},
+						},
+						Spans: []*otelpb.Span{
+							{
+								TraceID:           "t1",
+								SpanID:            "s1",
+								TraceState:        "ts",
+								ParentSpanID:      "s0",
+								Name:              "GET /",
+								Kind:              2,
+								StartTimeUnixNano: 1000000,
+								EndTimeUnixNano:   1020000,
+								Attributes: []*otelpb.KeyValue{
+									{Key: "http.status_code", Value: i64(500)},
+									{Key: "retry", Value: boolean(false)},
+									{Key: "payload", Value: str("abc")},
+								},
+								Events: []*otelpb.SpanEvent{
+									{
+										TimeUnixNano: 1005000,
+										Name:         "exception",
+										Attributes: []*otelpb.KeyValue{
+											{Key: "exception.message", Value: str("boom")},
+										},
+									},
+								},
+								Links: []*otelpb.SpanLink{
+									{TraceID: "t2", SpanID: "s2"},
+									{TraceID: "t3", SpanID: "s3", Attributes: []*otelpb.KeyValue{
+										{Key: "opentracing.ref_type", Value: str("child_of")},
+									}},
+								},
+								Status: otelpb.Status{
+									Message: "oops",
+									Code:    2,
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Resource: otelpb.Resource{
+					Attributes: []*otelpb.KeyValue{
+						{Key: "service.name", Value: str("db")},
+					},
+				},
+				ScopeSpans: []*otelpb.ScopeSpans{
+					{
+						Spans: []*otelpb.Span{
+							{
+								TraceID:           "t1",
+								SpanID:            "s4",
+								Name:              "SELECT",
+								Kind:              3,
+								StartTimeUnixNano: 1001000,
+								EndTimeUnixNano:   1006000,
+							},
+						},
+					},
+				},
+			},
+		},
+	}, "")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/netutil"
 
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtinsert/internalinsert"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtinsert/jaeger"
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtinsert/opentelemetry"
 	"github.com/VictoriaMetrics/VictoriaTraces/lib/grpc"
 	"github.com/VictoriaMetrics/VictoriaTraces/lib/http2server"
@@ -87,6 +88,8 @@ func insertHandler(w http.ResponseWriter, r *http.Request, path string) bool {
 	switch {
 	case strings.HasPrefix(path, "/insert/opentelemetry/"):
 		return opentelemetry.RequestHandler(path, w, r)
+	case strings.HasPrefix(path, "/insert/jaeger/"):
+		return jaeger.RequestHandler(path, w, r)
 	}
 
 	return false
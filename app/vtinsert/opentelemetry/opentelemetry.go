@@ -2,7 +2,10 @@ package opentelemetry
 
 import (
 	"context"
+	"flag"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
@@ -15,7 +18,13 @@ import (
 	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
 )
 
-var maxRequestSize = flagutil.NewBytes("opentelemetry.traces.maxRequestSize", 64*1024*1024, "The maximum size in bytes of a single OpenTelemetry trace export request.")
+var (
+	maxRequestSize = flagutil.NewBytes("opentelemetry.traces.maxRequestSize", 64*1024*1024, "The maximum size in bytes of a single OpenTelemetry trace export request.")
+	indexSpanID    = flag.Bool("insert.indexSpanID", false, "Whether to write an entry into the span ID index stream for every ingested span. "+
+		"The index is required by /select/traces/by_span_id/<span_id> API. It increases the number of stored rows, so it is disabled by default")
+	indexSpanLinks = flag.Bool("insert.indexSpanLinks", false, "Whether to write an entry into the link index stream for every link of the ingested spans. "+
+		"The index is required for returning incoming links by /select/traces/<trace_id>/links API. It increases the number of stored rows, so it is disabled by default")
+)
 
 var (
 	mandatoryStreamFields = []string{otelpb.ResourceAttrServiceName, otelpb.NameField}
@@ -27,9 +36,9 @@ var (
 	traceIDCache = fastcache.New(32 * 1024 * 1024)
 )
 
-// pushExportTraceServiceRequest is the entry point of OTLP data processing. It should be called by different
-// request handlers such as OTLPHTTP handler, OTLPgRPC handler.
-func pushExportTraceServiceRequest(req *otelpb.ExportTraceServiceRequest, lmp insertutil.LogMessageProcessor) error {
+// PushExportTraceServiceRequest is the entry point of OTLP data processing. It should be called by different
+// request handlers such as OTLPHTTP handler, OTLPgRPC handler, and the handlers of other formats converted to OTLP.
+func PushExportTraceServiceRequest(req *otelpb.ExportTraceServiceRequest, lmp insertutil.LogMessageProcessor) error {
 	var commonFields []logstorage.Field
 	for _, rs := range req.ResourceSpans {
 		commonFields = commonFields[:0]
@@ -94,6 +103,9 @@ func pushFieldsFromSpan(span *otelpb.Span, scopeCommonFields []logstorage.Field,
 		// append event attributes
 		fields = appendKeyValuesWithPrefixSuffix(fields, event.Attributes, "", eventFieldPrefix+otelpb.EventAttrPrefix, eventFieldSuffix)
 	}
+	if len(span.Events) > 0 {
+		fields = appendEventSearchFields(fields, fields[len(scopeCommonFields):])
+	}
 
 	for idx, link := range span.Links {
 		linkFieldPrefix := otelpb.LinkPrefix
@@ -131,6 +143,8 @@ func pushFieldsFromSpan(span *otelpb.Span, scopeCommonFields []logstorage.Field,
 		traceIDCache.Set([]byte(span.TraceID), nil)
 	}
 
+	addSpanIDIndexRow(lmp, int64(span.EndTimeUnixNano), span.TraceID, span.SpanID)
+	addLinkIndexRows(lmp, int64(span.EndTimeUnixNano), span.TraceID, span.SpanID, fields)
 	lmp.AddRow(int64(span.EndTimeUnixNano), fields, -1)
 
 	return fields
@@ -211,6 +225,137 @@ func NewPushSpansCallbackFunc(lmp insertutil.LogMessageProcessor) func(timestamp
 			traceIDCache.Set([]byte(traceID), nil)
 		}
 
+		var spanID string
+		for i := range fields {
+			if fields[i].Name == otelpb.SpanIDField {
+				spanID = fields[i].Value
+				break
+			}
+		}
+		addSpanIDIndexRow(lmp, timestamp, traceID, spanID)
+		addLinkIndexRows(lmp, timestamp, traceID, spanID, fields)
+
 		lmp.AddRow(timestamp, fields, -1)
 	}
 }
+
+// addSpanIDIndexRow creates an entry in the span-id-idx stream for the span if -insert.indexSpanID is set.
+//
+// The entry has the same timestamp as the span, so the span could be located precisely by the entry.
+func addSpanIDIndexRow(lmp insertutil.LogMessageProcessor, timestamp int64, traceID, spanID string) {
+	if !*indexSpanID || spanID == "" {
+		return
+	}
+	lmp.AddRow(timestamp, []logstorage.Field{
+		{Name: otelpb.SpanIDIndexStreamName, Value: strconv.FormatUint(xxhash.Sum64String(spanID)%otelpb.SpanIDIndexPartitionCount, 10)},
+		{Name: "_msg", Value: msgFieldValue},
+		{Name: otelpb.SpanIDIndexFieldName, Value: spanID},
+		{Name: otelpb.SpanIDIndexTraceIDFieldName, Value: traceID},
+	}, 1)
+}
+
+// addLinkIndexRows creates an entry in the link-idx stream for every link of the span in fields if -insert.indexSpanLinks is set.
+//
+// The entries are partitioned by the linked trace ID, so the spans linking to a trace could be found by its trace ID.
+func addLinkIndexRows(lmp insertutil.LogMessageProcessor, timestamp int64, traceID, spanID string, fields []logstorage.Field) {
+	if !*indexSpanLinks {
+		return
+	}
+
+	const traceIDPrefix = otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":"
+	const spanIDPrefix = otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":"
+
+	// group the linked trace IDs and span IDs by link index in a single pass over fields.
+	type link struct {
+		traceID string
+		spanID  string
+	}
+	var links []link
+	var linkIdxs map[string]int
+	getLink := func(idx string) *link {
+		if n, ok := linkIdxs[idx]; ok {
+			return &links[n]
+		}
+		if linkIdxs == nil {
+			linkIdxs = make(map[string]int)
+		}
+		linkIdxs[idx] = len(links)
+		links = append(links, link{})
+		return &links[len(links)-1]
+	}
+	for i := range fields {
+		if !strings.HasPrefix(fields[i].Name, otelpb.LinkPrefix) {
+			continue
+		}
+		if idx, ok := strings.CutPrefix(fields[i].Name, traceIDPrefix); ok {
+			getLink(idx).traceID = fields[i].Value
+		} else if idx, ok := strings.CutPrefix(fields[i].Name, spanIDPrefix); ok {
+			getLink(idx).spanID = fields[i].Value
+		}
+	}
+
+	for _, l := range links {
+		if l.traceID == "" {
+			continue
+		}
+		lmp.AddRow(timestamp, []logstorage.Field{
+			{Name: otelpb.LinkIndexStreamName, Value: strconv.FormatUint(xxhash.Sum64String(l.traceID)%otelpb.LinkIndexPartitionCount, 10)},
+			{Name: "_msg", Value: msgFieldValue},
+			{Name: otelpb.LinkIndexFieldName, Value: l.traceID},
+			{Name: otelpb.LinkIndexSpanIDFieldName, Value: l.spanID},
+			{Name: otelpb.LinkIndexSourceTraceIDFieldName, Value: traceID},
+			{Name: otelpb.LinkIndexSourceSpanIDFieldName, Value: spanID},
+		}, 1)
+	}
+}
+
+// appendEventSearchFields appends the event names, exception types and exception messages found in `event:*` fields
+// of the span in fields to dst. Every appended field contains the unique values of all the span events joined with "\n".
+//
+// See otelpb.EventNamesField for details.
+func appendEventSearchFields(dst, fields []logstorage.Field) []logstorage.Field {
+	const eventNamePrefix = otelpb.EventPrefix + otelpb.EventNameField + ":"
+	const exceptionTypePrefix = otelpb.EventPrefix + otelpb.EventAttrPrefix + otelpb.EventAttrExceptionType + ":"
+	const exceptionMessagePrefix = otelpb.EventPrefix + otelpb.EventAttrPrefix + otelpb.EventAttrExceptionMessage + ":"
+
+	var eventNames, exceptionTypes, exceptionMessages []string
+	for i := range fields {
+		name, value := fields[i].Name, fields[i].Value
+		if !strings.HasPrefix(name, otelpb.EventPrefix) || value == "" {
+			continue
+		}
+		if idx, ok := strings.CutPrefix(name, eventNamePrefix); ok && isEventIndex(idx) {
+			eventNames = appendUniqueValue(eventNames, value)
+		} else if idx, ok := strings.CutPrefix(name, exceptionTypePrefix); ok && isEventIndex(idx) {
+			exceptionTypes = appendUniqueValue(exceptionTypes, value)
+		} else if idx, ok := strings.CutPrefix(name, exceptionMessagePrefix); ok && isEventIndex(idx) {
+			exceptionMessages = appendUniqueValue(exceptionMessages, value)
+		}
+	}
+
+	if len(eventNames) > 0 {
+		dst = append(dst, logstorage.Field{Name: otelpb.EventNamesField, Value: strings.Join(eventNames, "\n")})
+	}
+	if len(exceptionTypes) > 0 {
+		dst = append(dst, logstorage.Field{Name: otelpb.ExceptionTypesField, Value: strings.Join(exceptionTypes, "\n")})
+	}
+	if len(exceptionMessages) > 0 {
+		dst = append(dst, logstorage.Field{Name: otelpb.ExceptionMessagesField, Value: strings.Join(exceptionMessages, "\n")})
+	}
+	return dst
+}
+
+// isEventIndex returns true if s is the event index suffix of `event:*` field name.
+//
+// It is used for distinguishing e.g. `event:event_attr:exception.type:0` from `event:event_attr:exception.type:foo:0`.
+func isEventIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func appendUniqueValue(dst []string, v string) []string {
+	if slices.Contains(dst, v) {
+		return dst
+	}
+	return append(dst, v)
+}
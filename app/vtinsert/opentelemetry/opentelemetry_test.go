@@ -0,0 +1,186 @@
+package opentelemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/google/go-cmp/cmp"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestAppendEventSearchFields(t *testing.T) {
+	f := func(fields, resultExpected []logstorage.Field) {
+		t.Helper()
+
+		result := appendEventSearchFields(nil, fields)
+		if !cmp.Equal(result, resultExpected) {
+			t.Fatalf("unexpected result; diff = %s", cmp.Diff(result, resultExpected))
+		}
+	}
+
+	// no events
+	f([]logstorage.Field{
+		{Name: otelpb.SpanIDField, Value: "s1"},
+		{Name: "span_attr:exception.type", Value: "Foo"},
+	}, nil)
+
+	// events without exceptions
+	f([]logstorage.Field{
+		{Name: "event:event_name:0", Value: "log"},
+		{Name: "event:event_name:1", Value: "retry"},
+		{Name: "event:event_name:2", Value: "log"},
+		{Name: "event:event_name:3", Value: ""},
+	}, []logstorage.Field{
+		{Name: otelpb.EventNamesField, Value: "log\nretry"},
+	})
+
+	// exceptions
+	f([]logstorage.Field{
+		{Name: "event:event_name:0", Value: "exception"},
+		{Name: "event:event_attr:exception.type:0", Value: "java.util.concurrent.TimeoutException"},
+		{Name: "event:event_attr:exception.message:0", Value: "timed out after 5s"},
+		{Name: "event:event_attr:exception.stacktrace:0", Value: "at Foo.bar()"},
+		{Name: "event:event_name:1", Value: "exception"},
+		{Name: "event:event_attr:exception.type:1", Value: "java.io.IOException"},
+		{Name: "event:event_attr:exception.type:foo:1", Value: "NotAnExceptionType"},
+		{Name: "event:event_attr:exception.type.foo:1", Value: "NotAnExceptionType"},
+	}, []logstorage.Field{
+		{Name: otelpb.EventNamesField, Value: "exception"},
+		{Name: otelpb.ExceptionTypesField, Value: "java.util.concurrent.TimeoutException\njava.io.IOException"},
+		{Name: otelpb.ExceptionMessagesField, Value: "timed out after 5s"},
+	})
+}
+
+func TestAddSpanIDIndexRow(t *testing.T) {
+	defer func(v bool) {
+		*indexSpanID = v
+	}(*indexSpanID)
+
+	const traceID = "0102030405060708090a0b0c0d0e0f10"
+	const spanID = "a1a2a3a4a5a6a7a8"
+	data := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"svc"}}]},"scopeSpans":[{"scope":{"name":"s"},"spans":[` +
+		`{"traceId":"` + traceID + `","spanId":"` + spanID + `","name":"op","startTimeUnixNano":"1700000000000000000","endTimeUnixNano":"1700000001000000000"}]}]}]}`
+
+	// f ingests the span and returns the rows matching the given LogsQL filter.
+	f := func(indexEnabled bool, filter string) []testRow {
+		t.Helper()
+
+		*indexSpanID = indexEnabled
+		tlp := &testLogMessageProcessor{}
+		if err := pushJSONLines(strings.NewReader(data), tlp); err != nil {
+			t.Fatalf("cannot push span: %s", err)
+		}
+		lf, err := logstorage.ParseFilter(filter)
+		if err != nil {
+			t.Fatalf("cannot parse filter [%s]: %s", filter, err)
+		}
+		var rows []testRow
+		for _, row := range tlp.rows {
+			if lf.MatchRow(row.fields) {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+
+	indexFilter := otelpb.SpanIDIndexFieldName + `:="` + spanID + `"`
+	spanFilter := otelpb.TraceIDField + `:="` + traceID + `" AND ` + otelpb.SpanIDField + `:="` + spanID + `"`
+
+	// the index entry isn't written if -insert.indexSpanID isn't set
+	if rows := f(false, indexFilter); len(rows) != 0 {
+		t.Fatalf("unexpected index rows when the span ID index is disabled: %v", rows)
+	}
+
+	// the index entry refers to the trace ID of the span
+	indexRows := f(true, indexFilter)
+	if len(indexRows) != 1 {
+		t.Fatalf("unexpected number of index rows; got %d; want 1", len(indexRows))
+	}
+	var indexTraceID string
+	for _, field := range indexRows[0].fields {
+		if field.Name == otelpb.SpanIDIndexTraceIDFieldName {
+			indexTraceID = field.Value
+		}
+	}
+	if indexTraceID != traceID {
+		t.Fatalf("unexpected trace ID in the index row; got %q; want %q", indexTraceID, traceID)
+	}
+
+	// the index entry mustn't be returned by trace_id and span_id queries, so only the span itself is found
+	spanRows := f(true, spanFilter)
+	if len(spanRows) != 1 {
+		t.Fatalf("unexpected number of rows matching [%s]; got %d; want 1: %v", spanFilter, len(spanRows), spanRows)
+	}
+	if rows := f(true, otelpb.TraceIDField+`:="`+traceID+`"`); len(rows) != 1 {
+		t.Fatalf("the span ID index row mustn't match trace_id queries; got %d rows: %v", len(rows), rows)
+	}
+
+	// the span is located at the timestamp of the index entry
+	if indexRows[0].timestamp != spanRows[0].timestamp {
+		t.Fatalf("unexpected timestamp of the index row; got %d; want %d", indexRows[0].timestamp, spanRows[0].timestamp)
+	}
+}
+
+func TestAddLinkIndexRows(t *testing.T) {
+	defer func(v bool) {
+		*indexSpanLinks = v
+	}(*indexSpanLinks)
+
+	const traceID = "0102030405060708090a0b0c0d0e0f10"
+	const spanID = "a1a2a3a4a5a6a7a8"
+	data := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"svc"}}]},"scopeSpans":[{"scope":{"name":"s"},"spans":[` +
+		`{"traceId":"` + traceID + `","spanId":"` + spanID + `","name":"op","startTimeUnixNano":"1700000000000000000","endTimeUnixNano":"1700000001000000000","links":[` +
+		`{"traceId":"1112131415161718191a1b1c1d1e1f20","spanId":"b1b2b3b4b5b6b7b8"},` +
+		`{"traceId":"2122232425262728292a2b2c2d2e2f30","spanId":"c1c2c3c4c5c6c7c8","attributes":[{"key":"k","value":{"stringValue":"v"}}]}]}]}]}]}`
+
+	// f ingests the span and returns the linked trace ID and span ID pairs from the link index rows.
+	f := func(indexEnabled bool, resultExpected []string) {
+		t.Helper()
+
+		*indexSpanLinks = indexEnabled
+		tlp := &testLogMessageProcessor{}
+		if err := pushJSONLines(strings.NewReader(data), tlp); err != nil {
+			t.Fatalf("cannot push span: %s", err)
+		}
+		var result []string
+		for _, row := range tlp.rows {
+			var isIndexRow bool
+			var linkedTraceID, linkedSpanID, sourceTraceID, sourceSpanID string
+			for _, field := range row.fields {
+				switch field.Name {
+				case otelpb.LinkIndexStreamName:
+					isIndexRow = true
+				case otelpb.LinkIndexFieldName:
+					linkedTraceID = field.Value
+				case otelpb.LinkIndexSpanIDFieldName:
+					linkedSpanID = field.Value
+				case otelpb.LinkIndexSourceTraceIDFieldName:
+					sourceTraceID = field.Value
+				case otelpb.LinkIndexSourceSpanIDFieldName:
+					sourceSpanID = field.Value
+				}
+			}
+			if !isIndexRow {
+				continue
+			}
+			if sourceTraceID != traceID || sourceSpanID != spanID {
+				t.Fatalf("unexpected source of the link index row; got %q/%q; want %q/%q", sourceTraceID, sourceSpanID, traceID, spanID)
+			}
+			result = append(result, linkedTraceID+"/"+linkedSpanID)
+		}
+		if !cmp.Equal(result, resultExpected) {
+			t.Fatalf("unexpected link index rows; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	// the index entries aren't written if -insert.indexSpanLinks isn't set
+	f(false, nil)
+
+	// an index entry is written for every link
+	f(true, []string{
+		"1112131415161718191a1b1c1d1e1f20/b1b2b3b4b5b6b7b8",
+		"2122232425262728292a2b2c2d2e2f30/c1c2c3c4c5c6c7c8",
+	})
+}
@@ -1,7 +1,10 @@
 package opentelemetry
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -16,6 +19,9 @@ import (
 const (
 	contentTypeProtobuf = "application/x-protobuf"
 	contentTypeJSON     = "application/json"
+	// contentTypeJSONLines is used for bulk import of ExportTraceServiceRequest messages in JSON format, one message per line.
+	// This is the format of the file exporter of OpenTelemetry Collector.
+	contentTypeJSONLines = "application/x-ndjson"
 )
 
 var (
@@ -24,8 +30,12 @@ var (
 	requestsJSONTotal     = metrics.NewCounter(`vt_http_requests_total{path="/insert/opentelemetry/v1/traces",format="json"}`)
 	errorsJSONTotal       = metrics.NewCounter(`vt_http_errors_total{path="/insert/opentelemetry/v1/traces",format="json"}`)
 
-	requestProtobufDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/opentelemetry/v1/traces",format="protobuf"}`)
-	requestJSONDuration     = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/opentelemetry/v1/traces",format="json"}`)
+	requestsJSONLinesTotal = metrics.NewCounter(`vt_http_requests_total{path="/insert/opentelemetry/v1/traces",format="jsonl"}`)
+	errorsJSONLinesTotal   = metrics.NewCounter(`vt_http_errors_total{path="/insert/opentelemetry/v1/traces",format="jsonl"}`)
+
+	requestProtobufDuration  = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/opentelemetry/v1/traces",format="protobuf"}`)
+	requestJSONDuration      = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/opentelemetry/v1/traces",format="json"}`)
+	requestJSONLinesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/insert/opentelemetry/v1/traces",format="jsonl"}`)
 )
 
 // RequestHandler processes Opentelemetry insert requests
@@ -46,8 +56,10 @@ func handleTracesRequest(r *http.Request, w http.ResponseWriter) bool {
 		handleProtobufRequest(r, w)
 	case contentTypeJSON:
 		handleJSONRequest(r, w)
+	case contentTypeJSONLines:
+		handleJSONLinesRequest(r, w)
 	default:
-		httpserver.Errorf(w, r, "Content-Type %s isn't supported for opentelemetry format. Use protobuf or JSON encoding", contentType)
+		httpserver.Errorf(w, r, "Content-Type %s isn't supported for opentelemetry format. Use protobuf, JSON or JSON lines encoding", contentType)
 		return false
 	}
 	return true
@@ -131,7 +143,7 @@ func handleJSONRequest(r *http.Request, w http.ResponseWriter) {
 			errorsJSONTotal.Inc()
 			return fmt.Errorf("cannot unmarshal request from %d protobuf bytes: %w", len(data), callbackErr)
 		}
-		callbackErr = pushExportTraceServiceRequest(&req, lmp)
+		callbackErr = PushExportTraceServiceRequest(&req, lmp)
 		lmp.MustClose()
 		return callbackErr
 	})
@@ -144,3 +156,73 @@ func handleJSONRequest(r *http.Request, w http.ResponseWriter) {
 	// since their timings are usually much smaller than the timing for successful request parsing.
 	requestJSONDuration.UpdateDuration(startTime)
 }
+
+// handleJSONLinesRequest imports ExportTraceServiceRequest messages in JSON format, one message per line.
+//
+// Unlike handleJSONRequest, the request body isn't read into memory at once, so it could be used for bulk import of big files.
+func handleJSONLinesRequest(r *http.Request, w http.ResponseWriter) {
+	startTime := time.Now()
+	requestsJSONLinesTotal.Inc()
+
+	cp, err := insertutil.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot parse common params from request: %s", err)
+		return
+	}
+	// stream fields must contain the service name and span name.
+	// by using arguments and headers, users can also add other fields as stream fields
+	// for potentially better efficiency.
+	cp.StreamFields = append(mandatoryStreamFields, cp.StreamFields...)
+
+	if err = insertutil.CanWriteData(); err != nil {
+		httpserver.Errorf(w, r, "%s", err)
+		return
+	}
+
+	encoding := r.Header.Get("Content-Encoding")
+	reader, err := protoparserutil.GetUncompressedReader(r.Body, encoding)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot read OpenTelemetry protocol data: %s", err)
+		return
+	}
+	defer protoparserutil.PutUncompressedReader(reader)
+
+	lmp := cp.NewLogMessageProcessor("opentelemetry_traces_otlphttp_jsonl", false)
+	err = pushJSONLines(reader, lmp)
+	lmp.MustClose()
+	if err != nil {
+		errorsJSONLinesTotal.Inc()
+		httpserver.Errorf(w, r, "cannot read OpenTelemetry protocol data: %s", err)
+		return
+	}
+	// update requestJSONLinesDuration only for successfully parsed requests
+	requestJSONLinesDuration.UpdateDuration(startTime)
+}
+
+// pushJSONLines reads ExportTraceServiceRequest messages in JSON format from r line by line, and pushes them to lmp.
+//
+// Empty lines are skipped. Every line cannot exceed -opentelemetry.traces.maxRequestSize.
+// The lines before the invalid line are pushed to lmp.
+func pushJSONLines(r io.Reader, lmp insertutil.LogMessageProcessor) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxRequestSize.IntN())
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var req otelpb.ExportTraceServiceRequest
+		if err := req.UnmarshalJSONCustom(line); err != nil {
+			return fmt.Errorf("cannot unmarshal line #%d with %d bytes: %w", lineNum, len(line), err)
+		}
+		if err := PushExportTraceServiceRequest(&req, lmp); err != nil {
+			return fmt.Errorf("cannot push line #%d: %w", lineNum, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("cannot read line #%d: %w", lineNum+1, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package opentelemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+type testLogMessageProcessor struct {
+	spanIDs []string
+	rows    []testRow
+}
+
+type testRow struct {
+	timestamp int64
+	fields    []logstorage.Field
+}
+
+func (tlp *testLogMessageProcessor) AddRow(timestamp int64, fields []logstorage.Field, _ int) {
+	tlp.rows = append(tlp.rows, testRow{
+		timestamp: timestamp,
+		fields:    append([]logstorage.Field{}, fields...),
+	})
+	for _, f := range fields {
+		if f.Name == otelpb.SpanIDField {
+			tlp.spanIDs = append(tlp.spanIDs, f.Value)
+		}
+	}
+}
+
+func (tlp *testLogMessageProcessor) MustClose() {}
+
+func TestPushJSONLines(t *testing.T) {
+	f := func(data string, spanIDsExpected []string, errorMsg string) {
+		t.Helper()
+
+		tlp := &testLogMessageProcessor{}
+		var errMsgGot string
+		if err := pushJSONLines(strings.NewReader(data), tlp); err != nil {
+			errMsgGot = err.Error()
+		}
+		if !strings.HasPrefix(errMsgGot, errorMsg) {
+			t.Fatalf("unexpected error; got %q; want prefix %q", errMsgGot, errorMsg)
+		}
+		if strings.Join(tlp.spanIDs, ",") != strings.Join(spanIDsExpected, ",") {
+			t.Fatalf("unexpected span IDs; got %q; want %q", tlp.spanIDs, spanIDsExpected)
+		}
+	}
+
+	line := func(spanID string) string {
+		return `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"svc"}}]},"scopeSpans":[{"scope":{"name":"s"},"spans":[` +
+			`{"traceId":"0102030405060708090a0b0c0d0e0f10","spanId":"` + spanID + `","name":"op","startTimeUnixNano":"1","endTimeUnixNano":"2"}]}]}]}`
+	}
+
+	f("", nil, "")
+	f(line("0102030405060708")+"\n\n"+line("1112131415161718")+"\n", []string{"0102030405060708", "1112131415161718"}, "")
+
+	// the lines before the invalid line are pushed
+	f(line("0102030405060708")+"\nfoobar\n"+line("1112131415161718"), []string{"0102030405060708"}, "cannot unmarshal line #2 with 6 bytes")
+}
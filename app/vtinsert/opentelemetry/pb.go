@@ -263,6 +263,7 @@ func decodeSpan(src []byte, fs *logstorage.Fields, fb *fmtBuffer) (startTimeUnix
 		// endTimeUnixNano uint64
 		traceID string
 	)
+	spanFieldsStart := len(fs.Fields)
 	for len(src) > 0 {
 		src, err = fc.NextField(src)
 		if err != nil {
@@ -383,6 +384,10 @@ func decodeSpan(src []byte, fs *logstorage.Fields, fb *fmtBuffer) (startTimeUnix
 		}
 	}
 
+	if eventIdx > 0 {
+		fs.Fields = appendEventSearchFields(fs.Fields, fs.Fields[spanFieldsStart:])
+	}
+
 	if endTimeUnixNano > 0 && startTimeUnixNano > 0 {
 		fs.Add(pb.DurationField, strconv.FormatUint(endTimeUnixNano-startTimeUnixNano, 10))
 	}
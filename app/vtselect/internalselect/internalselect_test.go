@@ -0,0 +1,98 @@
+package internalselect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage/netselect"
+)
+
+func TestTenantIDsRequestRoundTrip(t *testing.T) {
+	defer func(f func(ctx context.Context, start, end int64) ([]logstorage.TenantID, error)) {
+		getTenantIDs = f
+	}(getTenantIDs)
+
+	// every storage node returns its own tenantIDs for the requested time range.
+	nodeTenantIDs := map[string][]logstorage.TenantID{}
+	newStorageNode := func(tenantIDs ...logstorage.TenantID) string {
+		t.Helper()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), nodeAddrKey{}, r.Host)
+			RequestHandler(ctx, w, r)
+		}))
+		t.Cleanup(srv.Close)
+		addr := strings.TrimPrefix(srv.URL, "http://")
+		nodeTenantIDs[addr] = tenantIDs
+		return addr
+	}
+	var requestedRangesLock sync.Mutex
+	var requestedRanges []string
+	getTenantIDs = func(ctx context.Context, start, end int64) ([]logstorage.TenantID, error) {
+		requestedRangesLock.Lock()
+		requestedRanges = append(requestedRanges, fmt.Sprintf("[%d, %d]", start, end))
+		requestedRangesLock.Unlock()
+		if start > end {
+			return nil, fmt.Errorf("start=%d cannot exceed end=%d", start, end)
+		}
+		return nodeTenantIDs[ctx.Value(nodeAddrKey{}).(string)], nil
+	}
+
+	addrs := []string{
+		newStorageNode(logstorage.TenantID{AccountID: 0, ProjectID: 0}, logstorage.TenantID{AccountID: 1, ProjectID: 2}),
+		newStorageNode(logstorage.TenantID{AccountID: 1, ProjectID: 2}, logstorage.TenantID{AccountID: 3, ProjectID: 0}),
+		newStorageNode(),
+	}
+	ac, err := (&promauth.Options{}).NewConfig()
+	if err != nil {
+		t.Fatalf("cannot create auth config: %s", err)
+	}
+	s := netselect.NewStorage(addrs, []*promauth.Config{ac, ac, ac}, []bool{false, false, false}, false)
+	defer s.MustStop()
+
+	// the tenantIDs of all the storage nodes are merged and deduplicated
+	tenantIDs, err := s.GetTenantIDs(context.Background(), 1000, 2000)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	sort.Slice(tenantIDs, func(i, j int) bool {
+		a, b := tenantIDs[i], tenantIDs[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		return a.ProjectID < b.ProjectID
+	})
+	tenantIDsExpected := []logstorage.TenantID{
+		{AccountID: 0, ProjectID: 0},
+		{AccountID: 1, ProjectID: 2},
+		{AccountID: 3, ProjectID: 0},
+	}
+	if !cmp.Equal(tenantIDs, tenantIDsExpected) {
+		t.Fatalf("unexpected tenantIDs; diff = %s", cmp.Diff(tenantIDs, tenantIDsExpected))
+	}
+	for _, r := range requestedRanges {
+		if r != "[1000, 2000]" {
+			t.Fatalf("unexpected time range passed to the storage node; got %s; want [1000, 2000]", r)
+		}
+	}
+	if len(requestedRanges) != len(addrs) {
+		t.Fatalf("unexpected number of requests to storage nodes; got %d; want %d", len(requestedRanges), len(addrs))
+	}
+
+	// the error at storage node is returned to the caller
+	if _, err := s.GetTenantIDs(context.Background(), 2000, 1000); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
+type nodeAddrKey struct{}
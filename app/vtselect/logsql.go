@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/metrics"
 
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/logsql"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
 )
 
@@ -208,10 +211,61 @@ func processDeleteRunTaskRequest(ctx context.Context, w http.ResponseWriter, r *
 		return
 	}
 
+	// The deleted spans may contain the last occurrences of some service names and span names.
+	query.InvalidateNameListCache(tenantIDs)
+	query.InvalidateTraceCache(tenantIDs)
+
+	// The spans are deleted in background, so they and their names may be put to the caches again by the requests executed
+	// while the task is running. Invalidate the caches once again after the task is finished.
+	deleteTasksWG.Add(1)
+	go func() {
+		defer deleteTasksWG.Done()
+		waitForDeleteTask(taskID)
+		query.InvalidateNameListCache(tenantIDs)
+		query.InvalidateTraceCache(tenantIDs)
+	}()
+
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{"task_id":%q}`, taskID)
 }
 
+var deleteTasksWG sync.WaitGroup
+
+// deleteTaskCheckInterval is the interval for checking whether the delete task is finished.
+const deleteTaskCheckInterval = time.Second
+
+// waitForDeleteTask waits until the delete task with the given taskID is finished or vtselect is stopped.
+func waitForDeleteTask(taskID string) {
+	ticker := time.NewTicker(deleteTaskCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		tasks, err := vtstorage.DeleteActiveTasks(context.Background())
+		if err != nil {
+			logger.Warnf("cannot obtain active delete tasks while waiting for task_id=%q: %s", taskID, err)
+			continue
+		}
+		if !hasDeleteTask(tasks, taskID) {
+			return
+		}
+	}
+}
+
+func hasDeleteTask(tasks []*logstorage.DeleteTask, taskID string) bool {
+	for _, task := range tasks {
+		if task.TaskID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
 func processDeleteStopTaskRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	taskID := r.FormValue("task_id")
 	if taskID == "" {
@@ -19,6 +19,9 @@ import (
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/internalselect"
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/logsql"
 	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/jaeger"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/native"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/otlp"
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/zipkin"
 )
 
 var (
@@ -56,12 +59,18 @@ func getDefaultMaxConcurrentRequests() int {
 // Init initializes vtselect
 func Init() {
 	concurrencyLimitCh = make(chan struct{}, *maxConcurrentRequests)
+	stopCh = make(chan struct{})
 }
 
 // Stop stops vtselect
 func Stop() {
+	close(stopCh)
+	deleteTasksWG.Wait()
 }
 
+// stopCh is closed when vtselect is stopped.
+var stopCh chan struct{}
+
 var concurrencyLimitCh chan struct{}
 
 var (
@@ -197,6 +206,19 @@ func selectHandler(w http.ResponseWriter, r *http.Request, path string) bool {
 		// Could be used by Grafana Jaeger datasource, Jaeger UI, and more.
 		return jaeger.RequestHandler(ctxWithTimeout, w, r)
 	}
+	if strings.HasPrefix(path, "/select/zipkin/") {
+		// Zipkin HTTP APIs for distributed tracing.
+		// Could be used by Grafana Zipkin datasource, Zipkin UI, and more.
+		return zipkin.RequestHandler(ctxWithTimeout, w, r)
+	}
+	if strings.HasPrefix(path, "/select/otlp/") {
+		// OTLP export APIs for downloading traces in their original OTLP form.
+		return otlp.RequestHandler(ctxWithTimeout, w, r)
+	}
+	if strings.HasPrefix(path, "/select/traces/") {
+		// VictoriaTraces native HTTP APIs for distributed tracing.
+		return native.RequestHandler(ctxWithTimeout, w, r)
+	}
 
 	ok := processSelectRequest(ctxWithTimeout, w, r, path)
 	if !ok {
@@ -1,6 +1,7 @@
 package jaeger
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/metrics"
@@ -31,6 +33,9 @@ var (
 	jaegerOperationsRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/services/*/operations"}`)
 	jaegerOperationsDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/services/*/operations"}`)
 
+	jaegerOperationsV2Requests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/operations"}`)
+	jaegerOperationsV2Duration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/operations"}`)
+
 	jaegerTracesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/traces"}`)
 	jaegerTracesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/traces"}`)
 
@@ -39,6 +44,18 @@ var (
 
 	jaegerDependenciesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/dependencies"}`)
 	jaegerDependenciesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/dependencies"}`)
+
+	jaegerMetricsLatenciesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/metrics/latencies"}`)
+	jaegerMetricsLatenciesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/metrics/latencies"}`)
+
+	jaegerMetricsCallsRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/metrics/calls"}`)
+	jaegerMetricsCallsDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/metrics/calls"}`)
+
+	jaegerMetricsErrorsRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/metrics/errors"}`)
+	jaegerMetricsErrorsDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/metrics/errors"}`)
+
+	jaegerMetricsMinStepRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/jaeger/api/metrics/minstep"}`)
+	jaegerMetricsMinStepDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/jaeger/api/metrics/minstep"}`)
 )
 
 // RequestHandler is the entry point for all Jaeger query APIs.
@@ -61,6 +78,11 @@ func RequestHandler(ctx context.Context, w http.ResponseWriter, r *http.Request)
 		processGetOperationsRequest(ctx, w, r)
 		jaegerOperationsDuration.UpdateDuration(startTime)
 		return true
+	} else if path == "/select/jaeger/api/operations" {
+		jaegerOperationsV2Requests.Inc()
+		processGetOperationsV2Request(ctx, w, r)
+		jaegerOperationsV2Duration.UpdateDuration(startTime)
+		return true
 	} else if path == "/select/jaeger/api/traces" {
 		jaegerTracesRequests.Inc()
 		processGetTracesRequest(ctx, w, r)
@@ -76,6 +98,27 @@ func RequestHandler(ctx context.Context, w http.ResponseWriter, r *http.Request)
 		processGetDependenciesRequest(ctx, w, r)
 		jaegerDependenciesDuration.UpdateDuration(startTime)
 		return true
+	} else if path == "/select/jaeger/api/metrics/latencies" {
+		jaegerMetricsLatenciesRequests.Inc()
+		processGetMetricsLatenciesRequest(ctx, w, r)
+		jaegerMetricsLatenciesDuration.UpdateDuration(startTime)
+		return true
+	} else if path == "/select/jaeger/api/metrics/calls" {
+		jaegerMetricsCallsRequests.Inc()
+		processGetMetricsCallsRequest(ctx, w, r)
+		jaegerMetricsCallsDuration.UpdateDuration(startTime)
+		return true
+	} else if path == "/select/jaeger/api/metrics/errors" {
+		jaegerMetricsErrorsRequests.Inc()
+		processGetMetricsErrorsRequest(ctx, w, r)
+		jaegerMetricsErrorsDuration.UpdateDuration(startTime)
+		return true
+	} else if path == "/select/jaeger/api/metrics/minstep" {
+		jaegerMetricsMinStepRequests.Inc()
+		w.Header().Set("Content-Type", "application/json")
+		WriteGetMinStepResponse(w, metricsMinStep.Milliseconds())
+		jaegerMetricsMinStepDuration.UpdateDuration(startTime)
+		return true
 	}
 	return false
 }
@@ -89,7 +132,18 @@ func processGetServicesRequest(ctx context.Context, w http.ResponseWriter, r *ht
 		return
 	}
 
-	serviceList, err := query.GetServiceNameList(ctx, cp)
+	start, end, ok, err := parseJaegerTimeRange(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect time range: %s", err)
+		return
+	}
+
+	var serviceList []string
+	if ok {
+		serviceList, err = query.GetServiceNameListInTimeRange(ctx, cp, start, end)
+	} else {
+		serviceList, err = query.GetServiceNameList(ctx, cp)
+	}
 	if err != nil {
 		httpserver.Errorf(w, r, "cannot get services list: %s", err)
 		return
@@ -124,7 +178,18 @@ func processGetOperationsRequest(ctx context.Context, w http.ResponseWriter, r *
 		return
 	}
 
-	operationList, err := query.GetSpanNameList(ctx, cp, serviceName)
+	start, end, ok, err := parseJaegerTimeRange(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect time range: %s", err)
+		return
+	}
+
+	var operationList []string
+	if ok {
+		operationList, err = query.GetSpanNameListInTimeRange(ctx, cp, serviceName, start, end)
+	} else {
+		operationList, err = query.GetSpanNameList(ctx, cp, serviceName)
+	}
 	if err != nil {
 		httpserver.Errorf(w, r, "cannot get operation list: %s", err)
 		return
@@ -135,6 +200,103 @@ func processGetOperationsRequest(ctx context.Context, w http.ResponseWriter, r *
 	WriteGetOperationsResponse(w, operationList)
 }
 
+// processGetOperationsV2Request handle the Jaeger /api/operations API request, which returns span names with span kinds.
+// https://github.com/jaegertracing/jaeger/blob/9a45f522422c548827b2f3897affc8170e4a3d8b/cmd/query/app/http_handler.go#L184
+func processGetOperationsV2Request(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	q := r.URL.Query()
+	serviceName := q.Get("service")
+	if serviceName == "" {
+		httpserver.Errorf(w, r, "service name is required")
+		return
+	}
+	spanKind := q.Get("spanKind")
+	kind := ""
+	if spanKind != "" {
+		var ok bool
+		kind, ok = spanKindMap[spanKind]
+		if !ok {
+			httpserver.Errorf(w, r, "unsupported spanKind [%s]", spanKind)
+			return
+		}
+	}
+
+	start, end, ok, err := parseJaegerTimeRange(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect time range: %s", err)
+		return
+	}
+
+	var operationList []*query.Operation
+	if ok {
+		operationList, err = query.GetOperationListInTimeRange(ctx, cp, serviceName, start, end)
+	} else {
+		operationList, err = query.GetOperationList(ctx, cp, serviceName)
+	}
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get operation list: %s", err)
+		return
+	}
+
+	operations := make([]operation, 0, len(operationList))
+	for _, op := range operationList {
+		if kind != "" && op.Kind != kind {
+			continue
+		}
+		operations = append(operations, operation{
+			name:     op.Name,
+			spanKind: spanKindName(op.Kind),
+		})
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].name != operations[j].name {
+			return operations[i].name < operations[j].name
+		}
+		return operations[i].spanKind < operations[j].spanKind
+	})
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetOperationsV2Response(w, operations)
+}
+
+// parseJaegerTimeRange parses the optional `start` and `end` params in unix microseconds.
+//
+// false is returned if none of them is set. Otherwise, the missing `start` defaults to the unix epoch,
+// and the missing `end` defaults to the current time.
+func parseJaegerTimeRange(r *http.Request) (time.Time, time.Time, bool, error) {
+	q := r.URL.Query()
+	startStr, endStr := q.Get("start"), q.Get("end")
+	if startStr == "" && endStr == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	start, end := time.Unix(0, 0), time.Now()
+	if startStr != "" {
+		unixMicro, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("cannot parse start [%s]: %w", startStr, err)
+		}
+		start = time.UnixMicro(unixMicro)
+	}
+	if endStr != "" {
+		unixMicro, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("cannot parse end [%s]: %w", endStr, err)
+		}
+		end = time.UnixMicro(unixMicro)
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("start [%s] cannot be after end [%s]", startStr, endStr)
+	}
+	return start, end, true, nil
+}
+
 // processGetTraceRequest handle the Jaeger /api/traces/<trace_id> API request.
 // https://github.com/jaegertracing/jaeger/blob/9a45f522422c548827b2f3897affc8170e4a3d8b/cmd/query/app/http_handler.go#L465
 func processGetTraceRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -152,58 +314,111 @@ func processGetTraceRequest(ctx context.Context, w http.ResponseWriter, r *http.
 		return
 	}
 
-	rows, err := query.GetTrace(ctx, cp, traceID)
-	if err != nil {
-		httpserver.Errorf(w, r, "cannot get traces: %s", err)
-		return
+	param := &query.TraceStreamParam{}
+	if maxDepth := r.URL.Query().Get("maxDepth"); maxDepth != "" {
+		param.MaxDepth, err = strconv.Atoi(maxDepth)
+		if err != nil || param.MaxDepth < 0 {
+			httpserver.Errorf(w, r, "cannot parse maxDepth [%s]: it must be a non-negative integer", maxDepth)
+			return
+		}
 	}
 
-	if len(rows) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		WriteGetTraceResponse(w, nil)
-		return
-	}
+	writeStreamedTrace(w, r, traceID, func(writeSpan func(fields []logstorage.Field)) (*query.TraceStreamResult, error) {
+		return query.StreamTrace(ctx, cp, traceID, param, writeSpan)
+	})
+}
 
-	t := &trace{}
-	processHashIDMap := make(map[uint64]string)     // process name -> process id
-	processIDProcessMap := make(map[string]process) // process id -> process
-	for i := range rows {
-		var sp *span
-		sp, err = fieldsToSpan(rows[i].Fields)
+// writeStreamedTrace writes the spans passed by streamTrace to w in Jaeger's /api/traces/<trace_id> response format.
+//
+// The spans are converted and written to the response as soon as they are read from the storage,
+// so big traces aren't kept in memory. If streamTrace fails after a part of the response has been sent to the client,
+// the error is logged and the response is left unterminated, so the client sees a truncated response instead of malformed JSON.
+func writeStreamedTrace(w http.ResponseWriter, r *http.Request, traceID string, streamTrace func(writeSpan func(fields []logstorage.Field)) (*query.TraceStreamResult, error)) {
+	sw := &sentTracker{w: w}
+	bw := bufio.NewWriterSize(sw, 64*1024)
+	processHashIDMap := make(map[uint64]string) // process hash -> process id
+	var processes []processMap
+	spansWritten := 0
+	writeSpan := func(fields []logstorage.Field) {
+		sp, err := fieldsToSpan(fields)
 		if err != nil {
-			logger.Errorf("cannot unmarshal log fields [%v] to span: %s", rows[i].Fields, err)
-			continue
+			logger.Errorf("cannot unmarshal log fields [%v] to span: %s", fields, err)
+			return
 		}
 
 		// Process ID
 		processHash := hashProcess(sp.process)
-		if _, ok := processHashIDMap[processHash]; !ok {
-			processID := "p" + strconv.Itoa(len(processHashIDMap)+1)
+		processID, ok := processHashIDMap[processHash]
+		if !ok {
+			processID = "p" + strconv.Itoa(len(processHashIDMap)+1)
 			processHashIDMap[processHash] = processID
-			processIDProcessMap[processID] = sp.process
+			// the fields are valid only during the call, so the process must be cloned.
+			processes = append(processes, processMap{
+				processID: processID,
+				process:   cloneProcess(sp.process),
+			})
 		}
+		sp.processID = processID
 
-		sp.processID = processHashIDMap[processHash]
-		t.spans = append(t.spans, sp)
+		if spansWritten == 0 {
+			writestreamedTraceHead(bw)
+		} else {
+			_, _ = bw.WriteString(",")
+		}
+		writespanJson(bw, sp)
+		spansWritten++
 	}
 
-	// 6. attach process info to this trace
-	t.processMap = make([]processMap, 0, len(processIDProcessMap))
-	for processID, p := range processIDProcessMap {
-		t.processMap = append(t.processMap, processMap{
-			processID: processID,
-			process:   p,
-		})
+	tsr, err := streamTrace(writeSpan)
+	if err != nil {
+		if !sw.sent {
+			// nothing has been sent to the client yet, so the buffered spans can be dropped in favor of the error response.
+			httpserver.Errorf(w, r, "cannot get traces: %s", err)
+			return
+		}
+		logger.Errorf("cannot stream trace %q after sending %d spans to the client; the response is truncated: %s", traceID, spansWritten, err)
+		return
 	}
 
-	sort.Slice(t.processMap, func(i, j int) bool {
-		return t.processMap[i].processID < t.processMap[j].processID
-	})
+	if spansWritten == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		WriteGetTraceResponse(w, nil)
+		return
+	}
 
-	// Write results
-	w.Header().Set("Content-Type", "application/json")
-	WriteGetTraceResponse(w, t)
+	writestreamedTraceTail(bw, traceID, processes, tsr.Warnings())
+	_ = bw.Flush()
+}
+
+// sentTracker tracks whether the response has been started to be sent to the client.
+type sentTracker struct {
+	w    http.ResponseWriter
+	sent bool
+}
+
+// Write implements io.Writer.
+func (st *sentTracker) Write(p []byte) (int, error) {
+	if !st.sent {
+		st.w.Header().Set("Content-Type", "application/json")
+		st.sent = true
+	}
+	return st.w.Write(p)
+}
+
+// cloneProcess returns a copy of p, which doesn't refer to the original strings.
+func cloneProcess(p process) process {
+	tags := make([]keyValue, len(p.tags))
+	for i, tag := range p.tags {
+		tags[i] = keyValue{
+			key:  strings.Clone(tag.key),
+			vStr: strings.Clone(tag.vStr),
+		}
+	}
+	return process{
+		serviceName: strings.Clone(p.serviceName),
+		tags:        tags,
+	}
 }
 
 // processGetTracesRequest handle the Jaeger /api/traces API request.
@@ -215,21 +430,37 @@ func processGetTracesRequest(ctx context.Context, w http.ResponseWriter, r *http
 		return
 	}
 
-	param, err := parseJaegerTraceQueryParam(ctx, r)
-	if err != nil {
-		httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
-		return
-	}
+	var traceIDList []string
+	var rows []*query.Row
+	var nextCursor *query.TraceCursor
+	if traceIDs := r.URL.Query()["traceID"]; len(traceIDs) > 0 {
+		// lookup traces by the given trace IDs. other search params are ignored, the same as Jaeger does.
+		if len(traceIDs) > maxLimit {
+			httpserver.Errorf(w, r, "the number of traceID should be not higher than %d", maxLimit)
+			return
+		}
+		traceIDList, rows, err = query.GetTracesByIDs(ctx, cp, traceIDs)
+		if err != nil {
+			httpserver.Errorf(w, r, "get traces by trace IDs error: %s", err)
+			return
+		}
+	} else {
+		param, err := parseJaegerTraceQueryParam(ctx, r)
+		if err != nil {
+			httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
+			return
+		}
 
-	traceIDList, rows, err := query.GetTraceList(ctx, cp, param)
-	if err != nil {
-		httpserver.Errorf(w, r, "get trace list error: %s", err)
-		return
+		traceIDList, rows, nextCursor, err = query.GetTraceList(ctx, cp, param)
+		if err != nil {
+			httpserver.Errorf(w, r, "get trace list error: %s", err)
+			return
+		}
 	}
 	if len(rows) == 0 {
 		// Write empty results
 		w.Header().Set("Content-Type", "application/json")
-		WriteGetTracesResponse(w, nil)
+		WriteGetTracesResponse(w, nil, nil)
 		return
 	}
 
@@ -292,7 +523,7 @@ func processGetTracesRequest(ctx context.Context, w http.ResponseWriter, r *http
 
 	// Write results
 	w.Header().Set("Content-Type", "application/json")
-	WriteGetTracesResponse(w, traces)
+	WriteGetTracesResponse(w, traces, nextCursor)
 }
 
 // parseJaegerTraceQueryParam parse Jaeger request to unified query.TraceQueryParam.
@@ -338,6 +569,18 @@ func parseJaegerTraceQueryParam(_ context.Context, r *http.Request) (*query.Trac
 		}
 	}
 
+	if cursor := q.Get("cursor"); cursor != "" {
+		p.Cursor, err = query.ParseTraceCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse cursor [%s]: %w", cursor, err)
+		}
+	}
+
+	p.SortOrder, err = query.ParseTraceSortOrder(q.Get("sort"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse sort: %w", err)
+	}
+
 	startTimeMin := q.Get("start")
 	if startTimeMin != "" {
 		unixNano, err := strconv.ParseInt(startTimeMin, 10, 64)
@@ -363,6 +606,15 @@ func parseJaegerTraceQueryParam(_ context.Context, r *http.Request) (*query.Trac
 		}
 	}
 
+	// the attribute filter expression is passed via the special `vt.filter` tag.
+	if filter, ok := p.Attributes[filterTagKey]; ok {
+		delete(p.Attributes, filterTagKey)
+		p.Filter, err = query.ParseAttributeFilter(filter, toFilterField)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s tag [%s]: %w", filterTagKey, filter, err)
+		}
+	}
+
 	attributesFilter := make(map[string]string, len(p.Attributes))
 	// some special fields in the OpenTelemetry span will be treated as span attributes/tags
 	// in query result, so they should be converted to proper filters correspondingly.
@@ -391,6 +643,34 @@ func parseJaegerTraceQueryParam(_ context.Context, r *http.Request) (*query.Trac
 	return p, nil
 }
 
+// filterTagKey is the special tag key for passing the attribute filter expression via the `tags` param.
+// e.g.: `tags={"vt.filter":"http.status_code>=500 OR error=true"}`.
+//
+// See query.AttributeFilter for the filter syntax.
+const filterTagKey = "vt.filter"
+
+// toFilterField converts the Jaeger tag key and value in filter expression to the field name and value in storage.
+// It follows the same conversion as the `tags` param.
+func toFilterField(k, v string) (string, string) {
+	if field, ok := spanAttributeMap[k]; ok {
+		switch k {
+		case "error":
+			if code, ok := errorStatusCodeMap[v]; ok {
+				v = code
+			}
+		case "span.kind":
+			if kind, ok := spanKindMap[v]; ok {
+				v = kind
+			}
+		}
+		return field, v
+	}
+	if strings.HasPrefix(k, otelpb.InstrumentationScopeAttrPrefix) || strings.HasPrefix(k, otelpb.ResourceAttrPrefix) {
+		return k, v
+	}
+	return otelpb.SpanAttrPrefixField + k, v
+}
+
 // hashProcess generate hash result for a process according to its tags.
 func hashProcess(process process) uint64 {
 	d := hashpool.Get()
@@ -422,44 +702,15 @@ func processGetDependenciesRequest(ctx context.Context, w http.ResponseWriter, r
 		return
 	}
 
-	rows, err := query.GetServiceGraphList(ctx, cp, param)
+	edges, err := query.GetServiceGraphEdges(ctx, cp, param)
 	if err != nil {
 		httpserver.Errorf(w, r, "get dependencies error: %s", err)
 		return
 	}
 
-	if len(rows) == 0 {
-		// Write empty results
-		w.Header().Set("Content-Type", "application/json")
-		WriteGetDependenciesResponse(w, nil)
-		return
-	}
-
-	dependencies := make([]*dependencyLink, 0)
-	for _, row := range rows {
-		dependency := &dependencyLink{}
-		for _, f := range row.Fields {
-			switch f.Name {
-			case "parent":
-				dependency.parent = f.Value
-			case "child":
-				dependency.child = f.Value
-			case "callCount":
-				dependency.callCount, err = strconv.ParseUint(f.Value, 10, 64)
-				if err != nil {
-					logger.Errorf("cannot parse callCount [%s]: %s", f.Value, err)
-					continue
-				}
-			}
-		}
-		if dependency.parent != "" && dependency.child != "" && dependency.callCount > 0 {
-			dependencies = append(dependencies, dependency)
-		}
-	}
-
 	// Write results
 	w.Header().Set("Content-Type", "application/json")
-	WriteGetDependenciesResponse(w, dependencies)
+	WriteGetDependenciesResponse(w, edges)
 }
 
 // parseJaegerDependenciesQueryParam parse Jaeger request to unified ServiceGraphQueryParameters.
@@ -495,3 +746,212 @@ func parseJaegerDependenciesQueryParam(_ context.Context, r *http.Request) (*que
 
 	return p, nil
 }
+
+// default params and limits of the Jaeger Service Performance Monitoring (SPM) APIs.
+//
+// See: https://github.com/jaegertracing/jaeger/blob/9a45f522422c548827b2f3897affc8170e4a3d8b/cmd/query/app/query_parser.go#L44
+const (
+	metricsDefaultLookback = time.Hour
+	metricsDefaultStep     = 5 * time.Second
+	metricsDefaultRatePer  = 10 * time.Minute
+	metricsMinStep         = time.Second
+)
+
+// processGetMetricsLatenciesRequest handle the Jaeger /api/metrics/latencies API request.
+func processGetMetricsLatenciesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseJaegerMetricsQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect metrics query params: %s", err)
+		return
+	}
+
+	quantileStr := r.URL.Query().Get("quantile")
+	if quantileStr == "" {
+		httpserver.Errorf(w, r, "missing quantile param")
+		return
+	}
+	quantile, err := strconv.ParseFloat(quantileStr, 64)
+	if err != nil || quantile <= 0 || quantile > 1 {
+		httpserver.Errorf(w, r, "quantile must be in the range (0, 1]; got %q", quantileStr)
+		return
+	}
+
+	series, err := query.GetSpanLatencies(ctx, cp, param, quantile)
+	if err != nil {
+		httpserver.Errorf(w, r, "get latencies error: %s", err)
+		return
+	}
+
+	mf := &metricFamily{
+		name:   "service_latencies",
+		help:   fmt.Sprintf("%.2fth quantile latency, grouped by service", quantile),
+		series: series,
+	}
+	if param.GroupByOperation {
+		mf.name = "service_operation_latencies"
+		mf.help = fmt.Sprintf("%.2fth quantile latency, grouped by service & operation", quantile)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetMetricsResponse(w, mf)
+}
+
+// processGetMetricsCallsRequest handle the Jaeger /api/metrics/calls API request.
+func processGetMetricsCallsRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseJaegerMetricsQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect metrics query params: %s", err)
+		return
+	}
+
+	series, err := query.GetSpanCallRates(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get call rates error: %s", err)
+		return
+	}
+
+	mf := &metricFamily{
+		name:   "service_call_rate",
+		help:   "calls/sec, grouped by service",
+		series: series,
+	}
+	if param.GroupByOperation {
+		mf.name = "service_operation_call_rate"
+		mf.help = "calls/sec, grouped by service & operation"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetMetricsResponse(w, mf)
+}
+
+// processGetMetricsErrorsRequest handle the Jaeger /api/metrics/errors API request.
+func processGetMetricsErrorsRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseJaegerMetricsQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect metrics query params: %s", err)
+		return
+	}
+
+	series, err := query.GetSpanErrorRates(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get error rates error: %s", err)
+		return
+	}
+
+	mf := &metricFamily{
+		name:   "service_error_rate",
+		help:   "error rate, computed as a fraction of errors/sec over calls/sec, grouped by service",
+		series: series,
+	}
+	if param.GroupByOperation {
+		mf.name = "service_operation_error_rate"
+		mf.help = "error rate, computed as a fraction of errors/sec over calls/sec, grouped by service & operation"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetMetricsResponse(w, mf)
+}
+
+// parseJaegerMetricsQueryParam parse Jaeger SPM request to unified SpanMetricsQueryParam.
+//
+// The durations (endTs, lookback, step and ratePer) are in milliseconds.
+func parseJaegerMetricsQueryParam(r *http.Request) (*query.SpanMetricsQueryParam, error) {
+	var err error
+
+	// default params
+	p := &query.SpanMetricsQueryParam{
+		SpanKinds: []string{spanKindMap["server"]},
+		EndTime:   time.Now(),
+		Lookback:  metricsDefaultLookback,
+		Step:      metricsDefaultStep,
+		RatePer:   metricsDefaultRatePer,
+	}
+	q := r.URL.Query()
+
+	for _, service := range q["service"] {
+		if service != "" {
+			p.ServiceNames = append(p.ServiceNames, service)
+		}
+	}
+
+	if spanKinds := q["spanKind"]; len(spanKinds) > 0 {
+		p.SpanKinds = p.SpanKinds[:0]
+		for _, spanKind := range spanKinds {
+			kind, ok := metricsSpanKindMap[strings.ToLower(spanKind)]
+			if !ok {
+				return nil, fmt.Errorf("unsupported spanKind [%s]", spanKind)
+			}
+			p.SpanKinds = append(p.SpanKinds, kind)
+		}
+	}
+
+	if groupByOperation := q.Get("groupByOperation"); groupByOperation != "" {
+		p.GroupByOperation, err = strconv.ParseBool(groupByOperation)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse groupByOperation [%s]: %w", groupByOperation, err)
+		}
+	}
+
+	if endTs := q.Get("endTs"); endTs != "" {
+		unixMilli, err := strconv.ParseInt(endTs, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse endTs [%s]: %w", endTs, err)
+		}
+		p.EndTime = time.UnixMilli(unixMilli)
+	}
+
+	for _, d := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{name: "lookback", dst: &p.Lookback},
+		{name: "step", dst: &p.Step},
+		{name: "ratePer", dst: &p.RatePer},
+	} {
+		v := q.Get(d.name)
+		if v == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s [%s]: %w", d.name, v, err)
+		}
+		*d.dst = time.Duration(ms) * time.Millisecond
+	}
+	if p.Step < metricsMinStep {
+		return nil, fmt.Errorf("step must be at least %dms", metricsMinStep.Milliseconds())
+	}
+
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// metricsSpanKindMap maps the span kinds in Jaeger SPM API to the stored format.
+var metricsSpanKindMap = map[string]string{
+	"unspecified": "0",
+	"internal":    spanKindMap["internal"],
+	"server":      spanKindMap["server"],
+	"client":      spanKindMap["client"],
+	"producer":    spanKindMap["producer"],
+	"consumer":    spanKindMap["consumer"],
+}
@@ -7,727 +7,1158 @@ package jaeger
 //line app/vtselect/traces/jaeger/jaeger.qtpl:1
 import (
 	"sort"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
 )
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:7
+//line app/vtselect/traces/jaeger/jaeger.qtpl:10
 import (
 	qtio422016 "io"
 
 	qt422016 "github.com/valyala/quicktemplate"
 )
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:7
+//line app/vtselect/traces/jaeger/jaeger.qtpl:10
 var (
 	_ = qtio422016.Copy
 	_ = qt422016.AcquireByteBuffer
 )
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:7
+//line app/vtselect/traces/jaeger/jaeger.qtpl:10
 func StreamGetServicesResponse(qw422016 *qt422016.Writer, serviceList []string) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:7
-	qw422016.N().S(`{`)
 //line app/vtselect/traces/jaeger/jaeger.qtpl:10
+	qw422016.N().S(`{`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:13
 	sort.Slice(serviceList, func(i, j int) bool { return serviceList[i] < serviceList[j] })
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:11
+//line app/vtselect/traces/jaeger/jaeger.qtpl:14
 	qw422016.N().S(`"data":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:13
+//line app/vtselect/traces/jaeger/jaeger.qtpl:16
 	if len(serviceList) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:14
+//line app/vtselect/traces/jaeger/jaeger.qtpl:17
 		qw422016.N().Q(serviceList[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:15
+//line app/vtselect/traces/jaeger/jaeger.qtpl:18
 		for _, service := range serviceList[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:15
+//line app/vtselect/traces/jaeger/jaeger.qtpl:18
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:16
+//line app/vtselect/traces/jaeger/jaeger.qtpl:19
 			qw422016.N().Q(service)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:17
+//line app/vtselect/traces/jaeger/jaeger.qtpl:20
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:18
+//line app/vtselect/traces/jaeger/jaeger.qtpl:21
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:18
+//line app/vtselect/traces/jaeger/jaeger.qtpl:21
 	qw422016.N().S(`],"errors": null,"limit": 0,"offset": 0,"total":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:23
+//line app/vtselect/traces/jaeger/jaeger.qtpl:26
 	qw422016.N().D(len(serviceList))
-//line app/vtselect/traces/jaeger/jaeger.qtpl:23
+//line app/vtselect/traces/jaeger/jaeger.qtpl:26
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 func WriteGetServicesResponse(qq422016 qtio422016.Writer, serviceList []string) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	StreamGetServicesResponse(qw422016, serviceList)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 func GetServicesResponse(serviceList []string) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	WriteGetServicesResponse(qb422016, serviceList)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:25
+//line app/vtselect/traces/jaeger/jaeger.qtpl:28
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:27
+//line app/vtselect/traces/jaeger/jaeger.qtpl:30
 func StreamGetOperationsResponse(qw422016 *qt422016.Writer, operationList []string) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:27
-	qw422016.N().S(`{`)
 //line app/vtselect/traces/jaeger/jaeger.qtpl:30
+	qw422016.N().S(`{`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:33
 	sort.Slice(operationList, func(i, j int) bool { return operationList[i] < operationList[j] })
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:31
+//line app/vtselect/traces/jaeger/jaeger.qtpl:34
 	qw422016.N().S(`"data":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:33
+//line app/vtselect/traces/jaeger/jaeger.qtpl:36
 	if len(operationList) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:34
+//line app/vtselect/traces/jaeger/jaeger.qtpl:37
 		qw422016.N().Q(operationList[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:35
+//line app/vtselect/traces/jaeger/jaeger.qtpl:38
 		for _, operation := range operationList[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:35
+//line app/vtselect/traces/jaeger/jaeger.qtpl:38
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:36
+//line app/vtselect/traces/jaeger/jaeger.qtpl:39
 			qw422016.N().Q(operation)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:37
+//line app/vtselect/traces/jaeger/jaeger.qtpl:40
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:38
+//line app/vtselect/traces/jaeger/jaeger.qtpl:41
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:38
+//line app/vtselect/traces/jaeger/jaeger.qtpl:41
 	qw422016.N().S(`],"errors": null,"limit": 0,"offset": 0,"total":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:43
+//line app/vtselect/traces/jaeger/jaeger.qtpl:46
 	qw422016.N().D(len(operationList))
-//line app/vtselect/traces/jaeger/jaeger.qtpl:43
+//line app/vtselect/traces/jaeger/jaeger.qtpl:46
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 func WriteGetOperationsResponse(qq422016 qtio422016.Writer, operationList []string) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	StreamGetOperationsResponse(qw422016, operationList)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 func GetOperationsResponse(operationList []string) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	WriteGetOperationsResponse(qb422016, operationList)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:45
+//line app/vtselect/traces/jaeger/jaeger.qtpl:48
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:47
-func StreamGetTracesResponse(qw422016 *qt422016.Writer, traces []*trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:47
-	qw422016.N().S(`{"data":[`)
 //line app/vtselect/traces/jaeger/jaeger.qtpl:50
+func StreamGetOperationsV2Response(qw422016 *qt422016.Writer, operations []operation) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:50
+	qw422016.N().S(`{"data":[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:53
+	if len(operations) > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:54
+		streamoperationJson(qw422016, operations[0])
+//line app/vtselect/traces/jaeger/jaeger.qtpl:55
+		for _, op := range operations[1:] {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:55
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:56
+			streamoperationJson(qw422016, op)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:57
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:58
+	}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:58
+	qw422016.N().S(`],"errors": null,"limit": 0,"offset": 0,"total":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:63
+	qw422016.N().D(len(operations))
+//line app/vtselect/traces/jaeger/jaeger.qtpl:63
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+func WriteGetOperationsV2Response(qq422016 qtio422016.Writer, operations []operation) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	StreamGetOperationsV2Response(qw422016, operations)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+func GetOperationsV2Response(operations []operation) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	WriteGetOperationsV2Response(qb422016, operations)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:65
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:67
+func streamoperationJson(qw422016 *qt422016.Writer, op operation) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:67
+	qw422016.N().S(`{"name":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:69
+	qw422016.N().Q(op.name)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:69
+	qw422016.N().S(`,"spanKind":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:70
+	qw422016.N().Q(op.spanKind)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:70
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+func writeoperationJson(qq422016 qtio422016.Writer, op operation) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	streamoperationJson(qw422016, op)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+func operationJson(op operation) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	writeoperationJson(qb422016, op)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:72
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:74
+func StreamGetTracesResponse(qw422016 *qt422016.Writer, traces []*trace, nextCursor *query.TraceCursor) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:74
+	qw422016.N().S(`{"data":[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:77
 	if len(traces) > 0 && len(traces[0].spans) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:51
+//line app/vtselect/traces/jaeger/jaeger.qtpl:78
 		streamtraceJson(qw422016, traces[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:52
+//line app/vtselect/traces/jaeger/jaeger.qtpl:79
 		for _, trace := range traces[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:53
+//line app/vtselect/traces/jaeger/jaeger.qtpl:80
 			if len(trace.spans) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:53
+//line app/vtselect/traces/jaeger/jaeger.qtpl:80
 				qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:54
+//line app/vtselect/traces/jaeger/jaeger.qtpl:81
 				streamtraceJson(qw422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:55
+//line app/vtselect/traces/jaeger/jaeger.qtpl:82
 			}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:56
+//line app/vtselect/traces/jaeger/jaeger.qtpl:83
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:57
+//line app/vtselect/traces/jaeger/jaeger.qtpl:84
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:57
+//line app/vtselect/traces/jaeger/jaeger.qtpl:84
 	qw422016.N().S(`],"errors": null,"limit": 0,"offset": 0,"total":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:62
+//line app/vtselect/traces/jaeger/jaeger.qtpl:89
 	qw422016.N().D(len(traces))
-//line app/vtselect/traces/jaeger/jaeger.qtpl:62
+//line app/vtselect/traces/jaeger/jaeger.qtpl:90
+	if nextCursor != nil {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:90
+		qw422016.N().S(`,"nextCursor":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:91
+		qw422016.N().Q(nextCursor.String())
+//line app/vtselect/traces/jaeger/jaeger.qtpl:92
+	}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:92
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
-func WriteGetTracesResponse(qq422016 qtio422016.Writer, traces []*trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+func WriteGetTracesResponse(qq422016 qtio422016.Writer, traces []*trace, nextCursor *query.TraceCursor) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
-	StreamGetTracesResponse(qw422016, traces)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+	StreamGetTracesResponse(qw422016, traces, nextCursor)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
-func GetTracesResponse(traces []*trace) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+func GetTracesResponse(traces []*trace, nextCursor *query.TraceCursor) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
-	WriteGetTracesResponse(qb422016, traces)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+	WriteGetTracesResponse(qb422016, traces, nextCursor)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:64
+//line app/vtselect/traces/jaeger/jaeger.qtpl:94
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:66
+//line app/vtselect/traces/jaeger/jaeger.qtpl:96
 func StreamGetTraceResponse(qw422016 *qt422016.Writer, trace *trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:66
+//line app/vtselect/traces/jaeger/jaeger.qtpl:96
 	qw422016.N().S(`{"data":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:69
+//line app/vtselect/traces/jaeger/jaeger.qtpl:99
 	if trace != nil {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:70
+//line app/vtselect/traces/jaeger/jaeger.qtpl:100
 		streamtraceJson(qw422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:71
+//line app/vtselect/traces/jaeger/jaeger.qtpl:101
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:71
+//line app/vtselect/traces/jaeger/jaeger.qtpl:101
 	qw422016.N().S(`],"errors":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:74
+//line app/vtselect/traces/jaeger/jaeger.qtpl:104
 	if trace == nil {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:74
+//line app/vtselect/traces/jaeger/jaeger.qtpl:104
 		qw422016.N().S(`[{"code":404,"msg":"trace not found"}]`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:76
+//line app/vtselect/traces/jaeger/jaeger.qtpl:106
 	} else {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:76
+//line app/vtselect/traces/jaeger/jaeger.qtpl:106
 		qw422016.N().S(`null`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:78
+//line app/vtselect/traces/jaeger/jaeger.qtpl:108
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:78
+//line app/vtselect/traces/jaeger/jaeger.qtpl:108
 	qw422016.N().S(`,"limit": 0,"offset": 0,"total":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 	if trace == nil {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 		qw422016.N().S(`0`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 	} else {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 		qw422016.N().S(`1`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:82
+//line app/vtselect/traces/jaeger/jaeger.qtpl:112
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 func WriteGetTraceResponse(qq422016 qtio422016.Writer, trace *trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	StreamGetTraceResponse(qw422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 func GetTraceResponse(trace *trace) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	WriteGetTraceResponse(qb422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:84
+//line app/vtselect/traces/jaeger/jaeger.qtpl:114
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:86
-func StreamGetDependenciesResponse(qw422016 *qt422016.Writer, dependencies []*dependencyLink) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:86
+//line app/vtselect/traces/jaeger/jaeger.qtpl:116
+func StreamGetDependenciesResponse(qw422016 *qt422016.Writer, edges []*query.ServiceGraphEdge) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:116
 	qw422016.N().S(`{"data":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:89
-	if len(dependencies) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:90
-		streamdependencyJson(qw422016, dependencies[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:91
-		for _, dependency := range dependencies[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:91
+//line app/vtselect/traces/jaeger/jaeger.qtpl:119
+	if len(edges) > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:120
+		streamdependencyJson(qw422016, edges[0])
+//line app/vtselect/traces/jaeger/jaeger.qtpl:121
+		for _, edge := range edges[1:] {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:121
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:92
-			streamdependencyJson(qw422016, dependency)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:93
+//line app/vtselect/traces/jaeger/jaeger.qtpl:122
+			streamdependencyJson(qw422016, edge)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:123
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+//line app/vtselect/traces/jaeger/jaeger.qtpl:124
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:94
+//line app/vtselect/traces/jaeger/jaeger.qtpl:124
 	qw422016.N().S(`],"errors": null,"limit": 0,"offset": 0,"total":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:99
-	qw422016.N().D(len(dependencies))
-//line app/vtselect/traces/jaeger/jaeger.qtpl:99
+//line app/vtselect/traces/jaeger/jaeger.qtpl:129
+	qw422016.N().D(len(edges))
+//line app/vtselect/traces/jaeger/jaeger.qtpl:129
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
-func WriteGetDependenciesResponse(qq422016 qtio422016.Writer, dependencies []*dependencyLink) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
+func WriteGetDependenciesResponse(qq422016 qtio422016.Writer, edges []*query.ServiceGraphEdge) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
-	StreamGetDependenciesResponse(qw422016, dependencies)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
+	StreamGetDependenciesResponse(qw422016, edges)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
-func GetDependenciesResponse(dependencies []*dependencyLink) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
+func GetDependenciesResponse(edges []*query.ServiceGraphEdge) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
-	WriteGetDependenciesResponse(qb422016, dependencies)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
+	WriteGetDependenciesResponse(qb422016, edges)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:101
+//line app/vtselect/traces/jaeger/jaeger.qtpl:131
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:103
-func streamdependencyJson(qw422016 *qt422016.Writer, dependency *dependencyLink) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:103
+//line app/vtselect/traces/jaeger/jaeger.qtpl:133
+func streamdependencyJson(qw422016 *qt422016.Writer, edge *query.ServiceGraphEdge) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:133
 	qw422016.N().S(`{"parent":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:105
-	qw422016.N().Q(dependency.parent)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:105
+//line app/vtselect/traces/jaeger/jaeger.qtpl:135
+	qw422016.N().Q(edge.Parent)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:135
 	qw422016.N().S(`,"child":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:106
-	qw422016.N().Q(dependency.child)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:106
+//line app/vtselect/traces/jaeger/jaeger.qtpl:136
+	qw422016.N().Q(edge.Child)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:136
 	qw422016.N().S(`,"callCount":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:107
-	qw422016.N().DUL(dependency.callCount)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:107
+//line app/vtselect/traces/jaeger/jaeger.qtpl:137
+	qw422016.N().DUL(edge.CallCount)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:137
+	qw422016.N().S(`,"errorCount":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:138
+	qw422016.N().DUL(edge.FailedCount)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:138
+	qw422016.N().S(`,"client":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:139
+	streamdependencyStatsJson(qw422016, &edge.Client)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:139
+	qw422016.N().S(`,"server":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:140
+	streamdependencyStatsJson(qw422016, &edge.Server)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:140
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+func writedependencyJson(qq422016 qtio422016.Writer, edge *query.ServiceGraphEdge) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	streamdependencyJson(qw422016, edge)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+func dependencyJson(edge *query.ServiceGraphEdge) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	writedependencyJson(qb422016, edge)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:144
+func streamdependencyStatsJson(qw422016 *qt422016.Writer, s *query.ServiceGraphEdgeStats) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:144
+	qw422016.N().S(`{"errorCount":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+	qw422016.N().DUL(s.ErrorCount)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+	qw422016.N().S(`,"p50Duration":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:147
+	qw422016.N().DL(s.P50.Microseconds())
+//line app/vtselect/traces/jaeger/jaeger.qtpl:147
+	qw422016.N().S(`,"p90Duration":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:148
+	qw422016.N().DL(s.P90.Microseconds())
+//line app/vtselect/traces/jaeger/jaeger.qtpl:148
+	qw422016.N().S(`,"p99Duration":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:149
+	qw422016.N().DL(s.P99.Microseconds())
+//line app/vtselect/traces/jaeger/jaeger.qtpl:149
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
-func writedependencyJson(qq422016 qtio422016.Writer, dependency *dependencyLink) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
+func writedependencyStatsJson(qq422016 qtio422016.Writer, s *query.ServiceGraphEdgeStats) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
-	streamdependencyJson(qw422016, dependency)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
+	streamdependencyStatsJson(qw422016, s)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
-func dependencyJson(dependency *dependencyLink) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
+func dependencyStatsJson(s *query.ServiceGraphEdgeStats) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
-	writedependencyJson(qb422016, dependency)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
+	writedependencyStatsJson(qb422016, s)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:109
+//line app/vtselect/traces/jaeger/jaeger.qtpl:151
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:111
+//line app/vtselect/traces/jaeger/jaeger.qtpl:153
 func streamtraceJson(qw422016 *qt422016.Writer, trace *trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:111
-	qw422016.N().S(`{"processes": {`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:114
-	if len(trace.processMap) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:115
-		qw422016.N().Q(trace.processMap[0].processID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:115
-		qw422016.N().S(`:`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:115
-		streamprocessJson(qw422016, trace.processMap[0].process)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:116
-		for _, v := range trace.processMap[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:116
-			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:117
-			qw422016.N().Q(v.processID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:117
-			qw422016.N().S(`:`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:117
-			streamprocessJson(qw422016, v.process)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:118
-		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:119
-	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:119
-	qw422016.N().S(`},"spans": [`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:122
+//line app/vtselect/traces/jaeger/jaeger.qtpl:153
+	qw422016.N().S(`{"processes":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:155
+	streamprocessesJson(qw422016, trace.processMap)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:155
+	qw422016.N().S(`,"spans": [`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:157
 	if len(trace.spans) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:123
+//line app/vtselect/traces/jaeger/jaeger.qtpl:158
 		streamspanJson(qw422016, trace.spans[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:124
+//line app/vtselect/traces/jaeger/jaeger.qtpl:159
 		for _, v := range trace.spans[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:124
+//line app/vtselect/traces/jaeger/jaeger.qtpl:159
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:125
+//line app/vtselect/traces/jaeger/jaeger.qtpl:160
 			streamspanJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:126
+//line app/vtselect/traces/jaeger/jaeger.qtpl:161
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:127
+//line app/vtselect/traces/jaeger/jaeger.qtpl:162
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:127
+//line app/vtselect/traces/jaeger/jaeger.qtpl:162
 	qw422016.N().S(`],"traceID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:129
+//line app/vtselect/traces/jaeger/jaeger.qtpl:164
 	qw422016.N().Q(trace.spans[0].traceID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:129
+//line app/vtselect/traces/jaeger/jaeger.qtpl:164
 	qw422016.N().S(`,"warnings": null}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 func writetraceJson(qq422016 qtio422016.Writer, trace *trace) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 	streamtraceJson(qw422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 func traceJson(trace *trace) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
 	writetraceJson(qb422016, trace)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:169
+func streamstreamedTraceHead(qw422016 *qt422016.Writer) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:169
+	qw422016.N().S(`{"data":[{"spans":[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+func writestreamedTraceHead(qq422016 qtio422016.Writer) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	streamstreamedTraceHead(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+func streamedTraceHead() string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	writestreamedTraceHead(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:171
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:173
+func streamstreamedTraceTail(qw422016 *qt422016.Writer, traceID string, processMap []processMap, warnings []string) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:173
+	qw422016.N().S(`],"processes":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:175
+	streamprocessesJson(qw422016, processMap)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:175
+	qw422016.N().S(`,"traceID":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:176
+	qw422016.N().Q(traceID)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:176
+	qw422016.N().S(`,"warnings":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:178
+	if len(warnings) > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:178
+		qw422016.N().S(`[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:180
+		qw422016.N().Q(warnings[0])
+//line app/vtselect/traces/jaeger/jaeger.qtpl:181
+		for _, v := range warnings[1:] {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:181
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+			qw422016.N().Q(v)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:183
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:183
+		qw422016.N().S(`]`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:185
+	} else {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:185
+		qw422016.N().S(`null`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:187
+	}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:187
+	qw422016.N().S(`}],"errors": null,"limit": 0,"offset": 0,"total": 1}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+func writestreamedTraceTail(qq422016 qtio422016.Writer, traceID string, processMap []processMap, warnings []string) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+	streamstreamedTraceTail(qw422016, traceID, processMap, warnings)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+func streamedTraceTail(traceID string, processMap []processMap, warnings []string) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+	writestreamedTraceTail(qb422016, traceID, processMap, warnings)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:132
+//line app/vtselect/traces/jaeger/jaeger.qtpl:194
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:134
+//line app/vtselect/traces/jaeger/jaeger.qtpl:196
+func streamprocessesJson(qw422016 *qt422016.Writer, processMap []processMap) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:196
+	qw422016.N().S(`{`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:198
+	if len(processMap) > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:199
+		qw422016.N().Q(processMap[0].processID)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:199
+		qw422016.N().S(`:`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:199
+		streamprocessJson(qw422016, processMap[0].process)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:200
+		for _, v := range processMap[1:] {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:200
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:201
+			qw422016.N().Q(v.processID)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:201
+			qw422016.N().S(`:`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:201
+			streamprocessJson(qw422016, v.process)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:202
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:203
+	}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:203
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+func writeprocessesJson(qq422016 qtio422016.Writer, processMap []processMap) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	streamprocessesJson(qw422016, processMap)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+func processesJson(processMap []processMap) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	writeprocessesJson(qb422016, processMap)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:205
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:207
 func streamprocessJson(qw422016 *qt422016.Writer, process process) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:134
+//line app/vtselect/traces/jaeger/jaeger.qtpl:207
 	qw422016.N().S(`{"serviceName":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:136
+//line app/vtselect/traces/jaeger/jaeger.qtpl:209
 	qw422016.N().Q(process.serviceName)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:136
+//line app/vtselect/traces/jaeger/jaeger.qtpl:209
 	qw422016.N().S(`,"tags": [`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:138
+//line app/vtselect/traces/jaeger/jaeger.qtpl:211
 	if len(process.tags) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:139
+//line app/vtselect/traces/jaeger/jaeger.qtpl:212
 		streamtagJson(qw422016, process.tags[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:140
+//line app/vtselect/traces/jaeger/jaeger.qtpl:213
 		for _, v := range process.tags[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:140
+//line app/vtselect/traces/jaeger/jaeger.qtpl:213
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:141
+//line app/vtselect/traces/jaeger/jaeger.qtpl:214
 			streamtagJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:142
+//line app/vtselect/traces/jaeger/jaeger.qtpl:215
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:143
+//line app/vtselect/traces/jaeger/jaeger.qtpl:216
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:143
+//line app/vtselect/traces/jaeger/jaeger.qtpl:216
 	qw422016.N().S(`]}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 func writeprocessJson(qq422016 qtio422016.Writer, process process) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	streamprocessJson(qw422016, process)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 func processJson(process process) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	writeprocessJson(qb422016, process)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:146
+//line app/vtselect/traces/jaeger/jaeger.qtpl:219
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:148
+//line app/vtselect/traces/jaeger/jaeger.qtpl:221
 func streamspanJson(qw422016 *qt422016.Writer, span *span) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:148
+//line app/vtselect/traces/jaeger/jaeger.qtpl:221
 	qw422016.N().S(`{"duration":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:150
+//line app/vtselect/traces/jaeger/jaeger.qtpl:223
 	qw422016.N().DL(span.duration)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:150
+//line app/vtselect/traces/jaeger/jaeger.qtpl:223
 	qw422016.N().S(`,"logs":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:152
+//line app/vtselect/traces/jaeger/jaeger.qtpl:225
 	if len(span.logs) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:153
+//line app/vtselect/traces/jaeger/jaeger.qtpl:226
 		streamlogJson(qw422016, span.logs[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:154
+//line app/vtselect/traces/jaeger/jaeger.qtpl:227
 		for _, v := range span.logs[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:154
+//line app/vtselect/traces/jaeger/jaeger.qtpl:227
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:155
+//line app/vtselect/traces/jaeger/jaeger.qtpl:228
 			streamlogJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:156
+//line app/vtselect/traces/jaeger/jaeger.qtpl:229
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:157
+//line app/vtselect/traces/jaeger/jaeger.qtpl:230
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:157
+//line app/vtselect/traces/jaeger/jaeger.qtpl:230
 	qw422016.N().S(`],"operationName":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:159
+//line app/vtselect/traces/jaeger/jaeger.qtpl:232
 	qw422016.N().Q(span.operationName)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:159
+//line app/vtselect/traces/jaeger/jaeger.qtpl:232
 	qw422016.N().S(`,"processID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:160
+//line app/vtselect/traces/jaeger/jaeger.qtpl:233
 	qw422016.N().Q(span.processID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:160
+//line app/vtselect/traces/jaeger/jaeger.qtpl:233
 	qw422016.N().S(`,"references": [`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:162
+//line app/vtselect/traces/jaeger/jaeger.qtpl:235
 	if len(span.references) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:163
+//line app/vtselect/traces/jaeger/jaeger.qtpl:236
 		streamspanRefJson(qw422016, span.references[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:164
+//line app/vtselect/traces/jaeger/jaeger.qtpl:237
 		for _, v := range span.references[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:164
+//line app/vtselect/traces/jaeger/jaeger.qtpl:237
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:165
+//line app/vtselect/traces/jaeger/jaeger.qtpl:238
 			streamspanRefJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:166
+//line app/vtselect/traces/jaeger/jaeger.qtpl:239
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+//line app/vtselect/traces/jaeger/jaeger.qtpl:240
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:167
+//line app/vtselect/traces/jaeger/jaeger.qtpl:240
 	qw422016.N().S(`],"spanID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:169
+//line app/vtselect/traces/jaeger/jaeger.qtpl:242
 	qw422016.N().Q(span.spanID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:169
+//line app/vtselect/traces/jaeger/jaeger.qtpl:242
 	qw422016.N().S(`,"startTime":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:170
+//line app/vtselect/traces/jaeger/jaeger.qtpl:243
 	qw422016.N().DL(span.startTime)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:170
+//line app/vtselect/traces/jaeger/jaeger.qtpl:243
 	qw422016.N().S(`,"tags": [`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:172
+//line app/vtselect/traces/jaeger/jaeger.qtpl:245
 	if len(span.tags) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:173
+//line app/vtselect/traces/jaeger/jaeger.qtpl:246
 		streamtagJson(qw422016, span.tags[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:174
+//line app/vtselect/traces/jaeger/jaeger.qtpl:247
 		for _, v := range span.tags[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:174
+//line app/vtselect/traces/jaeger/jaeger.qtpl:247
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:175
+//line app/vtselect/traces/jaeger/jaeger.qtpl:248
 			streamtagJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:176
+//line app/vtselect/traces/jaeger/jaeger.qtpl:249
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:177
+//line app/vtselect/traces/jaeger/jaeger.qtpl:250
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:177
+//line app/vtselect/traces/jaeger/jaeger.qtpl:250
 	qw422016.N().S(`],"traceID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:179
+//line app/vtselect/traces/jaeger/jaeger.qtpl:252
 	qw422016.N().Q(span.traceID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:179
+//line app/vtselect/traces/jaeger/jaeger.qtpl:252
 	qw422016.N().S(`,"warnings":null}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 func writespanJson(qq422016 qtio422016.Writer, span *span) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	streamspanJson(qw422016, span)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 func spanJson(span *span) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	writespanJson(qb422016, span)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:182
+//line app/vtselect/traces/jaeger/jaeger.qtpl:255
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:184
+//line app/vtselect/traces/jaeger/jaeger.qtpl:257
 func streamtagJson(qw422016 *qt422016.Writer, tag keyValue) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:184
+//line app/vtselect/traces/jaeger/jaeger.qtpl:257
 	qw422016.N().S(`{"key":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:186
+//line app/vtselect/traces/jaeger/jaeger.qtpl:259
 	qw422016.N().Q(tag.key)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:186
+//line app/vtselect/traces/jaeger/jaeger.qtpl:259
 	qw422016.N().S(`,"type":"string","value":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:188
+//line app/vtselect/traces/jaeger/jaeger.qtpl:261
 	qw422016.N().Q(tag.vStr)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:188
+//line app/vtselect/traces/jaeger/jaeger.qtpl:261
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 func writetagJson(qq422016 qtio422016.Writer, tag keyValue) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	streamtagJson(qw422016, tag)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 func tagJson(tag keyValue) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	writetagJson(qb422016, tag)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:190
+//line app/vtselect/traces/jaeger/jaeger.qtpl:263
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:192
+//line app/vtselect/traces/jaeger/jaeger.qtpl:265
 func streamlogJson(qw422016 *qt422016.Writer, l log) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:192
+//line app/vtselect/traces/jaeger/jaeger.qtpl:265
 	qw422016.N().S(`{"timestamp":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+//line app/vtselect/traces/jaeger/jaeger.qtpl:267
 	qw422016.N().DL(l.timestamp)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:194
+//line app/vtselect/traces/jaeger/jaeger.qtpl:267
 	qw422016.N().S(`,"fields":[`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:196
+//line app/vtselect/traces/jaeger/jaeger.qtpl:269
 	if len(l.fields) > 0 {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:197
+//line app/vtselect/traces/jaeger/jaeger.qtpl:270
 		streamtagJson(qw422016, l.fields[0])
-//line app/vtselect/traces/jaeger/jaeger.qtpl:198
+//line app/vtselect/traces/jaeger/jaeger.qtpl:271
 		for _, v := range l.fields[1:] {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:198
+//line app/vtselect/traces/jaeger/jaeger.qtpl:271
 			qw422016.N().S(`,`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:199
+//line app/vtselect/traces/jaeger/jaeger.qtpl:272
 			streamtagJson(qw422016, v)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:200
+//line app/vtselect/traces/jaeger/jaeger.qtpl:273
 		}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:201
+//line app/vtselect/traces/jaeger/jaeger.qtpl:274
 	}
-//line app/vtselect/traces/jaeger/jaeger.qtpl:201
+//line app/vtselect/traces/jaeger/jaeger.qtpl:274
 	qw422016.N().S(`]}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 func writelogJson(qq422016 qtio422016.Writer, l log) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	streamlogJson(qw422016, l)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 func logJson(l log) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	writelogJson(qb422016, l)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:204
+//line app/vtselect/traces/jaeger/jaeger.qtpl:277
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:206
+//line app/vtselect/traces/jaeger/jaeger.qtpl:279
 func streamspanRefJson(qw422016 *qt422016.Writer, ref spanRef) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:206
+//line app/vtselect/traces/jaeger/jaeger.qtpl:279
 	qw422016.N().S(`{"refType":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:208
+//line app/vtselect/traces/jaeger/jaeger.qtpl:281
 	qw422016.N().Q(ref.refType)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:208
+//line app/vtselect/traces/jaeger/jaeger.qtpl:281
 	qw422016.N().S(`,"spanID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:209
+//line app/vtselect/traces/jaeger/jaeger.qtpl:282
 	qw422016.N().Q(ref.spanID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:209
+//line app/vtselect/traces/jaeger/jaeger.qtpl:282
 	qw422016.N().S(`,"traceID":`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:210
+//line app/vtselect/traces/jaeger/jaeger.qtpl:283
 	qw422016.N().Q(ref.traceID)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:210
+//line app/vtselect/traces/jaeger/jaeger.qtpl:283
 	qw422016.N().S(`}`)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 func writespanRefJson(qq422016 qtio422016.Writer, ref spanRef) {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	streamspanRefJson(qw422016, ref)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	qt422016.ReleaseWriter(qw422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 }
 
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 func spanRefJson(ref spanRef) string {
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	writespanRefJson(qb422016, ref)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	qs422016 := string(qb422016.B)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
 	return qs422016
-//line app/vtselect/traces/jaeger/jaeger.qtpl:212
+//line app/vtselect/traces/jaeger/jaeger.qtpl:285
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:287
+func StreamGetMetricsResponse(qw422016 *qt422016.Writer, mf *metricFamily) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:287
+	qw422016.N().S(`{"name":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:289
+	qw422016.N().Q(mf.name)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:289
+	qw422016.N().S(`,"type":"GAUGE","help":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:291
+	qw422016.N().Q(mf.help)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:291
+	qw422016.N().S(`,"metrics":[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:293
+	for i, s := range mf.series {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:294
+		if i > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:294
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:294
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:294
+		qw422016.N().S(`{"labels":[{"name":"service_name","value":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:297
+		qw422016.N().Q(s.ServiceName)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:297
+		qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:298
+		if s.Operation != "" {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:298
+			qw422016.N().S(`,{"name":"operation","value":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:299
+			qw422016.N().Q(s.Operation)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:299
+			qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:300
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:300
+		qw422016.N().S(`],"metricPoints":[`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:303
+		for j, p := range s.Points {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:304
+			if j > 0 {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:304
+				qw422016.N().S(`,`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:304
+			}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:304
+			qw422016.N().S(`{"gaugeValue":{"doubleValue":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:306
+			qw422016.N().F(p.Value)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:306
+			qw422016.N().S(`},"timestamp":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:307
+			qw422016.N().Q(p.Timestamp.UTC().Format(time.RFC3339Nano))
+//line app/vtselect/traces/jaeger/jaeger.qtpl:307
+			qw422016.N().S(`}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:309
+		}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:309
+		qw422016.N().S(`]}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:312
+	}
+//line app/vtselect/traces/jaeger/jaeger.qtpl:312
+	qw422016.N().S(`]}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+func WriteGetMetricsResponse(qq422016 qtio422016.Writer, mf *metricFamily) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	StreamGetMetricsResponse(qw422016, mf)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+func GetMetricsResponse(mf *metricFamily) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	WriteGetMetricsResponse(qb422016, mf)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:315
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:317
+func StreamGetMinStepResponse(qw422016 *qt422016.Writer, minStepMilliseconds int64) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:317
+	qw422016.N().S(`{"data":`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:319
+	qw422016.N().DL(minStepMilliseconds)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:319
+	qw422016.N().S(`,"errors": null,"limit": 0,"offset": 0,"total": 0}`)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+func WriteGetMinStepResponse(qq422016 qtio422016.Writer, minStepMilliseconds int64) {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	StreamGetMinStepResponse(qw422016, minStepMilliseconds)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+}
+
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+func GetMinStepResponse(minStepMilliseconds int64) string {
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	WriteGetMinStepResponse(qb422016, minStepMilliseconds)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
+	return qs422016
+//line app/vtselect/traces/jaeger/jaeger.qtpl:325
 }
@@ -0,0 +1,95 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestWriteStreamedTrace(t *testing.T) {
+	f := func(spansCount int, streamErr error, statusCodeExpected int, validJSONExpected bool, bodyContains string) {
+		t.Helper()
+
+		streamTrace := func(writeSpan func(fields []logstorage.Field)) (*query.TraceStreamResult, error) {
+			for i := 0; i < spansCount; i++ {
+				writeSpan([]logstorage.Field{
+					{Name: otelpb.TraceIDField, Value: "1234567890"},
+					{Name: otelpb.SpanIDField, Value: strconv.Itoa(i)},
+					{Name: otelpb.NameField, Value: strings.Repeat("x", 100)},
+					{Name: otelpb.ResourceAttrServiceName, Value: "svc"},
+				})
+			}
+			if streamErr != nil {
+				return nil, streamErr
+			}
+			return &query.TraceStreamResult{SpansCount: spansCount}, nil
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/select/jaeger/api/traces/1234567890", nil)
+		w := httptest.NewRecorder()
+		writeStreamedTrace(w, r, "1234567890", streamTrace)
+
+		if w.Code != statusCodeExpected {
+			t.Fatalf("unexpected status code; got %d; want %d", w.Code, statusCodeExpected)
+		}
+		body := w.Body.String()
+		if validJSONExpected != json.Valid([]byte(body)) {
+			t.Fatalf("unexpected JSON validity of the response; got %v; want %v; response: %.200q", !validJSONExpected, validJSONExpected, body)
+		}
+		if !strings.Contains(body, bodyContains) {
+			t.Fatalf("the response must contain %q; got %.200q", bodyContains, body)
+		}
+	}
+
+	streamErr := errors.New("storage is unavailable")
+
+	// successful response
+	f(3, nil, http.StatusOK, true, `"spanID":"2"`)
+
+	// missing trace
+	f(0, nil, http.StatusNotFound, true, `"data"`)
+
+	// error before the first span
+	f(0, streamErr, http.StatusBadRequest, false, "cannot get traces: storage is unavailable")
+
+	// error after a few buffered spans, which weren't sent to the client yet
+	f(3, streamErr, http.StatusBadRequest, false, "cannot get traces: storage is unavailable")
+
+	// error after a part of the response has been sent to the client.
+	// the response must be left truncated without the error message appended to the JSON.
+	f(2000, streamErr, http.StatusOK, false, `"spanID":"0"`)
+}
+
+func TestWriteStreamedTrace_NoErrorAfterPartialWrite(t *testing.T) {
+	streamTrace := func(writeSpan func(fields []logstorage.Field)) (*query.TraceStreamResult, error) {
+		for i := 0; i < 2000; i++ {
+			writeSpan([]logstorage.Field{
+				{Name: otelpb.TraceIDField, Value: "1234567890"},
+				{Name: otelpb.SpanIDField, Value: strconv.Itoa(i)},
+				{Name: otelpb.NameField, Value: strings.Repeat("x", 100)},
+			})
+		}
+		return nil, errors.New("storage is unavailable")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/select/jaeger/api/traces/1234567890", nil)
+	w := httptest.NewRecorder()
+	writeStreamedTrace(w, r, "1234567890", streamTrace)
+
+	body := w.Body.String()
+	if strings.Contains(body, "storage is unavailable") {
+		t.Fatalf("the error message mustn't be appended to the already sent JSON response; got %.200q", body[len(body)-200:])
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected Content-Type; got %q; want %q", ct, "application/json")
+	}
+}
@@ -2,11 +2,13 @@ package jaeger
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
 
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
 	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
 )
 
@@ -56,10 +58,19 @@ type log struct {
 	fields    []keyValue
 }
 
-type dependencyLink struct {
-	parent    string
-	child     string
-	callCount uint64
+// operation is a span name of a service together with the span kind name, e.g. `server`.
+type operation struct {
+	name     string
+	spanKind string
+}
+
+// metricFamily is the response of Jaeger SPM APIs, which contains gauge series.
+//
+// See: https://github.com/jaegertracing/jaeger-idl/blob/main/proto/api_v2/metrics/openmetrics.proto
+type metricFamily struct {
+	name   string
+	help   string
+	series []*query.SpanMetricsSeries
 }
 
 // since Jaeger renamed some fields in OpenTelemetry
@@ -95,6 +106,17 @@ var spanKindMap = map[string]string{
 	"consumer": "5",
 }
 
+// spanKindName returns the name of the span kind in stored format, e.g. `server` for `2`.
+// Empty string is returned for unspecified span kind.
+func spanKindName(kind string) string {
+	for name, v := range spanKindMap {
+		if v == kind {
+			return name
+		}
+	}
+	return ""
+}
+
 // fieldsToSpan convert OTLP spans in fields to Jaeger Spans.
 func fieldsToSpan(fields []logstorage.Field) (*span, error) {
 	sp := &span{}
@@ -257,27 +279,45 @@ func fieldsToSpan(fields []logstorage.Field) (*span, error) {
 		sp.references = append(sp.references, parentSpanRef)
 	}
 
-	for i := 0; i < len(refsMap); i++ {
-		idx := strconv.Itoa(i)
-		if len(sp.references) > 0 && parentSpanRef.traceID == refsMap[idx].traceID && parentSpanRef.spanID == refsMap[idx].spanID {
+	// span links are exposed as FOLLOWS_FROM references in the order of their indexes,
+	// unless they are marked as `child_of` via `opentracing.ref_type` attribute.
+	for _, idx := range sortedIndexes(refsMap) {
+		ref := refsMap[idx]
+		if ref.traceID == "" || ref.spanID == "" {
+			// the link cannot be referenced without trace ID and span ID.
+			continue
+		}
+		if len(sp.references) > 0 && parentSpanRef.traceID == ref.traceID && parentSpanRef.spanID == ref.spanID {
 			// We already added a reference to this span, but maybe with the wrong type, so override.
-			sp.references[0].refType = refsMap[idx].refType
+			sp.references[0].refType = ref.refType
 			continue
 		}
-		sp.references = append(sp.references, spanRef{
-			refsMap[idx].traceID, refsMap[idx].spanID, refsMap[idx].refType,
-		})
+		sp.references = append(sp.references, *ref)
 	}
-	for i := 0; i < len(logsMap); i++ {
-		idx := strconv.Itoa(i)
-		sp.logs = append(sp.logs, log{
-			logsMap[idx].timestamp, logsMap[idx].fields,
-		})
+	for _, idx := range sortedIndexes(logsMap) {
+		sp.logs = append(sp.logs, *logsMap[idx])
 	}
 
 	return sp, nil
 }
 
+// sortedIndexes returns the keys of m sorted as numbers.
+//
+// The keys are indexes of span events or links, which could be sparse if some fields are missing.
+func sortedIndexes[T any](m map[string]T) []string {
+	idxs := make([]string, 0, len(m))
+	for idx := range m {
+		idxs = append(idxs, idx)
+	}
+	sort.Slice(idxs, func(i, j int) bool {
+		if len(idxs[i]) != len(idxs[j]) {
+			return len(idxs[i]) < len(idxs[j])
+		}
+		return idxs[i] < idxs[j]
+	})
+	return idxs
+}
+
 func extraAttributeNameAndIndex(input string) (string, string) {
 	splitIdx := strings.LastIndex(input, ":")
 	if splitIdx == -1 {
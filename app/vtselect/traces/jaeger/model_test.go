@@ -162,6 +162,34 @@ func TestFieldsToSpan(t *testing.T) {
 		},
 	}
 	f(fields, sp, "")
+
+	// case 6: links with sparse indexes and without span ID
+	fields = []logstorage.Field{
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+		{Name: otelpb.SpanIDField, Value: "12345"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":10", Value: "10101010"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":10", Value: "10"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":2", Value: "22222222"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":2", Value: "2"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":3", Value: "33333333"},
+	}
+	sp = &span{
+		traceID: "1234567890",
+		spanID:  "12345",
+		references: []spanRef{
+			{
+				traceID: "22222222",
+				spanID:  "2",
+				refType: "FOLLOWS_FROM",
+			},
+			{
+				traceID: "10101010",
+				spanID:  "10",
+				refType: "FOLLOWS_FROM",
+			},
+		},
+	}
+	f(fields, sp, "")
 }
 
 func TestRemoveArrayIndex(t *testing.T) {
@@ -0,0 +1,233 @@
+package native
+
+import (
+	"sort"
+	"strings"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// diffStatus is the status of the aligned span in trace comparison.
+type diffStatus string
+
+const (
+	diffStatusMatched diffStatus = "matched"
+	diffStatusAdded   diffStatus = "added"
+	diffStatusRemoved diffStatus = "removed"
+)
+
+// diffNode is a pair of aligned spans from trace a and trace b.
+//
+// a is nil for the span added in trace b, and b is nil for the span removed from trace a.
+type diffNode struct {
+	serviceName string
+	name        string
+
+	a *spanNode
+	b *spanNode
+
+	attributeDiffs []attributeDiff
+	children       []*diffNode
+}
+
+func (dn *diffNode) status() diffStatus {
+	switch {
+	case dn.a == nil:
+		return diffStatusAdded
+	case dn.b == nil:
+		return diffStatusRemoved
+	default:
+		return diffStatusMatched
+	}
+}
+
+// durationDelta returns the duration of span b minus the duration of span a in nanoseconds.
+func (dn *diffNode) durationDelta() int64 {
+	if dn.a == nil || dn.b == nil {
+		return 0
+	}
+	return dn.b.duration() - dn.a.duration()
+}
+
+func (dn *diffNode) errorChanged() bool {
+	if dn.a == nil || dn.b == nil {
+		return false
+	}
+	return dn.a.isError() != dn.b.isError()
+}
+
+// attributeDiff is the attribute with different values in the aligned spans.
+//
+// The value is nil if the attribute is missing in the span.
+type attributeDiff struct {
+	key    string
+	aValue *string
+	bValue *string
+}
+
+// traceDiff is the result of comparing trace a with trace b.
+type traceDiff struct {
+	aTraceID string
+	bTraceID string
+
+	aDuration int64
+	bDuration int64
+
+	matchedSpans       int
+	addedSpans         int
+	removedSpans       int
+	errorStatusChanged int
+
+	roots []*diffNode
+}
+
+// compareSpanTrees aligns the span trees of trace a and trace b, and returns the differences between them.
+//
+// Spans are aligned by (service name, span name, tree position): the root spans are aligned with each other,
+// and the children of aligned spans are aligned with each other. The n-th span with the given service name
+// and span name among its siblings (ordered by start time) is aligned with the n-th span with the same names
+// in the other trace. Spans without a pair are reported as added or removed together with all their descendants.
+func compareSpanTrees(aTraceID string, a *spanTree, bTraceID string, b *spanTree) *traceDiff {
+	td := &traceDiff{
+		aTraceID:  aTraceID,
+		bTraceID:  bTraceID,
+		aDuration: a.duration(),
+		bDuration: b.duration(),
+	}
+	td.roots = td.alignSpans(a.roots, a.startTime(), b.roots, b.startTime())
+	return td
+}
+
+func (td *traceDiff) alignSpans(aSpans []*spanNode, aTraceStart int64, bSpans []*spanNode, bTraceStart int64) []*diffNode {
+	type alignKey struct {
+		serviceName string
+		name        string
+	}
+
+	bByKey := make(map[alignKey][]*spanNode)
+	for _, sn := range bSpans {
+		k := alignKey{serviceName: sn.serviceName, name: sn.name}
+		bByKey[k] = append(bByKey[k], sn)
+	}
+
+	// nodes are ordered by the start time relative to the start of their trace.
+	type diffNodeWithOffset struct {
+		dn     *diffNode
+		offset int64
+	}
+	nodes := make([]diffNodeWithOffset, 0, max(len(aSpans), len(bSpans)))
+	for _, aSpan := range aSpans {
+		k := alignKey{serviceName: aSpan.serviceName, name: aSpan.name}
+		dn := &diffNode{
+			serviceName: aSpan.serviceName,
+			name:        aSpan.name,
+			a:           aSpan,
+		}
+		if candidates := bByKey[k]; len(candidates) > 0 {
+			dn.b = candidates[0]
+			bByKey[k] = candidates[1:]
+		}
+		nodes = append(nodes, diffNodeWithOffset{
+			dn:     dn,
+			offset: aSpan.startTime - aTraceStart,
+		})
+	}
+	for _, bSpan := range bSpans {
+		k := alignKey{serviceName: bSpan.serviceName, name: bSpan.name}
+		candidates := bByKey[k]
+		if len(candidates) == 0 || candidates[0] != bSpan {
+			// the span is already aligned.
+			continue
+		}
+		bByKey[k] = candidates[1:]
+		nodes = append(nodes, diffNodeWithOffset{
+			dn: &diffNode{
+				serviceName: bSpan.serviceName,
+				name:        bSpan.name,
+				b:           bSpan,
+			},
+			offset: bSpan.startTime - bTraceStart,
+		})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].offset < nodes[j].offset
+	})
+
+	result := make([]*diffNode, len(nodes))
+	for i, n := range nodes {
+		dn := n.dn
+		var aChildren, bChildren []*spanNode
+		switch dn.status() {
+		case diffStatusMatched:
+			td.matchedSpans++
+			if dn.errorChanged() {
+				td.errorStatusChanged++
+			}
+			dn.attributeDiffs = compareAttributes(dn.a, dn.b)
+			aChildren, bChildren = dn.a.children, dn.b.children
+		case diffStatusAdded:
+			td.addedSpans++
+			bChildren = dn.b.children
+		case diffStatusRemoved:
+			td.removedSpans++
+			aChildren = dn.a.children
+		}
+		dn.children = td.alignSpans(aChildren, aTraceStart, bChildren, bTraceStart)
+		result[i] = dn
+	}
+	return result
+}
+
+// isComparableAttribute returns true if the field is an attribute, which should be compared in the aligned spans.
+//
+// Span IDs, timestamps and durations differ in every trace, so they're not compared.
+func isComparableAttribute(name string) bool {
+	return strings.HasPrefix(name, otelpb.SpanAttrPrefixField) ||
+		strings.HasPrefix(name, otelpb.ResourceAttrPrefix) ||
+		strings.HasPrefix(name, otelpb.InstrumentationScopeAttrPrefix)
+}
+
+// compareAttributes returns the attributes with different values in span a and span b, sorted by key.
+func compareAttributes(a, b *spanNode) []attributeDiff {
+	aAttrs := make(map[string]string)
+	for _, f := range a.fields {
+		if isComparableAttribute(f.Name) {
+			aAttrs[f.Name] = f.Value
+		}
+	}
+	bAttrs := make(map[string]string)
+	for _, f := range b.fields {
+		if isComparableAttribute(f.Name) {
+			bAttrs[f.Name] = f.Value
+		}
+	}
+
+	var result []attributeDiff
+	for k, aValue := range aAttrs {
+		bValue, ok := bAttrs[k]
+		if ok && aValue == bValue {
+			continue
+		}
+		ad := attributeDiff{
+			key:    k,
+			aValue: &aValue,
+		}
+		if ok {
+			ad.bValue = &bValue
+		}
+		result = append(result, ad)
+	}
+	for k, bValue := range bAttrs {
+		if _, ok := aAttrs[k]; ok {
+			continue
+		}
+		result = append(result, attributeDiff{
+			key:    k,
+			bValue: &bValue,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result
+}
@@ -0,0 +1,131 @@
+package native
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func newTestNamedSpan(spanID, parentSpanID, serviceName, name string, startTime, endTime int64, extraFields ...logstorage.Field) []logstorage.Field {
+	fields := newTestSpan(spanID, parentSpanID, startTime, endTime)
+	fields = append(fields,
+		logstorage.Field{Name: otelpb.ResourceAttrServiceName, Value: serviceName},
+		logstorage.Field{Name: otelpb.NameField, Value: name},
+	)
+	return append(fields, extraFields...)
+}
+
+// formatDiffNodes returns the human-readable representation of the aligned spans.
+func formatDiffNodes(dst []string, nodes []*diffNode, depth int) []string {
+	for _, dn := range nodes {
+		s := fmt.Sprintf("%s%s:%s %s", strings.Repeat("  ", depth), dn.serviceName, dn.name, dn.status())
+		if dn.status() == diffStatusMatched {
+			s += fmt.Sprintf(" %d", dn.durationDelta())
+		}
+		if dn.errorChanged() {
+			s += " error_changed"
+		}
+		for _, ad := range dn.attributeDiffs {
+			s += fmt.Sprintf(" %s=%s->%s", ad.key, formatOptionalString(ad.aValue), formatOptionalString(ad.bValue))
+		}
+		dst = append(dst, s)
+		dst = formatDiffNodes(dst, dn.children, depth+1)
+	}
+	return dst
+}
+
+func formatOptionalString(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
+
+func TestCompareSpanTrees(t *testing.T) {
+	f := func(aSpans, bSpans [][]logstorage.Field, resultExpected []string, summaryExpected [4]int) {
+		t.Helper()
+
+		a, err := buildSpanTree(aSpans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b, err := buildSpanTree(bSpans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		td := compareSpanTrees("a", a, "b", b)
+		result := formatDiffNodes(nil, td.roots, 0)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", strings.Join(result, "\n"), strings.Join(resultExpected, "\n"))
+		}
+		summary := [4]int{td.matchedSpans, td.addedSpans, td.removedSpans, td.errorStatusChanged}
+		if summary != summaryExpected {
+			t.Fatalf("unexpected summary; got %v; want %v", summary, summaryExpected)
+		}
+	}
+
+	// identical traces with different IDs and start times
+	f([][]logstorage.Field{
+		newTestNamedSpan("a1", "", "frontend", "GET", 0, 100),
+		newTestNamedSpan("a2", "a1", "backend", "query", 10, 50),
+	}, [][]logstorage.Field{
+		newTestNamedSpan("b1", "", "frontend", "GET", 1000, 1100),
+		newTestNamedSpan("b2", "b1", "backend", "query", 1010, 1050),
+	}, []string{
+		"frontend:GET matched 0",
+		"  backend:query matched 0",
+	}, [4]int{2, 0, 0, 0})
+
+	// added, removed and slower spans
+	f([][]logstorage.Field{
+		newTestNamedSpan("a1", "", "frontend", "GET", 0, 100),
+		newTestNamedSpan("a2", "a1", "backend", "query", 10, 50),
+		newTestNamedSpan("a3", "a2", "db", "select", 20, 30),
+		newTestNamedSpan("a4", "a1", "backend", "query", 60, 90),
+	}, [][]logstorage.Field{
+		newTestNamedSpan("b1", "", "frontend", "GET", 0, 300),
+		newTestNamedSpan("b2", "b1", "cache", "get", 5, 8),
+		newTestNamedSpan("b3", "b1", "backend", "query", 10, 250),
+	}, []string{
+		"frontend:GET matched 200",
+		"  cache:get added",
+		"  backend:query matched 200",
+		"    db:select removed",
+		"  backend:query removed",
+	}, [4]int{2, 1, 2, 0})
+
+	// attribute and status differences
+	f([][]logstorage.Field{
+		newTestNamedSpan("a1", "", "frontend", "GET", 0, 100,
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "http.status_code", Value: "200"},
+			logstorage.Field{Name: otelpb.ResourceAttrPrefix + "host.name", Value: "host-1"},
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "user", Value: "alice"},
+		),
+	}, [][]logstorage.Field{
+		newTestNamedSpan("b1", "", "frontend", "GET", 0, 100,
+			logstorage.Field{Name: otelpb.StatusCodeField, Value: "2"},
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "http.status_code", Value: "500"},
+			logstorage.Field{Name: otelpb.ResourceAttrPrefix + "host.name", Value: "host-1"},
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "retry", Value: "true"},
+		),
+	}, []string{
+		"frontend:GET matched 0 error_changed span_attr:http.status_code=200->500 span_attr:retry=<nil>->true span_attr:user=alice-><nil>",
+	}, [4]int{1, 0, 0, 1})
+
+	// different roots
+	f([][]logstorage.Field{
+		newTestNamedSpan("a1", "", "frontend", "GET", 0, 100),
+	}, [][]logstorage.Field{
+		newTestNamedSpan("b1", "", "frontend", "POST", 0, 100),
+		newTestNamedSpan("b2", "b1", "backend", "insert", 10, 20),
+	}, []string{
+		"frontend:GET removed",
+		"frontend:POST added",
+		"  backend:insert added",
+	}, [4]int{0, 2, 1, 0})
+}
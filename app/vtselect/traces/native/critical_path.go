@@ -0,0 +1,151 @@
+package native
+
+import (
+	"sort"
+)
+
+// criticalPathSpan is a span on the critical path of a trace.
+type criticalPathSpan struct {
+	span *spanNode
+	// selfTime is the time in nanoseconds, during which the span itself determines the trace latency.
+	selfTime int64
+	// firstSectionStart is used for ordering spans on the critical path.
+	firstSectionStart int64
+}
+
+// computeCriticalPath returns the spans on the critical path of st, ordered by the time they appear on the path.
+//
+// The critical path starts from the root span finishing last. It walks backwards in time from the end of the span:
+// the last finishing child which ends before the current point of time is on the critical path, and the time
+// between the end of that child and the current point is the self time of the span. When there are no more
+// such children, the rest of the span until its start is the self time, and the walk returns to the parent.
+//
+// Children starting after the end of their parent (such as async children) are ignored, and children overlapping
+// the boundaries of their parent are truncated to the parent, so they cannot extend the critical path.
+// Overlapping siblings are handled by the walk, since only the children finishing before the point of time are considered.
+//
+// It follows the critical path algorithm of Jaeger UI, see:
+// https://github.com/jaegertracing/jaeger-ui/blob/main/packages/jaeger-ui/src/components/TracePage/CriticalPath/index.tsx
+func computeCriticalPath(st *spanTree) []*criticalPathSpan {
+	if len(st.roots) == 0 {
+		return nil
+	}
+	root := st.roots[0]
+	for _, sn := range st.roots[1:] {
+		if sn.endTime > root.endTime {
+			root = sn
+		}
+	}
+
+	bounds := sanitizeChildrenBounds(root)
+
+	pathSpans := make(map[*spanNode]*criticalPathSpan)
+	addSection := func(sn *spanNode, start, end int64) {
+		if start >= end {
+			return
+		}
+		cps, ok := pathSpans[sn]
+		if !ok {
+			cps = &criticalPathSpan{
+				span: sn,
+			}
+			pathSpans[sn] = cps
+		}
+		cps.selfTime += end - start
+		// sections are added backwards in time, so the last one is the earliest.
+		cps.firstSectionStart = start
+	}
+
+	visited := make(map[*spanNode]struct{})
+	current := root
+	visited[current] = struct{}{}
+	pointInTime := bounds[root].endTime
+	for {
+		child := findLastFinishingChild(current, bounds, visited, pointInTime)
+		if child != nil {
+			childBounds := bounds[child]
+			addSection(current, childBounds.endTime, pointInTime)
+			visited[child] = struct{}{}
+			current = child
+			pointInTime = childBounds.endTime
+			continue
+		}
+
+		currentBounds := bounds[current]
+		addSection(current, currentBounds.startTime, pointInTime)
+		if current == root {
+			break
+		}
+		pointInTime = currentBounds.startTime
+		current = current.parent
+	}
+
+	result := make([]*criticalPathSpan, 0, len(pathSpans))
+	for _, cps := range pathSpans {
+		result = append(result, cps)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].firstSectionStart != result[j].firstSectionStart {
+			return result[i].firstSectionStart < result[j].firstSectionStart
+		}
+		return result[i].span.spanID < result[j].span.spanID
+	})
+	return result
+}
+
+type spanBounds struct {
+	startTime int64
+	endTime   int64
+}
+
+// sanitizeChildrenBounds returns the bounds of root and its descendants, which are truncated to the bounds of their parents.
+//
+// Descendants starting after the end, or ending before the start of their (truncated) parent, are dropped.
+func sanitizeChildrenBounds(root *spanNode) map[*spanNode]spanBounds {
+	bounds := map[*spanNode]spanBounds{
+		root: {
+			startTime: root.startTime,
+			endTime:   root.endTime,
+		},
+	}
+	queue := []*spanNode{root}
+	for len(queue) > 0 {
+		sn := queue[0]
+		queue = queue[1:]
+		parentBounds := bounds[sn]
+		for _, child := range sn.children {
+			if child.startTime > parentBounds.endTime || child.endTime < parentBounds.startTime {
+				continue
+			}
+			bounds[child] = spanBounds{
+				startTime: max(child.startTime, parentBounds.startTime),
+				endTime:   min(child.endTime, parentBounds.endTime),
+			}
+			queue = append(queue, child)
+		}
+	}
+	return bounds
+}
+
+// findLastFinishingChild returns the not visited child of sn, which finishes last not later than pointInTime.
+func findLastFinishingChild(sn *spanNode, bounds map[*spanNode]spanBounds, visited map[*spanNode]struct{}, pointInTime int64) *spanNode {
+	var result *spanNode
+	var resultEndTime int64
+	for _, child := range sn.children {
+		childBounds, ok := bounds[child]
+		if !ok {
+			continue
+		}
+		if _, ok := visited[child]; ok {
+			continue
+		}
+		if childBounds.endTime > pointInTime {
+			continue
+		}
+		if result == nil || childBounds.endTime > resultEndTime {
+			result = child
+			resultEndTime = childBounds.endTime
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package native
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func newTestSpan(spanID, parentSpanID string, startTime, endTime int64) []logstorage.Field {
+	return []logstorage.Field{
+		{Name: otelpb.SpanIDField, Value: spanID},
+		{Name: otelpb.ParentSpanIDField, Value: parentSpanID},
+		{Name: otelpb.StartTimeUnixNanoField, Value: strconv.FormatInt(startTime, 10)},
+		{Name: otelpb.EndTimeUnixNanoField, Value: strconv.FormatInt(endTime, 10)},
+	}
+}
+
+func TestBuildSpanTree(t *testing.T) {
+	f := func(spans [][]logstorage.Field, rootsExpected []string) {
+		t.Helper()
+
+		st, err := buildSpanTree(spans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		roots := make([]string, 0, len(st.roots))
+		for _, sn := range st.roots {
+			roots = append(roots, sn.spanID)
+		}
+		if !reflect.DeepEqual(roots, rootsExpected) {
+			t.Fatalf("unexpected roots; got %q; want %q", roots, rootsExpected)
+		}
+	}
+
+	f([][]logstorage.Field{
+		newTestSpan("b", "a", 10, 20),
+		newTestSpan("a", "", 0, 100),
+	}, []string{"a"})
+
+	// missing parent
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("c", "b", 10, 20),
+	}, []string{"a", "c"})
+
+	// cycle and self reference
+	f([][]logstorage.Field{
+		newTestSpan("a", "b", 0, 100),
+		newTestSpan("b", "a", 10, 20),
+		newTestSpan("c", "c", 30, 40),
+	}, []string{"b", "c"})
+
+	// invalid span
+	if _, err := buildSpanTree([][]logstorage.Field{newTestSpan("", "", 0, 100)}); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
+func TestComputeCriticalPath(t *testing.T) {
+	f := func(spans [][]logstorage.Field, resultExpected map[string]int64, orderExpected []string) {
+		t.Helper()
+
+		st, err := buildSpanTree(spans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		path := computeCriticalPath(st)
+		result := make(map[string]int64, len(path))
+		order := make([]string, 0, len(path))
+		for _, cps := range path {
+			result[cps.span.spanID] = cps.selfTime
+			order = append(order, cps.span.spanID)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected self time; got %v; want %v", result, resultExpected)
+		}
+		if !reflect.DeepEqual(order, orderExpected) {
+			t.Fatalf("unexpected order; got %q; want %q", order, orderExpected)
+		}
+	}
+
+	// empty trace
+	f(nil, map[string]int64{}, []string{})
+
+	// a single span
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+	}, map[string]int64{"a": 100}, []string{"a"})
+
+	// sequential children
+	// a: [0, 100]
+	//   b: [10, 40]
+	//   c: [50, 90]
+	//     d: [60, 70]
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "a", 10, 40),
+		newTestSpan("c", "a", 50, 90),
+		newTestSpan("d", "c", 60, 70),
+	}, map[string]int64{"a": 30, "b": 30, "c": 30, "d": 10}, []string{"a", "b", "c", "d"})
+
+	// overlapping children: only the child finishing last before the next one starts is on the path.
+	// a: [0, 100]
+	//   b: [10, 60]
+	//   c: [20, 80]
+	//   d: [50, 90]
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "a", 10, 60),
+		newTestSpan("c", "a", 20, 80),
+		newTestSpan("d", "a", 50, 90),
+	}, map[string]int64{"a": 60, "d": 40}, []string{"a", "d"})
+
+	// async children: the child starting after the end of the parent is ignored,
+	// and the child ending after the end of the parent is truncated.
+	// a: [0, 100]
+	//   b: [20, 150]
+	//   c: [120, 200]
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "a", 20, 150),
+		newTestSpan("c", "a", 120, 200),
+	}, map[string]int64{"a": 20, "b": 80}, []string{"a", "b"})
+
+	// multiple roots: the root finishing last is used.
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "missing", 10, 200),
+	}, map[string]int64{"b": 190}, []string{"b"})
+}
@@ -0,0 +1,800 @@
+package native
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timeutil"
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 1000
+)
+
+var statsMaxTraces = flag.Int("search.traceStatsMaxTraces", 10000, "The maximum number of traces aggregated by /select/traces/stats API. "+
+	"The most recent traces matching the search params are aggregated, "+
+	"and the response is marked as truncated if there are more matching traces")
+
+// Native trace query APIs metrics
+var (
+	searchRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/search"}`)
+	searchDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/search"}`)
+
+	searchSummaryRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/search/summary"}`)
+	searchSummaryDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/search/summary"}`)
+
+	structuralSearchRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/search/structural"}`)
+	structuralSearchDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/search/structural"}`)
+
+	spanBySpanIDRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/by_span_id/*"}`)
+	spanBySpanIDDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/by_span_id/*"}`)
+
+	linksRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/*/links"}`)
+	linksDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/*/links"}`)
+
+	criticalPathRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/*/critical_path"}`)
+	criticalPathDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/*/critical_path"}`)
+
+	compareRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/compare"}`)
+	compareDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/compare"}`)
+
+	statsRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/stats"}`)
+	statsDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/stats"}`)
+
+	exceptionsRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/exceptions"}`)
+	exceptionsDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/exceptions"}`)
+
+	dependenciesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/traces/dependencies"}`)
+	dependenciesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/traces/dependencies"}`)
+)
+
+// RequestHandler is the entry point for all native trace query APIs at `/select/traces/*`.
+func RequestHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	httpserver.EnableCORS(w, r)
+	startTime := time.Now()
+	path := r.URL.Path
+	switch {
+	case path == "/select/traces/search":
+		searchRequests.Inc()
+		processSearchRequest(ctx, w, r)
+		searchDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/search/summary":
+		searchSummaryRequests.Inc()
+		processSearchSummaryRequest(ctx, w, r)
+		searchSummaryDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/search/structural":
+		structuralSearchRequests.Inc()
+		processStructuralSearchRequest(ctx, w, r)
+		structuralSearchDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/stats":
+		statsRequests.Inc()
+		processStatsRequest(ctx, w, r)
+		statsDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/exceptions":
+		exceptionsRequests.Inc()
+		processExceptionsRequest(ctx, w, r)
+		exceptionsDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/dependencies":
+		dependenciesRequests.Inc()
+		processDependenciesRequest(ctx, w, r)
+		dependenciesDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/traces/compare":
+		compareRequests.Inc()
+		processCompareRequest(ctx, w, r)
+		compareDuration.UpdateDuration(startTime)
+		return true
+	case strings.HasPrefix(path, "/select/traces/by_span_id/"):
+		spanBySpanIDRequests.Inc()
+		processSpanBySpanIDRequest(ctx, w, r)
+		spanBySpanIDDuration.UpdateDuration(startTime)
+		return true
+	case strings.HasPrefix(path, "/select/traces/") && strings.HasSuffix(path, "/links"):
+		linksRequests.Inc()
+		processLinksRequest(ctx, w, r)
+		linksDuration.UpdateDuration(startTime)
+		return true
+	case strings.HasPrefix(path, "/select/traces/") && strings.HasSuffix(path, "/critical_path"):
+		criticalPathRequests.Inc()
+		processCriticalPathRequest(ctx, w, r)
+		criticalPathDuration.UpdateDuration(startTime)
+		return true
+	}
+	return false
+}
+
+// trace contains spans of a trace in their stored fields.
+type trace struct {
+	traceID string
+	spans   [][]logstorage.Field
+}
+
+// processSearchRequest handles the /select/traces/search API request.
+func processSearchRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseTraceQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
+		return
+	}
+
+	traceIDList, rows, nextCursor, err := query.GetTraceList(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get trace list error: %s", err)
+		return
+	}
+
+	traces := groupRowsByTraceID(traceIDList, rows)
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteSearchResponse(w, traces, nextCursor)
+}
+
+// processSearchSummaryRequest handles the /select/traces/search/summary API request.
+//
+// It accepts the same params as /select/traces/search, and returns only the root span and the timing of every matching trace.
+func processSearchSummaryRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseTraceQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
+		return
+	}
+
+	summaries, nextCursor, err := query.GetTraceSummaryList(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get trace summary list error: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteSearchSummaryResponse(w, summaries, nextCursor)
+}
+
+// processStructuralSearchRequest handles the /select/traces/search/structural API request.
+func processStructuralSearchRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseSpanRelationQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect structural query params: %s", err)
+		return
+	}
+
+	traces, err := query.GetTracesBySpanRelation(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get traces by span relation error: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteStructuralSearchResponse(w, traces)
+}
+
+// processCriticalPathRequest handles the /select/traces/<trace_id>/critical_path API request.
+func processCriticalPathRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	// extract the `trace_id`.
+	// the path must be like `/select/traces/<trace_id>/critical_path`.
+	traceID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/select/traces/"), "/critical_path")
+	if len(traceID) == 0 || strings.Contains(traceID, "/") {
+		httpserver.Errorf(w, r, "incorrect query path [%s]", r.URL.Path)
+		return
+	}
+
+	rows, err := query.GetTrace(ctx, cp, traceID)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get trace: %s", err)
+		return
+	}
+
+	traces := groupRowsByTraceID([]string{traceID}, rows)
+	st, err := buildSpanTree(traces[0].spans)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot build span tree: %s", err)
+		return
+	}
+	path := computeCriticalPath(st)
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(path) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	WriteCriticalPathResponse(w, traceID, path)
+}
+
+// processSpanBySpanIDRequest handles the /select/traces/by_span_id/<span_id> API request.
+//
+// It requires the span ID index, which is written only if -insert.indexSpanID is set during the ingestion.
+func processSpanBySpanIDRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	// extract the `span_id`.
+	// the path must be like `/select/traces/by_span_id/<span_id>`.
+	spanID := strings.TrimPrefix(r.URL.Path, "/select/traces/by_span_id/")
+	if len(spanID) == 0 || strings.Contains(spanID, "/") {
+		httpserver.Errorf(w, r, "incorrect query path [%s]", r.URL.Path)
+		return
+	}
+
+	traceID, row, err := query.GetSpanBySpanID(ctx, cp, spanID)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get span: %s", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if row == nil {
+		w.WriteHeader(http.StatusNotFound)
+		WriteSpanBySpanIDResponse(w, "", nil)
+		return
+	}
+	WriteSpanBySpanIDResponse(w, traceID, row.Fields)
+}
+
+// processLinksRequest handles the /select/traces/<trace_id>/links API request.
+//
+// It returns the links from the spans of the trace to other spans, and the links from other spans to the spans of the trace.
+func processLinksRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	// extract the `trace_id`.
+	// the path must be like `/select/traces/<trace_id>/links`.
+	traceID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/select/traces/"), "/links")
+	if len(traceID) == 0 || strings.Contains(traceID, "/") {
+		httpserver.Errorf(w, r, "incorrect query path [%s]", r.URL.Path)
+		return
+	}
+
+	tl, err := query.GetTraceLinks(ctx, cp, traceID)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get trace links: %s", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if tl == nil {
+		w.WriteHeader(http.StatusNotFound)
+		tl = &query.TraceLinks{}
+	}
+	WriteLinksResponse(w, traceID, tl)
+}
+
+// processStatsRequest handles the /select/traces/stats API request.
+//
+// It accepts the same params as /select/traces/search, and aggregates the spans of all the matching traces
+// per (service name, span name). Up to -search.traceStatsMaxTraces most recent traces are aggregated.
+func processStatsRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseTraceQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
+		return
+	}
+	// the order of traces doesn't matter for the aggregation, so the most recent traces are aggregated.
+	param.SortOrder = query.TraceSortOrderRecent
+	param.Cursor = nil
+
+	// search for one more trace than the limit, so the response is marked as truncated only if there are more matching traces.
+	maxTraces := max(*statsMaxTraces, 1)
+	param.Limit = maxTraces + 1
+	traceIDList, startTime, err := query.GetTraceIDs(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get trace list error: %s", err)
+		return
+	}
+	ts := newTraceStats()
+	if len(traceIDList) > maxTraces {
+		traceIDList = traceIDList[:maxTraces]
+		ts.truncated = true
+	}
+
+	// fetch the spans in batches, so only the spans of up to maxLimit traces are kept in memory.
+	for len(traceIDList) > 0 {
+		n := min(maxLimit, len(traceIDList))
+		batch := traceIDList[:n]
+		traceIDList = traceIDList[n:]
+
+		rows, err := query.GetTraceRows(ctx, cp, batch, startTime, param.StartTimeMax)
+		if err != nil {
+			httpserver.Errorf(w, r, "get trace spans error: %s", err)
+			return
+		}
+		for _, t := range groupRowsByTraceID(batch, rows) {
+			if len(t.spans) == 0 {
+				continue
+			}
+			st, err := buildSpanTree(t.spans)
+			if err != nil {
+				httpserver.Errorf(w, r, "cannot build span tree for trace %q: %s", t.traceID, err)
+				return
+			}
+			ts.add(st)
+		}
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteStatsResponse(w, ts)
+}
+
+// processExceptionsRequest handles the /select/traces/exceptions API request.
+func processExceptionsRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseExceptionQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect exception query params: %s", err)
+		return
+	}
+
+	groups, err := query.GetExceptionGroups(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get exception groups error: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteExceptionsResponse(w, groups)
+}
+
+// processDependenciesRequest handles the /select/traces/dependencies API request.
+func processDependenciesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseDependenciesQueryParam(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect dependencies query params: %s", err)
+		return
+	}
+
+	edges, err := query.GetServiceGraphEdges(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get dependencies error: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteDependenciesResponse(w, edges)
+}
+
+// processCompareRequest handles the /select/traces/compare API request.
+func processCompareRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	q := r.URL.Query()
+	aTraceID, bTraceID := q.Get("a"), q.Get("b")
+	if aTraceID == "" || bTraceID == "" {
+		httpserver.Errorf(w, r, "missing `a` or `b` trace ID")
+		return
+	}
+
+	traceIDList, rows, err := query.GetTracesByIDs(ctx, cp, []string{aTraceID, bTraceID})
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get traces: %s", err)
+		return
+	}
+
+	trees := make(map[string]*spanTree, len(traceIDList))
+	for _, t := range groupRowsByTraceID(traceIDList, rows) {
+		if len(t.spans) == 0 {
+			continue
+		}
+		st, err := buildSpanTree(t.spans)
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot build span tree for trace %q: %s", t.traceID, err)
+			return
+		}
+		trees[t.traceID] = st
+	}
+	for _, traceID := range []string{aTraceID, bTraceID} {
+		if _, ok := trees[traceID]; !ok {
+			err := &httpserver.ErrorWithStatusCode{
+				Err:        fmt.Errorf("cannot find trace %q", traceID),
+				StatusCode: http.StatusNotFound,
+			}
+			httpserver.Errorf(w, r, "%s", err)
+			return
+		}
+	}
+
+	td := compareSpanTrees(aTraceID, trees[aTraceID], bTraceID, trees[bTraceID])
+
+	w.Header().Set("Content-Type", "application/json")
+	WriteCompareResponse(w, td)
+}
+
+// groupRowsByTraceID groups rows by trace_id in the order of traceIDList.
+// The spans in each trace are sorted by start time.
+func groupRowsByTraceID(traceIDList []string, rows []*query.Row) []*trace {
+	tracesMap := make(map[string]*trace, len(traceIDList))
+	traces := make([]*trace, 0, len(traceIDList))
+	for _, traceID := range traceIDList {
+		t := &trace{
+			traceID: traceID,
+		}
+		tracesMap[traceID] = t
+		traces = append(traces, t)
+	}
+
+	for _, row := range rows {
+		fields := make([]logstorage.Field, 0, len(row.Fields))
+		traceID := ""
+		for _, f := range row.Fields {
+			switch f.Name {
+			case "_msg", "_stream", "_stream_id":
+				// these fields are meaningless for spans.
+				continue
+			case otelpb.TraceIDField:
+				traceID = f.Value
+			}
+			fields = append(fields, f)
+		}
+		t, ok := tracesMap[traceID]
+		if !ok {
+			continue
+		}
+		t.spans = append(t.spans, fields)
+	}
+
+	for _, t := range traces {
+		sort.SliceStable(t.spans, func(i, j int) bool {
+			return getStartTime(t.spans[i]) < getStartTime(t.spans[j])
+		})
+	}
+	return traces
+}
+
+func getStartTime(fields []logstorage.Field) uint64 {
+	for _, f := range fields {
+		if f.Name == otelpb.StartTimeUnixNanoField {
+			n, _ := strconv.ParseUint(f.Value, 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// parseTraceQueryParam parses the native trace search request to query.TraceQueryParam.
+func parseTraceQueryParam(r *http.Request) (*query.TraceQueryParam, error) {
+	var err error
+
+	currentTime := time.Now()
+
+	// default params
+	p := &query.TraceQueryParam{
+		StartTimeMin: time.Unix(0, 0),
+		StartTimeMax: currentTime,
+		Limit:        defaultLimit,
+	}
+	q := r.URL.Query()
+
+	p.ServiceName = q.Get("service")
+	p.SpanName = q.Get("span_name")
+	p.EventName = q.Get("event_name")
+	p.ExceptionType = q.Get("exception_type")
+	p.ExceptionMessage = q.Get("exception_message")
+
+	if filter := q.Get("filter"); filter != "" {
+		p.Filter, err = query.ParseAttributeFilter(filter, toFilterField)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse filter [%s]: %w", filter, err)
+		}
+	}
+
+	if start := q.Get("start"); start != "" {
+		nsecs, err := timeutil.ParseTimeAt(start, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse start [%s]: %w", start, err)
+		}
+		p.StartTimeMin = time.Unix(0, nsecs)
+	}
+
+	if end := q.Get("end"); end != "" {
+		nsecs, err := timeutil.ParseTimeAt(end, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse end [%s]: %w", end, err)
+		}
+		p.StartTimeMax = time.Unix(0, nsecs)
+	}
+
+	if minDuration := q.Get("min_duration"); minDuration != "" {
+		p.DurationMin, err = time.ParseDuration(minDuration)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse min_duration [%s]: %w", minDuration, err)
+		}
+	}
+
+	if maxDuration := q.Get("max_duration"); maxDuration != "" {
+		p.DurationMax, err = time.ParseDuration(maxDuration)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse max_duration [%s]: %w", maxDuration, err)
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		p.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse limit [%s]: %w", limit, err)
+		}
+		if p.Limit <= 0 || p.Limit > maxLimit {
+			return nil, fmt.Errorf("limit should be in the range [1, %d]", maxLimit)
+		}
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		p.Cursor, err = query.ParseTraceCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse cursor [%s]: %w", cursor, err)
+		}
+	}
+
+	p.SortOrder, err = query.ParseTraceSortOrder(q.Get("sort"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse sort: %w", err)
+	}
+
+	return p, nil
+}
+
+// parseSpanRelationQueryParam parses the native structural search request to query.SpanRelationQueryParam.
+func parseSpanRelationQueryParam(r *http.Request) (*query.SpanRelationQueryParam, error) {
+	var err error
+
+	currentTime := time.Now()
+
+	// default params
+	p := &query.SpanRelationQueryParam{
+		Relation:     query.SpanRelationDescendant,
+		StartTimeMin: time.Unix(0, 0),
+		StartTimeMax: currentTime,
+		Limit:        defaultLimit,
+	}
+	q := r.URL.Query()
+
+	ancestor := q.Get("ancestor")
+	if ancestor == "" {
+		return nil, fmt.Errorf("missing ancestor filter")
+	}
+	p.AncestorFilter, err = query.ParseAttributeFilter(ancestor, toFilterField)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse ancestor [%s]: %w", ancestor, err)
+	}
+
+	descendant := q.Get("descendant")
+	if descendant == "" {
+		return nil, fmt.Errorf("missing descendant filter")
+	}
+	p.DescendantFilter, err = query.ParseAttributeFilter(descendant, toFilterField)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse descendant [%s]: %w", descendant, err)
+	}
+
+	if relation := q.Get("relation"); relation != "" {
+		p.Relation, err = query.ParseSpanRelation(relation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if start := q.Get("start"); start != "" {
+		nsecs, err := timeutil.ParseTimeAt(start, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse start [%s]: %w", start, err)
+		}
+		p.StartTimeMin = time.Unix(0, nsecs)
+	}
+
+	if end := q.Get("end"); end != "" {
+		nsecs, err := timeutil.ParseTimeAt(end, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse end [%s]: %w", end, err)
+		}
+		p.StartTimeMax = time.Unix(0, nsecs)
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		p.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse limit [%s]: %w", limit, err)
+		}
+		if p.Limit <= 0 || p.Limit > maxLimit {
+			return nil, fmt.Errorf("limit should be in the range [1, %d]", maxLimit)
+		}
+	}
+
+	return p, nil
+}
+
+// parseExceptionQueryParam parses the params of /select/traces/exceptions API request.
+//
+// The exceptions are aggregated over the last hour by default. The default step splits the time range into 60 points.
+func parseExceptionQueryParam(r *http.Request) (*query.ExceptionQueryParam, error) {
+	var err error
+
+	currentTime := time.Now()
+
+	// default params
+	p := &query.ExceptionQueryParam{
+		EndTime: currentTime,
+		Limit:   defaultLimit,
+	}
+	q := r.URL.Query()
+
+	p.ServiceName = q.Get("service")
+
+	if end := q.Get("end"); end != "" {
+		nsecs, err := timeutil.ParseTimeAt(end, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse end [%s]: %w", end, err)
+		}
+		p.EndTime = time.Unix(0, nsecs)
+	}
+
+	p.StartTime = p.EndTime.Add(-time.Hour)
+	if start := q.Get("start"); start != "" {
+		nsecs, err := timeutil.ParseTimeAt(start, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse start [%s]: %w", start, err)
+		}
+		p.StartTime = time.Unix(0, nsecs)
+	}
+
+	p.Step = max(p.EndTime.Sub(p.StartTime)/60, time.Second).Truncate(time.Second)
+	if step := q.Get("step"); step != "" {
+		p.Step, err = time.ParseDuration(step)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse step [%s]: %w", step, err)
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		p.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse limit [%s]: %w", limit, err)
+		}
+		if p.Limit <= 0 || p.Limit > maxLimit {
+			return nil, fmt.Errorf("limit should be in the range [1, %d]", maxLimit)
+		}
+	}
+
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseDependenciesQueryParam parses the params of /select/traces/dependencies API request.
+//
+// The dependencies are calculated over the last hour by default.
+func parseDependenciesQueryParam(r *http.Request) (*query.ServiceGraphQueryParameters, error) {
+	currentTime := time.Now()
+
+	// default params
+	p := &query.ServiceGraphQueryParameters{
+		EndTs:    currentTime,
+		Lookback: time.Hour,
+	}
+	q := r.URL.Query()
+
+	p.ServiceName = q.Get("service")
+
+	if end := q.Get("end"); end != "" {
+		nsecs, err := timeutil.ParseTimeAt(end, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse end [%s]: %w", end, err)
+		}
+		p.EndTs = time.Unix(0, nsecs)
+	}
+
+	if start := q.Get("start"); start != "" {
+		nsecs, err := timeutil.ParseTimeAt(start, currentTime.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse start [%s]: %w", start, err)
+		}
+		p.Lookback = p.EndTs.Sub(time.Unix(0, nsecs))
+	}
+	if p.Lookback <= 0 {
+		return nil, fmt.Errorf("end must be bigger than start")
+	}
+
+	return p, nil
+}
+
+// spanFieldNames contains the span fields which can be used in filter as they are.
+var spanFieldNames = map[string]struct{}{
+	otelpb.SpanIDField:                 {},
+	otelpb.TraceStateField:             {},
+	otelpb.ParentSpanIDField:           {},
+	otelpb.FlagsField:                  {},
+	otelpb.NameField:                   {},
+	otelpb.KindField:                   {},
+	otelpb.StartTimeUnixNanoField:      {},
+	otelpb.EndTimeUnixNanoField:        {},
+	otelpb.DurationField:               {},
+	otelpb.StatusCodeField:             {},
+	otelpb.StatusMessageField:          {},
+	otelpb.InstrumentationScopeName:    {},
+	otelpb.InstrumentationScopeVersion: {},
+}
+
+// toFilterField converts the key in filter expression to the field name in storage.
+//
+// The span fields (such as `name`, `duration` and `status_code`) and fields with
+// `resource_attr:`, `scope_attr:` or `span_attr:` prefix are used as they are. Other keys are treated as span attributes.
+func toFilterField(k, v string) (string, string) {
+	if _, ok := spanFieldNames[k]; ok {
+		return k, v
+	}
+	if strings.HasPrefix(k, otelpb.ResourceAttrPrefix) || strings.HasPrefix(k, otelpb.InstrumentationScopeAttrPrefix) || strings.HasPrefix(k, otelpb.SpanAttrPrefixField) {
+		return k, v
+	}
+	return otelpb.SpanAttrPrefixField + k, v
+}
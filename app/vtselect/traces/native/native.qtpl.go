@@ -0,0 +1,1346 @@
+// Code generated by qtc from "native.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line native.qtpl:1
+package native
+
+//line native.qtpl:1
+import (
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+)
+
+//line native.qtpl:9
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line native.qtpl:9
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line native.qtpl:9
+func StreamSearchResponse(qw422016 *qt422016.Writer, traces []*trace, nextCursor *query.TraceCursor) {
+//line native.qtpl:9
+	qw422016.N().S(`{"traces":[`)
+//line native.qtpl:12
+	if len(traces) > 0 {
+//line native.qtpl:13
+		streamtraceJson(qw422016, traces[0])
+//line native.qtpl:14
+		for _, t := range traces[1:] {
+//line native.qtpl:14
+			qw422016.N().S(`,`)
+//line native.qtpl:15
+			streamtraceJson(qw422016, t)
+//line native.qtpl:16
+		}
+//line native.qtpl:17
+	}
+//line native.qtpl:17
+	qw422016.N().S(`]`)
+//line native.qtpl:19
+	if nextCursor != nil {
+//line native.qtpl:19
+		qw422016.N().S(`,"nextCursor":`)
+//line native.qtpl:20
+		qw422016.N().Q(nextCursor.String())
+//line native.qtpl:21
+	}
+//line native.qtpl:21
+	qw422016.N().S(`}`)
+//line native.qtpl:23
+}
+
+//line native.qtpl:23
+func WriteSearchResponse(qq422016 qtio422016.Writer, traces []*trace, nextCursor *query.TraceCursor) {
+//line native.qtpl:23
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:23
+	StreamSearchResponse(qw422016, traces, nextCursor)
+//line native.qtpl:23
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:23
+}
+
+//line native.qtpl:23
+func SearchResponse(traces []*trace, nextCursor *query.TraceCursor) string {
+//line native.qtpl:23
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:23
+	WriteSearchResponse(qb422016, traces, nextCursor)
+//line native.qtpl:23
+	qs422016 := string(qb422016.B)
+//line native.qtpl:23
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:23
+	return qs422016
+//line native.qtpl:23
+}
+
+//line native.qtpl:25
+func StreamSearchSummaryResponse(qw422016 *qt422016.Writer, summaries []*query.TraceSummary, nextCursor *query.TraceCursor) {
+//line native.qtpl:25
+	qw422016.N().S(`{"traces":[`)
+//line native.qtpl:28
+	for i, ts := range summaries {
+//line native.qtpl:29
+		if i > 0 {
+//line native.qtpl:29
+			qw422016.N().S(`,`)
+//line native.qtpl:29
+		}
+//line native.qtpl:29
+		qw422016.N().S(`{"traceID":`)
+//line native.qtpl:31
+		qw422016.N().Q(ts.TraceID)
+//line native.qtpl:31
+		qw422016.N().S(`,"rootServiceName":`)
+//line native.qtpl:32
+		qw422016.N().Q(ts.RootServiceName)
+//line native.qtpl:32
+		qw422016.N().S(`,"rootTraceName":`)
+//line native.qtpl:33
+		qw422016.N().Q(ts.RootTraceName)
+//line native.qtpl:33
+		qw422016.N().S(`,"startTimeUnixNano":"`)
+//line native.qtpl:34
+		qw422016.N().DL(ts.StartTimeUnixNano)
+//line native.qtpl:34
+		qw422016.N().S(`","durationMs":`)
+//line native.qtpl:35
+		qw422016.N().DL(ts.DurationMs)
+//line native.qtpl:35
+		qw422016.N().S(`}`)
+//line native.qtpl:37
+	}
+//line native.qtpl:37
+	qw422016.N().S(`]`)
+//line native.qtpl:39
+	if nextCursor != nil {
+//line native.qtpl:39
+		qw422016.N().S(`,"nextCursor":`)
+//line native.qtpl:40
+		qw422016.N().Q(nextCursor.String())
+//line native.qtpl:41
+	}
+//line native.qtpl:41
+	qw422016.N().S(`}`)
+//line native.qtpl:43
+}
+
+//line native.qtpl:43
+func WriteSearchSummaryResponse(qq422016 qtio422016.Writer, summaries []*query.TraceSummary, nextCursor *query.TraceCursor) {
+//line native.qtpl:43
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:43
+	StreamSearchSummaryResponse(qw422016, summaries, nextCursor)
+//line native.qtpl:43
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:43
+}
+
+//line native.qtpl:43
+func SearchSummaryResponse(summaries []*query.TraceSummary, nextCursor *query.TraceCursor) string {
+//line native.qtpl:43
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:43
+	WriteSearchSummaryResponse(qb422016, summaries, nextCursor)
+//line native.qtpl:43
+	qs422016 := string(qb422016.B)
+//line native.qtpl:43
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:43
+	return qs422016
+//line native.qtpl:43
+}
+
+//line native.qtpl:45
+func streamtraceJson(qw422016 *qt422016.Writer, t *trace) {
+//line native.qtpl:45
+	qw422016.N().S(`{"traceID":`)
+//line native.qtpl:47
+	qw422016.N().Q(t.traceID)
+//line native.qtpl:47
+	qw422016.N().S(`,"spans":[`)
+//line native.qtpl:49
+	if len(t.spans) > 0 {
+//line native.qtpl:50
+		streamfieldsJson(qw422016, t.spans[0])
+//line native.qtpl:51
+		for _, fields := range t.spans[1:] {
+//line native.qtpl:51
+			qw422016.N().S(`,`)
+//line native.qtpl:52
+			streamfieldsJson(qw422016, fields)
+//line native.qtpl:53
+		}
+//line native.qtpl:54
+	}
+//line native.qtpl:54
+	qw422016.N().S(`]}`)
+//line native.qtpl:57
+}
+
+//line native.qtpl:57
+func writetraceJson(qq422016 qtio422016.Writer, t *trace) {
+//line native.qtpl:57
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:57
+	streamtraceJson(qw422016, t)
+//line native.qtpl:57
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:57
+}
+
+//line native.qtpl:57
+func traceJson(t *trace) string {
+//line native.qtpl:57
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:57
+	writetraceJson(qb422016, t)
+//line native.qtpl:57
+	qs422016 := string(qb422016.B)
+//line native.qtpl:57
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:57
+	return qs422016
+//line native.qtpl:57
+}
+
+//line native.qtpl:59
+func streamfieldsJson(qw422016 *qt422016.Writer, fields []logstorage.Field) {
+//line native.qtpl:59
+	qw422016.N().S(`{`)
+//line native.qtpl:61
+	if len(fields) > 0 {
+//line native.qtpl:62
+		qw422016.N().Q(fields[0].Name)
+//line native.qtpl:62
+		qw422016.N().S(`:`)
+//line native.qtpl:62
+		qw422016.N().Q(fields[0].Value)
+//line native.qtpl:63
+		for _, f := range fields[1:] {
+//line native.qtpl:63
+			qw422016.N().S(`,`)
+//line native.qtpl:64
+			qw422016.N().Q(f.Name)
+//line native.qtpl:64
+			qw422016.N().S(`:`)
+//line native.qtpl:64
+			qw422016.N().Q(f.Value)
+//line native.qtpl:65
+		}
+//line native.qtpl:66
+	}
+//line native.qtpl:66
+	qw422016.N().S(`}`)
+//line native.qtpl:68
+}
+
+//line native.qtpl:68
+func writefieldsJson(qq422016 qtio422016.Writer, fields []logstorage.Field) {
+//line native.qtpl:68
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:68
+	streamfieldsJson(qw422016, fields)
+//line native.qtpl:68
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:68
+}
+
+//line native.qtpl:68
+func fieldsJson(fields []logstorage.Field) string {
+//line native.qtpl:68
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:68
+	writefieldsJson(qb422016, fields)
+//line native.qtpl:68
+	qs422016 := string(qb422016.B)
+//line native.qtpl:68
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:68
+	return qs422016
+//line native.qtpl:68
+}
+
+//line native.qtpl:70
+func StreamSpanBySpanIDResponse(qw422016 *qt422016.Writer, traceID string, span []logstorage.Field) {
+//line native.qtpl:70
+	qw422016.N().S(`{"traceID":`)
+//line native.qtpl:72
+	qw422016.N().Q(traceID)
+//line native.qtpl:72
+	qw422016.N().S(`,"span":`)
+//line native.qtpl:74
+	if span == nil {
+//line native.qtpl:74
+		qw422016.N().S(`null`)
+//line native.qtpl:76
+	} else {
+//line native.qtpl:77
+		streamfieldsJson(qw422016, span)
+//line native.qtpl:78
+	}
+//line native.qtpl:78
+	qw422016.N().S(`}`)
+//line native.qtpl:80
+}
+
+//line native.qtpl:80
+func WriteSpanBySpanIDResponse(qq422016 qtio422016.Writer, traceID string, span []logstorage.Field) {
+//line native.qtpl:80
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:80
+	StreamSpanBySpanIDResponse(qw422016, traceID, span)
+//line native.qtpl:80
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:80
+}
+
+//line native.qtpl:80
+func SpanBySpanIDResponse(traceID string, span []logstorage.Field) string {
+//line native.qtpl:80
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:80
+	WriteSpanBySpanIDResponse(qb422016, traceID, span)
+//line native.qtpl:80
+	qs422016 := string(qb422016.B)
+//line native.qtpl:80
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:80
+	return qs422016
+//line native.qtpl:80
+}
+
+//line native.qtpl:82
+func StreamLinksResponse(qw422016 *qt422016.Writer, traceID string, tl *query.TraceLinks) {
+//line native.qtpl:82
+	qw422016.N().S(`{"traceID":`)
+//line native.qtpl:84
+	qw422016.N().Q(traceID)
+//line native.qtpl:84
+	qw422016.N().S(`,"outgoing":`)
+//line native.qtpl:85
+	streamtraceLinksJson(qw422016, tl.Outgoing)
+//line native.qtpl:85
+	qw422016.N().S(`,"incoming":`)
+//line native.qtpl:86
+	streamtraceLinksJson(qw422016, tl.Incoming)
+//line native.qtpl:86
+	qw422016.N().S(`}`)
+//line native.qtpl:88
+}
+
+//line native.qtpl:88
+func WriteLinksResponse(qq422016 qtio422016.Writer, traceID string, tl *query.TraceLinks) {
+//line native.qtpl:88
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:88
+	StreamLinksResponse(qw422016, traceID, tl)
+//line native.qtpl:88
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:88
+}
+
+//line native.qtpl:88
+func LinksResponse(traceID string, tl *query.TraceLinks) string {
+//line native.qtpl:88
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:88
+	WriteLinksResponse(qb422016, traceID, tl)
+//line native.qtpl:88
+	qs422016 := string(qb422016.B)
+//line native.qtpl:88
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:88
+	return qs422016
+//line native.qtpl:88
+}
+
+//line native.qtpl:90
+func streamtraceLinksJson(qw422016 *qt422016.Writer, links []query.TraceLink) {
+//line native.qtpl:90
+	qw422016.N().S(`[`)
+//line native.qtpl:92
+	for i := range links {
+//line native.qtpl:93
+		if i > 0 {
+//line native.qtpl:93
+			qw422016.N().S(`,`)
+//line native.qtpl:93
+		}
+//line native.qtpl:93
+		qw422016.N().S(`{"spanID":`)
+//line native.qtpl:95
+		qw422016.N().Q(links[i].SpanID)
+//line native.qtpl:95
+		qw422016.N().S(`,"linkedTraceID":`)
+//line native.qtpl:96
+		qw422016.N().Q(links[i].LinkedTraceID)
+//line native.qtpl:96
+		qw422016.N().S(`,"linkedSpanID":`)
+//line native.qtpl:97
+		qw422016.N().Q(links[i].LinkedSpanID)
+//line native.qtpl:97
+		qw422016.N().S(`}`)
+//line native.qtpl:99
+	}
+//line native.qtpl:99
+	qw422016.N().S(`]`)
+//line native.qtpl:101
+}
+
+//line native.qtpl:101
+func writetraceLinksJson(qq422016 qtio422016.Writer, links []query.TraceLink) {
+//line native.qtpl:101
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:101
+	streamtraceLinksJson(qw422016, links)
+//line native.qtpl:101
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:101
+}
+
+//line native.qtpl:101
+func traceLinksJson(links []query.TraceLink) string {
+//line native.qtpl:101
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:101
+	writetraceLinksJson(qb422016, links)
+//line native.qtpl:101
+	qs422016 := string(qb422016.B)
+//line native.qtpl:101
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:101
+	return qs422016
+//line native.qtpl:101
+}
+
+//line native.qtpl:103
+func StreamStructuralSearchResponse(qw422016 *qt422016.Writer, traces []*query.TraceSpanRelationMatches) {
+//line native.qtpl:103
+	qw422016.N().S(`{"traces":[`)
+//line native.qtpl:106
+	if len(traces) > 0 {
+//line native.qtpl:107
+		streamtraceMatchesJson(qw422016, traces[0])
+//line native.qtpl:108
+		for _, t := range traces[1:] {
+//line native.qtpl:108
+			qw422016.N().S(`,`)
+//line native.qtpl:109
+			streamtraceMatchesJson(qw422016, t)
+//line native.qtpl:110
+		}
+//line native.qtpl:111
+	}
+//line native.qtpl:111
+	qw422016.N().S(`]}`)
+//line native.qtpl:114
+}
+
+//line native.qtpl:114
+func WriteStructuralSearchResponse(qq422016 qtio422016.Writer, traces []*query.TraceSpanRelationMatches) {
+//line native.qtpl:114
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:114
+	StreamStructuralSearchResponse(qw422016, traces)
+//line native.qtpl:114
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:114
+}
+
+//line native.qtpl:114
+func StructuralSearchResponse(traces []*query.TraceSpanRelationMatches) string {
+//line native.qtpl:114
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:114
+	WriteStructuralSearchResponse(qb422016, traces)
+//line native.qtpl:114
+	qs422016 := string(qb422016.B)
+//line native.qtpl:114
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:114
+	return qs422016
+//line native.qtpl:114
+}
+
+//line native.qtpl:116
+func streamtraceMatchesJson(qw422016 *qt422016.Writer, t *query.TraceSpanRelationMatches) {
+//line native.qtpl:116
+	qw422016.N().S(`{"traceID":`)
+//line native.qtpl:118
+	qw422016.N().Q(t.TraceID)
+//line native.qtpl:118
+	qw422016.N().S(`,"matches":[`)
+//line native.qtpl:120
+	if len(t.Matches) > 0 {
+//line native.qtpl:121
+		streamspanRelationMatchJson(qw422016, &t.Matches[0])
+//line native.qtpl:122
+		for i := range t.Matches[1:] {
+//line native.qtpl:122
+			qw422016.N().S(`,`)
+//line native.qtpl:123
+			streamspanRelationMatchJson(qw422016, &t.Matches[i+1])
+//line native.qtpl:124
+		}
+//line native.qtpl:125
+	}
+//line native.qtpl:125
+	qw422016.N().S(`]}`)
+//line native.qtpl:128
+}
+
+//line native.qtpl:128
+func writetraceMatchesJson(qq422016 qtio422016.Writer, t *query.TraceSpanRelationMatches) {
+//line native.qtpl:128
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:128
+	streamtraceMatchesJson(qw422016, t)
+//line native.qtpl:128
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:128
+}
+
+//line native.qtpl:128
+func traceMatchesJson(t *query.TraceSpanRelationMatches) string {
+//line native.qtpl:128
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:128
+	writetraceMatchesJson(qb422016, t)
+//line native.qtpl:128
+	qs422016 := string(qb422016.B)
+//line native.qtpl:128
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:128
+	return qs422016
+//line native.qtpl:128
+}
+
+//line native.qtpl:130
+func streamspanRelationMatchJson(qw422016 *qt422016.Writer, m *query.SpanRelationMatch) {
+//line native.qtpl:130
+	qw422016.N().S(`{"ancestorSpanID":`)
+//line native.qtpl:132
+	qw422016.N().Q(m.AncestorSpanID)
+//line native.qtpl:132
+	qw422016.N().S(`,"descendantSpanID":`)
+//line native.qtpl:133
+	qw422016.N().Q(m.DescendantSpanID)
+//line native.qtpl:133
+	qw422016.N().S(`}`)
+//line native.qtpl:135
+}
+
+//line native.qtpl:135
+func writespanRelationMatchJson(qq422016 qtio422016.Writer, m *query.SpanRelationMatch) {
+//line native.qtpl:135
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:135
+	streamspanRelationMatchJson(qw422016, m)
+//line native.qtpl:135
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:135
+}
+
+//line native.qtpl:135
+func spanRelationMatchJson(m *query.SpanRelationMatch) string {
+//line native.qtpl:135
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:135
+	writespanRelationMatchJson(qb422016, m)
+//line native.qtpl:135
+	qs422016 := string(qb422016.B)
+//line native.qtpl:135
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:135
+	return qs422016
+//line native.qtpl:135
+}
+
+//line native.qtpl:137
+func StreamCriticalPathResponse(qw422016 *qt422016.Writer, traceID string, path []*criticalPathSpan) {
+//line native.qtpl:137
+	qw422016.N().S(`{"traceID":`)
+//line native.qtpl:139
+	qw422016.N().Q(traceID)
+//line native.qtpl:139
+	qw422016.N().S(`,"criticalPath":[`)
+//line native.qtpl:141
+	if len(path) > 0 {
+//line native.qtpl:142
+		streamcriticalPathSpanJson(qw422016, path[0])
+//line native.qtpl:143
+		for _, cps := range path[1:] {
+//line native.qtpl:143
+			qw422016.N().S(`,`)
+//line native.qtpl:144
+			streamcriticalPathSpanJson(qw422016, cps)
+//line native.qtpl:145
+		}
+//line native.qtpl:146
+	}
+//line native.qtpl:146
+	qw422016.N().S(`]}`)
+//line native.qtpl:149
+}
+
+//line native.qtpl:149
+func WriteCriticalPathResponse(qq422016 qtio422016.Writer, traceID string, path []*criticalPathSpan) {
+//line native.qtpl:149
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:149
+	StreamCriticalPathResponse(qw422016, traceID, path)
+//line native.qtpl:149
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:149
+}
+
+//line native.qtpl:149
+func CriticalPathResponse(traceID string, path []*criticalPathSpan) string {
+//line native.qtpl:149
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:149
+	WriteCriticalPathResponse(qb422016, traceID, path)
+//line native.qtpl:149
+	qs422016 := string(qb422016.B)
+//line native.qtpl:149
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:149
+	return qs422016
+//line native.qtpl:149
+}
+
+//line native.qtpl:151
+func streamcriticalPathSpanJson(qw422016 *qt422016.Writer, cps *criticalPathSpan) {
+//line native.qtpl:151
+	qw422016.N().S(`{"spanID":`)
+//line native.qtpl:153
+	qw422016.N().Q(cps.span.spanID)
+//line native.qtpl:153
+	qw422016.N().S(`,"parentSpanID":`)
+//line native.qtpl:154
+	qw422016.N().Q(cps.span.parentSpanID)
+//line native.qtpl:154
+	qw422016.N().S(`,"serviceName":`)
+//line native.qtpl:155
+	qw422016.N().Q(cps.span.serviceName)
+//line native.qtpl:155
+	qw422016.N().S(`,"name":`)
+//line native.qtpl:156
+	qw422016.N().Q(cps.span.name)
+//line native.qtpl:156
+	qw422016.N().S(`,"startTimeUnixNano":`)
+//line native.qtpl:157
+	qw422016.N().DL(cps.span.startTime)
+//line native.qtpl:157
+	qw422016.N().S(`,"duration":`)
+//line native.qtpl:158
+	qw422016.N().DL(cps.span.duration())
+//line native.qtpl:158
+	qw422016.N().S(`,"selfTime":`)
+//line native.qtpl:159
+	qw422016.N().DL(cps.selfTime)
+//line native.qtpl:159
+	qw422016.N().S(`}`)
+//line native.qtpl:161
+}
+
+//line native.qtpl:161
+func writecriticalPathSpanJson(qq422016 qtio422016.Writer, cps *criticalPathSpan) {
+//line native.qtpl:161
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:161
+	streamcriticalPathSpanJson(qw422016, cps)
+//line native.qtpl:161
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:161
+}
+
+//line native.qtpl:161
+func criticalPathSpanJson(cps *criticalPathSpan) string {
+//line native.qtpl:161
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:161
+	writecriticalPathSpanJson(qb422016, cps)
+//line native.qtpl:161
+	qs422016 := string(qb422016.B)
+//line native.qtpl:161
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:161
+	return qs422016
+//line native.qtpl:161
+}
+
+//line native.qtpl:163
+func StreamCompareResponse(qw422016 *qt422016.Writer, td *traceDiff) {
+//line native.qtpl:163
+	qw422016.N().S(`{"a":`)
+//line native.qtpl:165
+	qw422016.N().Q(td.aTraceID)
+//line native.qtpl:165
+	qw422016.N().S(`,"b":`)
+//line native.qtpl:166
+	qw422016.N().Q(td.bTraceID)
+//line native.qtpl:166
+	qw422016.N().S(`,"summary":{"durationA":`)
+//line native.qtpl:168
+	qw422016.N().DL(td.aDuration)
+//line native.qtpl:168
+	qw422016.N().S(`,"durationB":`)
+//line native.qtpl:169
+	qw422016.N().DL(td.bDuration)
+//line native.qtpl:169
+	qw422016.N().S(`,"durationDelta":`)
+//line native.qtpl:170
+	qw422016.N().DL(td.bDuration - td.aDuration)
+//line native.qtpl:170
+	qw422016.N().S(`,"matchedSpans":`)
+//line native.qtpl:171
+	qw422016.N().D(td.matchedSpans)
+//line native.qtpl:171
+	qw422016.N().S(`,"addedSpans":`)
+//line native.qtpl:172
+	qw422016.N().D(td.addedSpans)
+//line native.qtpl:172
+	qw422016.N().S(`,"removedSpans":`)
+//line native.qtpl:173
+	qw422016.N().D(td.removedSpans)
+//line native.qtpl:173
+	qw422016.N().S(`,"errorStatusChanged":`)
+//line native.qtpl:174
+	qw422016.N().D(td.errorStatusChanged)
+//line native.qtpl:174
+	qw422016.N().S(`},"spans":`)
+//line native.qtpl:176
+	streamdiffNodesJson(qw422016, td.roots)
+//line native.qtpl:176
+	qw422016.N().S(`}`)
+//line native.qtpl:178
+}
+
+//line native.qtpl:178
+func WriteCompareResponse(qq422016 qtio422016.Writer, td *traceDiff) {
+//line native.qtpl:178
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:178
+	StreamCompareResponse(qw422016, td)
+//line native.qtpl:178
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:178
+}
+
+//line native.qtpl:178
+func CompareResponse(td *traceDiff) string {
+//line native.qtpl:178
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:178
+	WriteCompareResponse(qb422016, td)
+//line native.qtpl:178
+	qs422016 := string(qb422016.B)
+//line native.qtpl:178
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:178
+	return qs422016
+//line native.qtpl:178
+}
+
+//line native.qtpl:180
+func streamdiffNodesJson(qw422016 *qt422016.Writer, nodes []*diffNode) {
+//line native.qtpl:180
+	qw422016.N().S(`[`)
+//line native.qtpl:182
+	if len(nodes) > 0 {
+//line native.qtpl:183
+		streamdiffNodeJson(qw422016, nodes[0])
+//line native.qtpl:184
+		for _, dn := range nodes[1:] {
+//line native.qtpl:184
+			qw422016.N().S(`,`)
+//line native.qtpl:185
+			streamdiffNodeJson(qw422016, dn)
+//line native.qtpl:186
+		}
+//line native.qtpl:187
+	}
+//line native.qtpl:187
+	qw422016.N().S(`]`)
+//line native.qtpl:189
+}
+
+//line native.qtpl:189
+func writediffNodesJson(qq422016 qtio422016.Writer, nodes []*diffNode) {
+//line native.qtpl:189
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:189
+	streamdiffNodesJson(qw422016, nodes)
+//line native.qtpl:189
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:189
+}
+
+//line native.qtpl:189
+func diffNodesJson(nodes []*diffNode) string {
+//line native.qtpl:189
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:189
+	writediffNodesJson(qb422016, nodes)
+//line native.qtpl:189
+	qs422016 := string(qb422016.B)
+//line native.qtpl:189
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:189
+	return qs422016
+//line native.qtpl:189
+}
+
+//line native.qtpl:191
+func streamdiffNodeJson(qw422016 *qt422016.Writer, dn *diffNode) {
+//line native.qtpl:191
+	qw422016.N().S(`{"serviceName":`)
+//line native.qtpl:193
+	qw422016.N().Q(dn.serviceName)
+//line native.qtpl:193
+	qw422016.N().S(`,"name":`)
+//line native.qtpl:194
+	qw422016.N().Q(dn.name)
+//line native.qtpl:194
+	qw422016.N().S(`,"status":`)
+//line native.qtpl:195
+	qw422016.N().Q(string(dn.status()))
+//line native.qtpl:195
+	qw422016.N().S(`,"a":`)
+//line native.qtpl:196
+	streamdiffSpanJson(qw422016, dn.a)
+//line native.qtpl:196
+	qw422016.N().S(`,"b":`)
+//line native.qtpl:197
+	streamdiffSpanJson(qw422016, dn.b)
+//line native.qtpl:197
+	qw422016.N().S(`,"durationDelta":`)
+//line native.qtpl:198
+	qw422016.N().DL(dn.durationDelta())
+//line native.qtpl:198
+	qw422016.N().S(`,"errorChanged":`)
+//line native.qtpl:199
+	if dn.errorChanged() {
+//line native.qtpl:199
+		qw422016.N().S(`true`)
+//line native.qtpl:199
+	} else {
+//line native.qtpl:199
+		qw422016.N().S(`false`)
+//line native.qtpl:199
+	}
+//line native.qtpl:199
+	qw422016.N().S(`,"attributes":[`)
+//line native.qtpl:201
+	for i, ad := range dn.attributeDiffs {
+//line native.qtpl:202
+		if i > 0 {
+//line native.qtpl:202
+			qw422016.N().S(`,`)
+//line native.qtpl:202
+		}
+//line native.qtpl:202
+		qw422016.N().S(`{"key":`)
+//line native.qtpl:204
+		qw422016.N().Q(ad.key)
+//line native.qtpl:204
+		qw422016.N().S(`,"a":`)
+//line native.qtpl:205
+		streamoptionalStringJson(qw422016, ad.aValue)
+//line native.qtpl:205
+		qw422016.N().S(`,"b":`)
+//line native.qtpl:206
+		streamoptionalStringJson(qw422016, ad.bValue)
+//line native.qtpl:206
+		qw422016.N().S(`}`)
+//line native.qtpl:208
+	}
+//line native.qtpl:208
+	qw422016.N().S(`],"children":`)
+//line native.qtpl:210
+	streamdiffNodesJson(qw422016, dn.children)
+//line native.qtpl:210
+	qw422016.N().S(`}`)
+//line native.qtpl:212
+}
+
+//line native.qtpl:212
+func writediffNodeJson(qq422016 qtio422016.Writer, dn *diffNode) {
+//line native.qtpl:212
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:212
+	streamdiffNodeJson(qw422016, dn)
+//line native.qtpl:212
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:212
+}
+
+//line native.qtpl:212
+func diffNodeJson(dn *diffNode) string {
+//line native.qtpl:212
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:212
+	writediffNodeJson(qb422016, dn)
+//line native.qtpl:212
+	qs422016 := string(qb422016.B)
+//line native.qtpl:212
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:212
+	return qs422016
+//line native.qtpl:212
+}
+
+//line native.qtpl:214
+func streamdiffSpanJson(qw422016 *qt422016.Writer, sn *spanNode) {
+//line native.qtpl:215
+	if sn == nil {
+//line native.qtpl:215
+		qw422016.N().S(`null`)
+//line native.qtpl:217
+	} else {
+//line native.qtpl:217
+		qw422016.N().S(`{"spanID":`)
+//line native.qtpl:219
+		qw422016.N().Q(sn.spanID)
+//line native.qtpl:219
+		qw422016.N().S(`,"startTimeUnixNano":`)
+//line native.qtpl:220
+		qw422016.N().DL(sn.startTime)
+//line native.qtpl:220
+		qw422016.N().S(`,"duration":`)
+//line native.qtpl:221
+		qw422016.N().DL(sn.duration())
+//line native.qtpl:221
+		qw422016.N().S(`,"error":`)
+//line native.qtpl:222
+		if sn.isError() {
+//line native.qtpl:222
+			qw422016.N().S(`true`)
+//line native.qtpl:222
+		} else {
+//line native.qtpl:222
+			qw422016.N().S(`false`)
+//line native.qtpl:222
+		}
+//line native.qtpl:222
+		qw422016.N().S(`}`)
+//line native.qtpl:224
+	}
+//line native.qtpl:225
+}
+
+//line native.qtpl:225
+func writediffSpanJson(qq422016 qtio422016.Writer, sn *spanNode) {
+//line native.qtpl:225
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:225
+	streamdiffSpanJson(qw422016, sn)
+//line native.qtpl:225
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:225
+}
+
+//line native.qtpl:225
+func diffSpanJson(sn *spanNode) string {
+//line native.qtpl:225
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:225
+	writediffSpanJson(qb422016, sn)
+//line native.qtpl:225
+	qs422016 := string(qb422016.B)
+//line native.qtpl:225
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:225
+	return qs422016
+//line native.qtpl:225
+}
+
+//line native.qtpl:227
+func streamoptionalStringJson(qw422016 *qt422016.Writer, s *string) {
+//line native.qtpl:228
+	if s == nil {
+//line native.qtpl:228
+		qw422016.N().S(`null`)
+//line native.qtpl:230
+	} else {
+//line native.qtpl:231
+		qw422016.N().Q(*s)
+//line native.qtpl:232
+	}
+//line native.qtpl:233
+}
+
+//line native.qtpl:233
+func writeoptionalStringJson(qq422016 qtio422016.Writer, s *string) {
+//line native.qtpl:233
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:233
+	streamoptionalStringJson(qw422016, s)
+//line native.qtpl:233
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:233
+}
+
+//line native.qtpl:233
+func optionalStringJson(s *string) string {
+//line native.qtpl:233
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:233
+	writeoptionalStringJson(qb422016, s)
+//line native.qtpl:233
+	qs422016 := string(qb422016.B)
+//line native.qtpl:233
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:233
+	return qs422016
+//line native.qtpl:233
+}
+
+//line native.qtpl:235
+func StreamStatsResponse(qw422016 *qt422016.Writer, ts *traceStats) {
+//line native.qtpl:235
+	qw422016.N().S(`{"traces":`)
+//line native.qtpl:237
+	qw422016.N().D(ts.traces)
+//line native.qtpl:237
+	qw422016.N().S(`,"truncated":`)
+//line native.qtpl:238
+	if ts.truncated {
+//line native.qtpl:238
+		qw422016.N().S(`true`)
+//line native.qtpl:238
+	} else {
+//line native.qtpl:238
+		qw422016.N().S(`false`)
+//line native.qtpl:238
+	}
+//line native.qtpl:238
+	qw422016.N().S(`,"operations":[`)
+//line native.qtpl:240
+	for i, s := range ts.operations() {
+//line native.qtpl:241
+		if i > 0 {
+//line native.qtpl:241
+			qw422016.N().S(`,`)
+//line native.qtpl:241
+		}
+//line native.qtpl:241
+		qw422016.N().S(`{"serviceName":`)
+//line native.qtpl:243
+		qw422016.N().Q(s.serviceName)
+//line native.qtpl:243
+		qw422016.N().S(`,"name":`)
+//line native.qtpl:244
+		qw422016.N().Q(s.name)
+//line native.qtpl:244
+		qw422016.N().S(`,"count":`)
+//line native.qtpl:245
+		qw422016.N().DUL(s.count)
+//line native.qtpl:245
+		qw422016.N().S(`,"errorCount":`)
+//line native.qtpl:246
+		qw422016.N().DUL(s.errorCount)
+//line native.qtpl:246
+		qw422016.N().S(`,"errorRatio":`)
+//line native.qtpl:247
+		qw422016.N().F(s.errorRatio())
+//line native.qtpl:247
+		qw422016.N().S(`,"totalDuration":`)
+//line native.qtpl:248
+		qw422016.N().DL(s.totalDuration)
+//line native.qtpl:248
+		qw422016.N().S(`,"avgDuration":`)
+//line native.qtpl:249
+		qw422016.N().DL(s.avgDuration())
+//line native.qtpl:249
+		qw422016.N().S(`,"minDuration":`)
+//line native.qtpl:250
+		qw422016.N().DL(s.minDuration)
+//line native.qtpl:250
+		qw422016.N().S(`,"maxDuration":`)
+//line native.qtpl:251
+		qw422016.N().DL(s.maxDuration)
+//line native.qtpl:251
+		qw422016.N().S(`,"selfDuration":`)
+//line native.qtpl:252
+		qw422016.N().DL(s.selfDuration)
+//line native.qtpl:252
+		qw422016.N().S(`,"avgSelfDuration":`)
+//line native.qtpl:253
+		qw422016.N().DL(s.avgSelfDuration())
+//line native.qtpl:253
+		qw422016.N().S(`}`)
+//line native.qtpl:255
+	}
+//line native.qtpl:255
+	qw422016.N().S(`]}`)
+//line native.qtpl:258
+}
+
+//line native.qtpl:258
+func WriteStatsResponse(qq422016 qtio422016.Writer, ts *traceStats) {
+//line native.qtpl:258
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:258
+	StreamStatsResponse(qw422016, ts)
+//line native.qtpl:258
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:258
+}
+
+//line native.qtpl:258
+func StatsResponse(ts *traceStats) string {
+//line native.qtpl:258
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:258
+	WriteStatsResponse(qb422016, ts)
+//line native.qtpl:258
+	qs422016 := string(qb422016.B)
+//line native.qtpl:258
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:258
+	return qs422016
+//line native.qtpl:258
+}
+
+//line native.qtpl:260
+func StreamExceptionsResponse(qw422016 *qt422016.Writer, groups []*query.ExceptionGroup) {
+//line native.qtpl:260
+	qw422016.N().S(`{"exceptions":[`)
+//line native.qtpl:263
+	for i, eg := range groups {
+//line native.qtpl:264
+		if i > 0 {
+//line native.qtpl:264
+			qw422016.N().S(`,`)
+//line native.qtpl:264
+		}
+//line native.qtpl:264
+		qw422016.N().S(`{"serviceName":`)
+//line native.qtpl:266
+		qw422016.N().Q(eg.ServiceName)
+//line native.qtpl:266
+		qw422016.N().S(`,"type":`)
+//line native.qtpl:267
+		qw422016.N().Q(eg.ExceptionType)
+//line native.qtpl:267
+		qw422016.N().S(`,"message":`)
+//line native.qtpl:268
+		qw422016.N().Q(eg.Message)
+//line native.qtpl:268
+		qw422016.N().S(`,"count":`)
+//line native.qtpl:269
+		qw422016.N().DUL(eg.Count)
+//line native.qtpl:269
+		qw422016.N().S(`,"firstSeenUnixNano":"`)
+//line native.qtpl:270
+		qw422016.N().DL(eg.FirstSeen.UnixNano())
+//line native.qtpl:270
+		qw422016.N().S(`","lastSeenUnixNano":"`)
+//line native.qtpl:271
+		qw422016.N().DL(eg.LastSeen.UnixNano())
+//line native.qtpl:271
+		qw422016.N().S(`","exampleTraceIDs":[`)
+//line native.qtpl:273
+		for j, traceID := range eg.ExampleTraceIDs {
+//line native.qtpl:274
+			if j > 0 {
+//line native.qtpl:274
+				qw422016.N().S(`,`)
+//line native.qtpl:274
+			}
+//line native.qtpl:275
+			qw422016.N().Q(traceID)
+//line native.qtpl:276
+		}
+//line native.qtpl:276
+		qw422016.N().S(`],"stacktrace":`)
+//line native.qtpl:278
+		qw422016.N().Q(eg.Stacktrace)
+//line native.qtpl:278
+		qw422016.N().S(`,"points":[`)
+//line native.qtpl:280
+		for j, p := range eg.Points {
+//line native.qtpl:281
+			if j > 0 {
+//line native.qtpl:281
+				qw422016.N().S(`,`)
+//line native.qtpl:281
+			}
+//line native.qtpl:281
+			qw422016.N().S(`{"timestampUnixNano":"`)
+//line native.qtpl:282
+			qw422016.N().DL(p.Timestamp.UnixNano())
+//line native.qtpl:282
+			qw422016.N().S(`","count":`)
+//line native.qtpl:282
+			qw422016.N().DUL(p.Count)
+//line native.qtpl:282
+			qw422016.N().S(`}`)
+//line native.qtpl:283
+		}
+//line native.qtpl:283
+		qw422016.N().S(`]}`)
+//line native.qtpl:286
+	}
+//line native.qtpl:286
+	qw422016.N().S(`]}`)
+//line native.qtpl:289
+}
+
+//line native.qtpl:289
+func WriteExceptionsResponse(qq422016 qtio422016.Writer, groups []*query.ExceptionGroup) {
+//line native.qtpl:289
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:289
+	StreamExceptionsResponse(qw422016, groups)
+//line native.qtpl:289
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:289
+}
+
+//line native.qtpl:289
+func ExceptionsResponse(groups []*query.ExceptionGroup) string {
+//line native.qtpl:289
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:289
+	WriteExceptionsResponse(qb422016, groups)
+//line native.qtpl:289
+	qs422016 := string(qb422016.B)
+//line native.qtpl:289
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:289
+	return qs422016
+//line native.qtpl:289
+}
+
+//line native.qtpl:291
+func StreamDependenciesResponse(qw422016 *qt422016.Writer, edges []*query.ServiceGraphEdge) {
+//line native.qtpl:291
+	qw422016.N().S(`{"dependencies":[`)
+//line native.qtpl:294
+	for i, edge := range edges {
+//line native.qtpl:295
+		if i > 0 {
+//line native.qtpl:295
+			qw422016.N().S(`,`)
+//line native.qtpl:295
+		}
+//line native.qtpl:295
+		qw422016.N().S(`{"parent":`)
+//line native.qtpl:297
+		qw422016.N().Q(edge.Parent)
+//line native.qtpl:297
+		qw422016.N().S(`,"child":`)
+//line native.qtpl:298
+		qw422016.N().Q(edge.Child)
+//line native.qtpl:298
+		qw422016.N().S(`,"callCount":`)
+//line native.qtpl:299
+		qw422016.N().DUL(edge.CallCount)
+//line native.qtpl:299
+		qw422016.N().S(`,"errorCount":`)
+//line native.qtpl:300
+		qw422016.N().DUL(edge.FailedCount)
+//line native.qtpl:300
+		qw422016.N().S(`,"client":`)
+//line native.qtpl:301
+		streamdependencyStatsJson(qw422016, &edge.Client)
+//line native.qtpl:301
+		qw422016.N().S(`,"server":`)
+//line native.qtpl:302
+		streamdependencyStatsJson(qw422016, &edge.Server)
+//line native.qtpl:302
+		qw422016.N().S(`}`)
+//line native.qtpl:304
+	}
+//line native.qtpl:304
+	qw422016.N().S(`]}`)
+//line native.qtpl:307
+}
+
+//line native.qtpl:307
+func WriteDependenciesResponse(qq422016 qtio422016.Writer, edges []*query.ServiceGraphEdge) {
+//line native.qtpl:307
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:307
+	StreamDependenciesResponse(qw422016, edges)
+//line native.qtpl:307
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:307
+}
+
+//line native.qtpl:307
+func DependenciesResponse(edges []*query.ServiceGraphEdge) string {
+//line native.qtpl:307
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:307
+	WriteDependenciesResponse(qb422016, edges)
+//line native.qtpl:307
+	qs422016 := string(qb422016.B)
+//line native.qtpl:307
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:307
+	return qs422016
+//line native.qtpl:307
+}
+
+//line native.qtpl:309
+func streamdependencyStatsJson(qw422016 *qt422016.Writer, s *query.ServiceGraphEdgeStats) {
+//line native.qtpl:309
+	qw422016.N().S(`{"errorCount":`)
+//line native.qtpl:311
+	qw422016.N().DUL(s.ErrorCount)
+//line native.qtpl:311
+	qw422016.N().S(`,"p50Duration":`)
+//line native.qtpl:312
+	qw422016.N().DL(s.P50.Nanoseconds())
+//line native.qtpl:312
+	qw422016.N().S(`,"p90Duration":`)
+//line native.qtpl:313
+	qw422016.N().DL(s.P90.Nanoseconds())
+//line native.qtpl:313
+	qw422016.N().S(`,"p99Duration":`)
+//line native.qtpl:314
+	qw422016.N().DL(s.P99.Nanoseconds())
+//line native.qtpl:314
+	qw422016.N().S(`}`)
+//line native.qtpl:316
+}
+
+//line native.qtpl:316
+func writedependencyStatsJson(qq422016 qtio422016.Writer, s *query.ServiceGraphEdgeStats) {
+//line native.qtpl:316
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line native.qtpl:316
+	streamdependencyStatsJson(qw422016, s)
+//line native.qtpl:316
+	qt422016.ReleaseWriter(qw422016)
+//line native.qtpl:316
+}
+
+//line native.qtpl:316
+func dependencyStatsJson(s *query.ServiceGraphEdgeStats) string {
+//line native.qtpl:316
+	qb422016 := qt422016.AcquireByteBuffer()
+//line native.qtpl:316
+	writedependencyStatsJson(qb422016, s)
+//line native.qtpl:316
+	qs422016 := string(qb422016.B)
+//line native.qtpl:316
+	qt422016.ReleaseByteBuffer(qb422016)
+//line native.qtpl:316
+	return qs422016
+//line native.qtpl:316
+}
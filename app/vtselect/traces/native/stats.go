@@ -0,0 +1,131 @@
+package native
+
+import (
+	"sort"
+)
+
+// operationStats is the aggregated statistics of spans with the same service name and span name.
+type operationStats struct {
+	serviceName string
+	name        string
+
+	count      uint64
+	errorCount uint64
+
+	// durations are in nanoseconds.
+	totalDuration int64
+	minDuration   int64
+	maxDuration   int64
+	selfDuration  int64
+}
+
+func (s *operationStats) avgDuration() int64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.totalDuration / int64(s.count)
+}
+
+func (s *operationStats) avgSelfDuration() int64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.selfDuration / int64(s.count)
+}
+
+func (s *operationStats) errorRatio() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return float64(s.errorCount) / float64(s.count)
+}
+
+// traceStats aggregates the statistics of spans per (service name, span name) across many traces.
+type traceStats struct {
+	traces int
+	ops    map[operationKey]*operationStats
+
+	// truncated is set if there are more matching traces than the aggregated ones.
+	truncated bool
+}
+
+type operationKey struct {
+	serviceName string
+	name        string
+}
+
+func newTraceStats() *traceStats {
+	return &traceStats{
+		ops: make(map[operationKey]*operationStats),
+	}
+}
+
+// add adds the spans of st to ts.
+func (ts *traceStats) add(st *spanTree) {
+	ts.traces++
+	for _, sn := range st.spans {
+		k := operationKey{serviceName: sn.serviceName, name: sn.name}
+		s, ok := ts.ops[k]
+		if !ok {
+			s = &operationStats{
+				serviceName: sn.serviceName,
+				name:        sn.name,
+				minDuration: sn.duration(),
+				maxDuration: sn.duration(),
+			}
+			ts.ops[k] = s
+		}
+		d := sn.duration()
+		s.count++
+		if sn.isError() {
+			s.errorCount++
+		}
+		s.totalDuration += d
+		s.minDuration = min(s.minDuration, d)
+		s.maxDuration = max(s.maxDuration, d)
+		s.selfDuration += selfTime(sn)
+	}
+}
+
+// operations returns the aggregated statistics sorted by the total self duration in descending order,
+// so the operations where the most time goes are at the top.
+func (ts *traceStats) operations() []*operationStats {
+	result := make([]*operationStats, 0, len(ts.ops))
+	for _, s := range ts.ops {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.selfDuration != b.selfDuration {
+			return a.selfDuration > b.selfDuration
+		}
+		if a.serviceName != b.serviceName {
+			return a.serviceName < b.serviceName
+		}
+		return a.name < b.name
+	})
+	return result
+}
+
+// selfTime returns the time in nanoseconds, during which none of the children of sn is running.
+//
+// The children are truncated to the bounds of sn, and the overlapping children are counted only once.
+func selfTime(sn *spanNode) int64 {
+	if len(sn.children) == 0 {
+		return sn.duration()
+	}
+
+	// children are sorted by start time, so the union of their intervals can be calculated in a single pass.
+	var covered int64
+	coveredEnd := sn.startTime
+	for _, child := range sn.children {
+		start := max(child.startTime, coveredEnd)
+		end := min(child.endTime, sn.endTime)
+		if start >= end {
+			continue
+		}
+		covered += end - start
+		coveredEnd = end
+	}
+	return sn.duration() - covered
+}
@@ -0,0 +1,104 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestSelfTime(t *testing.T) {
+	f := func(spans [][]logstorage.Field, selfTimeExpected int64) {
+		t.Helper()
+
+		st, err := buildSpanTree(spans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := selfTime(st.roots[0])
+		if result != selfTimeExpected {
+			t.Fatalf("unexpected self time; got %d; want %d", result, selfTimeExpected)
+		}
+	}
+
+	// no children
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+	}, 100)
+
+	// sequential children
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "a", 10, 20),
+		newTestSpan("c", "a", 30, 50),
+	}, 70)
+
+	// overlapping and nested children
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 0, 100),
+		newTestSpan("b", "a", 10, 40),
+		newTestSpan("c", "a", 20, 30),
+		newTestSpan("d", "a", 35, 60),
+	}, 50)
+
+	// children outside the parent
+	f([][]logstorage.Field{
+		newTestSpan("a", "", 10, 100),
+		newTestSpan("b", "a", 0, 20),
+		newTestSpan("c", "a", 90, 150),
+		newTestSpan("d", "a", 200, 300),
+	}, 70)
+}
+
+func TestTraceStats(t *testing.T) {
+	ts := newTraceStats()
+	for _, spans := range [][][]logstorage.Field{
+		{
+			newTestNamedSpan("a1", "", "frontend", "GET", 0, 100),
+			newTestNamedSpan("a2", "a1", "backend", "query", 10, 90),
+		},
+		{
+			newTestNamedSpan("b1", "", "frontend", "GET", 0, 300),
+			newTestNamedSpan("b2", "b1", "backend", "query", 100, 200, logstorage.Field{Name: otelpb.StatusCodeField, Value: "2"}),
+		},
+	} {
+		st, err := buildSpanTree(spans)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ts.add(st)
+	}
+
+	if ts.traces != 2 {
+		t.Fatalf("unexpected number of traces; got %d; want 2", ts.traces)
+	}
+	ops := ts.operations()
+	if len(ops) != 2 {
+		t.Fatalf("unexpected number of operations; got %d; want 2", len(ops))
+	}
+
+	f := func(s *operationStats, serviceName, name string, count, errorCount uint64, totalDuration, minDuration, maxDuration, selfDuration int64) {
+		t.Helper()
+
+		if s.serviceName != serviceName || s.name != name {
+			t.Fatalf("unexpected operation; got %s:%s; want %s:%s", s.serviceName, s.name, serviceName, name)
+		}
+		if s.count != count || s.errorCount != errorCount {
+			t.Fatalf("unexpected counts for %s:%s; got %d, %d; want %d, %d", serviceName, name, s.count, s.errorCount, count, errorCount)
+		}
+		if s.totalDuration != totalDuration || s.minDuration != minDuration || s.maxDuration != maxDuration || s.selfDuration != selfDuration {
+			t.Fatalf("unexpected durations for %s:%s; got total=%d, min=%d, max=%d, self=%d; want total=%d, min=%d, max=%d, self=%d",
+				serviceName, name, s.totalDuration, s.minDuration, s.maxDuration, s.selfDuration, totalDuration, minDuration, maxDuration, selfDuration)
+		}
+	}
+	f(ops[0], "frontend", "GET", 2, 0, 400, 100, 300, 220)
+	f(ops[1], "backend", "query", 2, 1, 180, 80, 100, 180)
+
+	if ratio := ops[1].errorRatio(); ratio != 0.5 {
+		t.Fatalf("unexpected error ratio; got %v; want 0.5", ratio)
+	}
+	if avg := ops[0].avgSelfDuration(); avg != 110 {
+		t.Fatalf("unexpected avg self duration; got %d; want 110", avg)
+	}
+}
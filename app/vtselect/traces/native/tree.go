@@ -0,0 +1,153 @@
+package native
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// spanNode is a span in the span tree of a trace.
+type spanNode struct {
+	spanID       string
+	parentSpanID string
+	name         string
+	serviceName  string
+	statusCode   string
+
+	// startTime and endTime are unix timestamps in nanoseconds.
+	startTime int64
+	endTime   int64
+
+	// fields contains all the stored fields of the span.
+	fields []logstorage.Field
+
+	parent   *spanNode
+	children []*spanNode
+}
+
+func (sn *spanNode) duration() int64 {
+	return sn.endTime - sn.startTime
+}
+
+// isError returns true if the span has the error status code.
+func (sn *spanNode) isError() bool {
+	return sn.statusCode == "2"
+}
+
+// spanTree is the span tree of a trace.
+type spanTree struct {
+	// roots contains spans without parent span or with missing parent span, sorted by start time.
+	roots []*spanNode
+	// spans contains all the spans of the trace, sorted by start time.
+	spans []*spanNode
+}
+
+// startTime returns the start time of the earliest span in st.
+func (st *spanTree) startTime() int64 {
+	if len(st.spans) == 0 {
+		return 0
+	}
+	return st.spans[0].startTime
+}
+
+// duration returns the time between the start of the earliest span and the end of the latest span in st.
+func (st *spanTree) duration() int64 {
+	if len(st.spans) == 0 {
+		return 0
+	}
+	endTime := st.spans[0].endTime
+	for _, sn := range st.spans[1:] {
+		endTime = max(endTime, sn.endTime)
+	}
+	return endTime - st.startTime()
+}
+
+// newSpanNode creates spanNode from the stored span fields.
+func newSpanNode(fields []logstorage.Field) (*spanNode, error) {
+	sn := &spanNode{
+		fields: fields,
+	}
+	for _, f := range fields {
+		switch f.Name {
+		case otelpb.SpanIDField:
+			sn.spanID = f.Value
+		case otelpb.ParentSpanIDField:
+			sn.parentSpanID = f.Value
+		case otelpb.NameField:
+			sn.name = f.Value
+		case otelpb.ResourceAttrServiceName:
+			sn.serviceName = f.Value
+		case otelpb.StatusCodeField:
+			sn.statusCode = f.Value
+		case otelpb.StartTimeUnixNanoField:
+			n, err := strconv.ParseInt(f.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start_time_unix_nano field: %s", err)
+			}
+			sn.startTime = n
+		case otelpb.EndTimeUnixNanoField:
+			n, err := strconv.ParseInt(f.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end_time_unix_nano field: %s", err)
+			}
+			sn.endTime = n
+		}
+	}
+	if sn.spanID == "" {
+		return nil, fmt.Errorf("missing span_id field in %v", fields)
+	}
+	if sn.endTime < sn.startTime {
+		sn.endTime = sn.startTime
+	}
+	return sn, nil
+}
+
+// buildSpanTree builds the span tree from the spans of a trace.
+//
+// The children of every span are sorted by start time. Spans with duplicated span ID are ignored.
+// Spans referring to the missing parent span, or forming a cycle, become roots.
+func buildSpanTree(spans [][]logstorage.Field) (*spanTree, error) {
+	st := &spanTree{
+		spans: make([]*spanNode, 0, len(spans)),
+	}
+	spansByID := make(map[string]*spanNode, len(spans))
+	for _, fields := range spans {
+		sn, err := newSpanNode(fields)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := spansByID[sn.spanID]; ok {
+			continue
+		}
+		spansByID[sn.spanID] = sn
+		st.spans = append(st.spans, sn)
+	}
+	sort.SliceStable(st.spans, func(i, j int) bool {
+		return st.spans[i].startTime < st.spans[j].startTime
+	})
+
+	for _, sn := range st.spans {
+		parent, ok := spansByID[sn.parentSpanID]
+		if !ok || parent == sn || isAncestorOf(sn, parent) {
+			st.roots = append(st.roots, sn)
+			continue
+		}
+		sn.parent = parent
+		parent.children = append(parent.children, sn)
+	}
+	return st, nil
+}
+
+// isAncestorOf returns true if sn is an ancestor of other in the already linked spans.
+func isAncestorOf(sn, other *spanNode) bool {
+	for p := other.parent; p != nil; p = p.parent {
+		if p == sn {
+			return true
+		}
+	}
+	return false
+}
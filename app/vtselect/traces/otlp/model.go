@@ -0,0 +1,272 @@
+package otlp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// rowsToExportTraceServiceRequest rebuilds the OTLP request from spans in rows.
+//
+// Spans are grouped back into ResourceSpans by resource attributes, and then into ScopeSpans by instrumentation scope.
+//
+// Note that the attribute values are stored as strings, so all the attributes are rebuilt as string values,
+// and the nested key-value list attributes are rebuilt as flattened attributes with `.` joined keys.
+func rowsToExportTraceServiceRequest(rows []*query.Row) (*otelpb.ExportTraceServiceRequest, error) {
+	req := &otelpb.ExportTraceServiceRequest{
+		ResourceSpans: make([]*otelpb.ResourceSpans, 0),
+	}
+	resourceSpansMap := make(map[string]*otelpb.ResourceSpans)
+	scopeSpansMap := make(map[string]*otelpb.ScopeSpans)
+
+	for i := range rows {
+		resource, scope, sp, err := fieldsToSpan(rows[i].Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		resourceKey := attributesKey(resource.Attributes)
+		rs, ok := resourceSpansMap[resourceKey]
+		if !ok {
+			rs = &otelpb.ResourceSpans{
+				Resource: *resource,
+			}
+			resourceSpansMap[resourceKey] = rs
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+
+		scopeKey := resourceKey + "\x00" + scope.Name + "\x00" + scope.Version + "\x00" + attributesKey(scope.Attributes)
+		ss, ok := scopeSpansMap[scopeKey]
+		if !ok {
+			ss = &otelpb.ScopeSpans{
+				Scope: *scope,
+			}
+			scopeSpansMap[scopeKey] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, sp)
+	}
+
+	// the spans are returned from storage in random order. Sort them for stable result.
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			sort.Slice(ss.Spans, func(i, j int) bool {
+				if ss.Spans[i].StartTimeUnixNano != ss.Spans[j].StartTimeUnixNano {
+					return ss.Spans[i].StartTimeUnixNano < ss.Spans[j].StartTimeUnixNano
+				}
+				return ss.Spans[i].SpanID < ss.Spans[j].SpanID
+			})
+		}
+	}
+
+	return req, nil
+}
+
+// fieldsToSpan converts span in fields to OTLP span, and the resource and instrumentation scope it belongs to.
+//
+// See: lib/protoparser/opentelemetry/pb/trace_fields.go
+func fieldsToSpan(fields []logstorage.Field) (*otelpb.Resource, *otelpb.InstrumentationScope, *otelpb.Span, error) {
+	resource := &otelpb.Resource{}
+	scope := &otelpb.InstrumentationScope{}
+	sp := &otelpb.Span{}
+
+	eventsMap := make(map[int]*otelpb.SpanEvent) // idx -> *SpanEvent
+	linksMap := make(map[int]*otelpb.SpanLink)   // idx -> *SpanLink
+	for _, field := range fields {
+		var err error
+		switch field.Name {
+		case "_stream", "_stream_id", "_msg", "_time":
+			// no-op
+		case otelpb.TraceIDField:
+			sp.TraceID = field.Value
+		case otelpb.SpanIDField:
+			sp.SpanID = field.Value
+		case otelpb.TraceStateField:
+			sp.TraceState = field.Value
+		case otelpb.ParentSpanIDField:
+			sp.ParentSpanID = field.Value
+		case otelpb.FlagsField:
+			sp.Flags, err = parseUint32(field.Value)
+		case otelpb.NameField:
+			sp.Name = field.Value
+		case otelpb.KindField:
+			var kind int32
+			kind, err = parseInt32(field.Value)
+			sp.Kind = otelpb.SpanKind(kind)
+		case otelpb.StartTimeUnixNanoField:
+			sp.StartTimeUnixNano, err = strconv.ParseUint(field.Value, 10, 64)
+		case otelpb.EndTimeUnixNanoField:
+			sp.EndTimeUnixNano, err = strconv.ParseUint(field.Value, 10, 64)
+		case otelpb.DroppedAttributesCountField:
+			sp.DroppedAttributesCount, err = parseUint32(field.Value)
+		case otelpb.DroppedEventsCountField:
+			sp.DroppedEventsCount, err = parseUint32(field.Value)
+		case otelpb.DroppedLinksCountField:
+			sp.DroppedLinksCount, err = parseUint32(field.Value)
+		case otelpb.StatusMessageField:
+			sp.Status.Message = field.Value
+		case otelpb.StatusCodeField:
+			var code int32
+			code, err = parseInt32(field.Value)
+			sp.Status.Code = otelpb.StatusCode(code)
+		case otelpb.InstrumentationScopeName:
+			scope.Name = field.Value
+		case otelpb.InstrumentationScopeVersion:
+			scope.Version = field.Value
+		default:
+			switch {
+			case strings.HasPrefix(field.Name, otelpb.ResourceAttrPrefix):
+				resource.Attributes = append(resource.Attributes, newStringKeyValue(strings.TrimPrefix(field.Name, otelpb.ResourceAttrPrefix), field.Value))
+			case strings.HasPrefix(field.Name, otelpb.InstrumentationScopeAttrPrefix):
+				scope.Attributes = append(scope.Attributes, newStringKeyValue(strings.TrimPrefix(field.Name, otelpb.InstrumentationScopeAttrPrefix), field.Value))
+			case strings.HasPrefix(field.Name, otelpb.SpanAttrPrefixField):
+				sp.Attributes = append(sp.Attributes, newStringKeyValue(strings.TrimPrefix(field.Name, otelpb.SpanAttrPrefixField), field.Value))
+			case strings.HasPrefix(field.Name, otelpb.EventPrefix):
+				err = setEventField(eventsMap, strings.TrimPrefix(field.Name, otelpb.EventPrefix), field.Value)
+			case strings.HasPrefix(field.Name, otelpb.LinkPrefix):
+				err = setLinkField(linksMap, strings.TrimPrefix(field.Name, otelpb.LinkPrefix), field.Value)
+			}
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot parse field %q=%q: %w", field.Name, field.Value, err)
+		}
+	}
+
+	if sp.SpanID == "" || sp.TraceID == "" {
+		return nil, nil, nil, fmt.Errorf("invalid fields: %v", fields)
+	}
+
+	sortKeyValues(resource.Attributes)
+	sortKeyValues(scope.Attributes)
+	sortKeyValues(sp.Attributes)
+
+	// the events and links are exported in the order of their indexes. The indexes could be sparse if some fields are missing.
+	for _, idx := range sortedIndexes(eventsMap) {
+		ev := eventsMap[idx]
+		sortKeyValues(ev.Attributes)
+		sp.Events = append(sp.Events, ev)
+	}
+	for _, idx := range sortedIndexes(linksMap) {
+		l := linksMap[idx]
+		sortKeyValues(l.Attributes)
+		sp.Links = append(sp.Links, l)
+	}
+
+	return resource, scope, sp, nil
+}
+
+// sortedIndexes returns the sorted keys of m.
+func sortedIndexes[T any](m map[int]T) []int {
+	idxs := make([]int, 0, len(m))
+	for idx := range m {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	return idxs
+}
+
+func setEventField(eventsMap map[int]*otelpb.SpanEvent, name, value string) error {
+	fieldName, idx, err := splitFieldNameAndIndex(name)
+	if err != nil {
+		return err
+	}
+	ev, ok := eventsMap[idx]
+	if !ok {
+		ev = &otelpb.SpanEvent{}
+		eventsMap[idx] = ev
+	}
+	switch fieldName {
+	case otelpb.EventTimeUnixNanoField:
+		ev.TimeUnixNano, err = strconv.ParseUint(value, 10, 64)
+	case otelpb.EventNameField:
+		ev.Name = value
+	case otelpb.EventDroppedAttributesCountField:
+		ev.DroppedAttributesCount, err = parseUint32(value)
+	default:
+		ev.Attributes = append(ev.Attributes, newStringKeyValue(strings.TrimPrefix(fieldName, otelpb.EventAttrPrefix), value))
+	}
+	return err
+}
+
+func setLinkField(linksMap map[int]*otelpb.SpanLink, name, value string) error {
+	fieldName, idx, err := splitFieldNameAndIndex(name)
+	if err != nil {
+		return err
+	}
+	l, ok := linksMap[idx]
+	if !ok {
+		l = &otelpb.SpanLink{}
+		linksMap[idx] = l
+	}
+	switch fieldName {
+	case otelpb.LinkTraceIDField:
+		l.TraceID = value
+	case otelpb.LinkSpanIDField:
+		l.SpanID = value
+	case otelpb.LinkTraceStateField:
+		l.TraceState = value
+	case otelpb.LinkDroppedAttributesCountField:
+		l.DroppedAttributesCount, err = parseUint32(value)
+	case otelpb.LinkFlagsField:
+		l.Flags, err = parseUint32(value)
+	default:
+		l.Attributes = append(l.Attributes, newStringKeyValue(strings.TrimPrefix(fieldName, otelpb.LinkAttrPrefix), value))
+	}
+	return err
+}
+
+// splitFieldNameAndIndex splits the event or link field name in `<name>:<idx>` format.
+func splitFieldNameAndIndex(s string) (string, int, error) {
+	n := strings.LastIndexByte(s, ':')
+	if n < 0 {
+		return "", 0, fmt.Errorf("missing index")
+	}
+	idx, err := strconv.Atoi(s[n+1:])
+	if err != nil || idx < 0 {
+		return "", 0, fmt.Errorf("invalid index %q", s[n+1:])
+	}
+	return s[:n], idx, nil
+}
+
+func newStringKeyValue(key, value string) *otelpb.KeyValue {
+	return &otelpb.KeyValue{
+		Key: key,
+		Value: &otelpb.AnyValue{
+			StringValue: &value,
+		},
+	}
+}
+
+func sortKeyValues(kvs []*otelpb.KeyValue) {
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+}
+
+// attributesKey returns a unique key for the sorted attributes, which is used for grouping.
+func attributesKey(kvs []*otelpb.KeyValue) string {
+	var sb strings.Builder
+	for _, kv := range kvs {
+		sb.WriteString(strconv.Quote(kv.Key))
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(*kv.Value.StringValue))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+func parseUint32(s string) (uint32, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	return uint32(n), err
+}
+
+func parseInt32(s string) (int32, error) {
+	n, err := strconv.ParseInt(s, 10, 32)
+	return int32(n), err
+}
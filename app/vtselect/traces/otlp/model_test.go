@@ -0,0 +1,219 @@
+package otlp
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestRowsToExportTraceServiceRequest(t *testing.T) {
+	f := func(rows []*query.Row, want *otelpb.ExportTraceServiceRequest, errorMsg string) {
+		t.Helper()
+
+		var errMsgGot string
+		got, err := rowsToExportTraceServiceRequest(rows)
+		if err != nil {
+			errMsgGot = err.Error()
+		}
+		if errMsgGot != errorMsg {
+			t.Fatalf("rowsToExportTraceServiceRequest() error = %v, want err: %v", err, errorMsg)
+		}
+		if !cmp.Equal(got, want) {
+			t.Fatalf("rowsToExportTraceServiceRequest() diff = %v", cmp.Diff(got, want))
+		}
+	}
+
+	newRow := func(fields ...logstorage.Field) *query.Row {
+		return &query.Row{Fields: fields}
+	}
+	sv := func(s string) *otelpb.AnyValue {
+		return &otelpb.AnyValue{StringValue: &s}
+	}
+
+	// case 1: empty
+	f(nil, &otelpb.ExportTraceServiceRequest{ResourceSpans: []*otelpb.ResourceSpans{}}, "")
+
+	// case 2: without trace_id
+	f([]*query.Row{
+		newRow(logstorage.Field{Name: otelpb.SpanIDField, Value: "12345"}),
+	}, nil, "invalid fields: [{span_id 12345}]")
+
+	// case 3: invalid numeric field
+	f([]*query.Row{
+		newRow(logstorage.Field{Name: otelpb.KindField, Value: "foo"}),
+	}, nil, `cannot parse field "kind"="foo": strconv.ParseInt: parsing "foo": invalid syntax`)
+
+	// case 4: spans from the same resource and different instrumentation scopes
+	f([]*query.Row{
+		newRow(
+			logstorage.Field{Name: "_time", Value: "2025-01-01T00:00:00Z"},
+			logstorage.Field{Name: otelpb.ResourceAttrServiceName, Value: "service_1"},
+			logstorage.Field{Name: otelpb.InstrumentationScopeName, Value: "scope_2"},
+			logstorage.Field{Name: otelpb.SpanIDField, Value: "span_2"},
+			logstorage.Field{Name: otelpb.ParentSpanIDField, Value: "span_1"},
+			logstorage.Field{Name: otelpb.StartTimeUnixNanoField, Value: "2"},
+			logstorage.Field{Name: otelpb.TraceIDField, Value: "trace_1"},
+		),
+		newRow(
+			logstorage.Field{Name: otelpb.ResourceAttrServiceName, Value: "service_1"},
+			logstorage.Field{Name: otelpb.ResourceAttrPrefix + "host.name", Value: "host_1"},
+			logstorage.Field{Name: otelpb.InstrumentationScopeName, Value: "scope_1"},
+			logstorage.Field{Name: otelpb.InstrumentationScopeVersion, Value: "v1"},
+			logstorage.Field{Name: otelpb.InstrumentationScopeAttrPrefix + "scope_attr_1", Value: "value_1"},
+			logstorage.Field{Name: otelpb.SpanIDField, Value: "span_1"},
+			logstorage.Field{Name: otelpb.TraceStateField, Value: "state_1"},
+			logstorage.Field{Name: otelpb.FlagsField, Value: "1"},
+			logstorage.Field{Name: otelpb.NameField, Value: "span_name_1"},
+			logstorage.Field{Name: otelpb.KindField, Value: "2"},
+			logstorage.Field{Name: otelpb.StartTimeUnixNanoField, Value: "1"},
+			logstorage.Field{Name: otelpb.EndTimeUnixNanoField, Value: "10"},
+			logstorage.Field{Name: otelpb.DurationField, Value: "9"},
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "span_attr_2", Value: "value_2"},
+			logstorage.Field{Name: otelpb.SpanAttrPrefixField + "span_attr_1", Value: "value_1"},
+			logstorage.Field{Name: otelpb.DroppedAttributesCountField, Value: "1"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":1", Value: "6"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventNameField + ":1", Value: "event_2"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":0", Value: "5"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventNameField + ":0", Value: "event_1"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventAttrPrefix + "event_attr_1:0", Value: "value_1"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventDroppedAttributesCountField + ":0", Value: "2"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":0", Value: "trace_2"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":0", Value: "span_3"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkFlagsField + ":0", Value: "1"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkAttrPrefix + "link_attr_1:0", Value: "value_1"},
+			logstorage.Field{Name: otelpb.StatusMessageField, Value: "status_message_1"},
+			logstorage.Field{Name: otelpb.StatusCodeField, Value: "2"},
+			logstorage.Field{Name: otelpb.TraceIDField, Value: "trace_1"},
+		),
+		newRow(
+			logstorage.Field{Name: otelpb.ResourceAttrServiceName, Value: "service_1"},
+			logstorage.Field{Name: otelpb.InstrumentationScopeName, Value: "scope_2"},
+			logstorage.Field{Name: otelpb.SpanIDField, Value: "span_4"},
+			logstorage.Field{Name: otelpb.StartTimeUnixNanoField, Value: "1"},
+			logstorage.Field{Name: otelpb.TraceIDField, Value: "trace_1"},
+		),
+	}, &otelpb.ExportTraceServiceRequest{
+		ResourceSpans: []*otelpb.ResourceSpans{
+			{
+				Resource: otelpb.Resource{
+					Attributes: []*otelpb.KeyValue{
+						{Key: "service.name", Value: sv("service_1")},
+					},
+				},
+				ScopeSpans: []*otelpb.ScopeSpans{
+					{
+						Scope: otelpb.InstrumentationScope{Name: "scope_2"},
+						Spans: []*otelpb.Span{
+							{TraceID: "trace_1", SpanID: "span_4", StartTimeUnixNano: 1},
+							{TraceID: "trace_1", SpanID: "span_2", ParentSpanID: "span_1", StartTimeUnixNano: 2},
+						},
+					},
+				},
+			},
+			{
+				Resource: otelpb.Resource{
+					Attributes: []*otelpb.KeyValue{
+						{Key: "host.name", Value: sv("host_1")},
+						{Key: "service.name", Value: sv("service_1")},
+					},
+				},
+				ScopeSpans: []*otelpb.ScopeSpans{
+					{
+						Scope: otelpb.InstrumentationScope{
+							Name:    "scope_1",
+							Version: "v1",
+							Attributes: []*otelpb.KeyValue{
+								{Key: "scope_attr_1", Value: sv("value_1")},
+							},
+						},
+						Spans: []*otelpb.Span{
+							{
+								TraceID:           "trace_1",
+								SpanID:            "span_1",
+								TraceState:        "state_1",
+								Flags:             1,
+								Name:              "span_name_1",
+								Kind:              2,
+								StartTimeUnixNano: 1,
+								EndTimeUnixNano:   10,
+								Attributes: []*otelpb.KeyValue{
+									{Key: "span_attr_1", Value: sv("value_1")},
+									{Key: "span_attr_2", Value: sv("value_2")},
+								},
+								DroppedAttributesCount: 1,
+								Events: []*otelpb.SpanEvent{
+									{
+										TimeUnixNano: 5,
+										Name:         "event_1",
+										Attributes: []*otelpb.KeyValue{
+											{Key: "event_attr_1", Value: sv("value_1")},
+										},
+										DroppedAttributesCount: 2,
+									},
+									{TimeUnixNano: 6, Name: "event_2"},
+								},
+								Links: []*otelpb.SpanLink{
+									{
+										TraceID: "trace_2",
+										SpanID:  "span_3",
+										Attributes: []*otelpb.KeyValue{
+											{Key: "link_attr_1", Value: sv("value_1")},
+										},
+										Flags: 1,
+									},
+								},
+								Status: otelpb.Status{Message: "status_message_1", Code: 2},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, "")
+
+	// case 5: events and links with non-contiguous indexes are exported in the order of their indexes
+	f([]*query.Row{
+		newRow(
+			logstorage.Field{Name: otelpb.ResourceAttrServiceName, Value: "service_1"},
+			logstorage.Field{Name: otelpb.SpanIDField, Value: "span_1"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventNameField + ":10", Value: "event_10"},
+			logstorage.Field{Name: otelpb.EventPrefix + otelpb.EventNameField + ":2", Value: "event_2"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":3", Value: "trace_3"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":3", Value: "span_3"},
+			logstorage.Field{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":1", Value: "trace_2"},
+			logstorage.Field{Name: otelpb.TraceIDField, Value: "trace_1"},
+		),
+	}, &otelpb.ExportTraceServiceRequest{
+		ResourceSpans: []*otelpb.ResourceSpans{
+			{
+				Resource: otelpb.Resource{
+					Attributes: []*otelpb.KeyValue{
+						{Key: "service.name", Value: sv("service_1")},
+					},
+				},
+				ScopeSpans: []*otelpb.ScopeSpans{
+					{
+						Spans: []*otelpb.Span{
+							{
+								TraceID: "trace_1",
+								SpanID:  "span_1",
+								Events: []*otelpb.SpanEvent{
+									{Name: "event_2"},
+									{Name: "event_10"},
+								},
+								Links: []*otelpb.SpanLink{
+									{TraceID: "trace_2"},
+									{TraceID: "trace_3", SpanID: "span_3"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, "")
+}
@@ -0,0 +1,136 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	maxTraceIDsPerRequest = 1000
+)
+
+// OTLP export APIs metrics
+var (
+	otlpTracesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/otlp/v1/traces"}`)
+	otlpTracesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/otlp/v1/traces"}`)
+
+	otlpTraceRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/otlp/v1/traces/*"}`)
+	otlpTraceDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/otlp/v1/traces/*"}`)
+)
+
+// RequestHandler is the entry point for all OTLP export APIs.
+//
+// These APIs return the stored spans as OTLP ExportTraceServiceRequest, which can be replayed to any OTLP compatible receiver.
+func RequestHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	httpserver.EnableCORS(w, r)
+	startTime := time.Now()
+	path := r.URL.Path
+	switch {
+	case path == "/select/otlp/v1/traces":
+		otlpTracesRequests.Inc()
+		processGetTracesRequest(ctx, w, r)
+		otlpTracesDuration.UpdateDuration(startTime)
+		return true
+	case strings.HasPrefix(path, "/select/otlp/v1/traces/") && len(path) > len("/select/otlp/v1/traces/"):
+		otlpTraceRequests.Inc()
+		processGetTraceRequest(ctx, w, r)
+		otlpTraceDuration.UpdateDuration(startTime)
+		return true
+	}
+	return false
+}
+
+// processGetTraceRequest handle the /select/otlp/v1/traces/<trace_id> API request.
+func processGetTraceRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	// extract the `trace_id`.
+	// the path must be like `/select/otlp/v1/traces/<trace_id>`.
+	traceID := r.URL.Path[len("/select/otlp/v1/traces/"):]
+
+	rows, err := query.GetTrace(ctx, cp, traceID)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get trace: %s", err)
+		return
+	}
+
+	req, err := rowsToExportTraceServiceRequest(rows)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot convert spans of trace %q to OTLP: %s", traceID, err)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if len(rows) == 0 {
+		statusCode = http.StatusNotFound
+	}
+	writeExportTraceServiceRequest(w, r, statusCode, req)
+}
+
+// processGetTracesRequest handle the /select/otlp/v1/traces?trace_id=<trace_id>&trace_id=<trace_id> API request.
+func processGetTracesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	traceIDs := r.URL.Query()["trace_id"]
+	if len(traceIDs) == 0 {
+		httpserver.Errorf(w, r, "missing `trace_id` query arg")
+		return
+	}
+	if len(traceIDs) > maxTraceIDsPerRequest {
+		httpserver.Errorf(w, r, "too many `trace_id` query args: %d; it should be not higher than %d", len(traceIDs), maxTraceIDsPerRequest)
+		return
+	}
+
+	_, rows, err := query.GetTracesByIDs(ctx, cp, traceIDs)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get traces: %s", err)
+		return
+	}
+
+	req, err := rowsToExportTraceServiceRequest(rows)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot convert spans to OTLP: %s", err)
+		return
+	}
+	writeExportTraceServiceRequest(w, r, http.StatusOK, req)
+}
+
+// writeExportTraceServiceRequest writes req to w in JSON format if it's accepted by the client. Otherwise, in protobuf format.
+func writeExportTraceServiceRequest(w http.ResponseWriter, r *http.Request, statusCode int, req *otelpb.ExportTraceServiceRequest) {
+	if strings.Contains(r.Header.Get("Accept"), contentTypeJSON) {
+		data, err := json.Marshal(req)
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot marshal OTLP request to JSON: %s", err)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.WriteHeader(statusCode)
+		_, _ = w.Write(data)
+		return
+	}
+
+	data := req.MarshalProtobuf(nil)
+	w.Header().Set("Content-Type", contentTypeProtobuf)
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// TraceCursor is the position in the trace search result, after which the next page of traces starts.
+//
+// Traces in the search result are sorted by the time of their last matching span and by trace_id in descending order,
+// so the position is the (_time, trace_id) of the last trace on the previous page.
+type TraceCursor struct {
+	// Timestamp is the time of the last matching span of the trace in nanoseconds.
+	Timestamp int64
+	TraceID   string
+}
+
+// String returns the opaque token for c, which could be parsed with ParseTraceCursor.
+func (c *TraceCursor) String() string {
+	s := strconv.FormatInt(c.Timestamp, 10) + ":" + c.TraceID
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// ParseTraceCursor parses the token returned by TraceCursor.String.
+func ParseTraceCursor(s string) (*TraceCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode cursor: %w", err)
+	}
+	timestampStr, traceID, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return nil, fmt.Errorf("missing trace_id in cursor")
+	}
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse timestamp in cursor: %w", err)
+	}
+	if traceID == "" || !traceIDRegex.MatchString(traceID) {
+		return nil, fmt.Errorf("invalid trace_id in cursor: %q", traceID)
+	}
+	return &TraceCursor{
+		Timestamp: timestamp,
+		TraceID:   traceID,
+	}, nil
+}
+
+// filter returns LogsQL filter, which matches the (_time, trace_id) pairs after c in the search result order.
+func (c *TraceCursor) filter() string {
+	t := time.Unix(0, c.Timestamp).UTC().Format(time.RFC3339Nano)
+	return fmt.Sprintf(`(_time:<%s OR (_time:[%s, %s] AND %s:string_range("", %q)))`, t, t, t, otelpb.TraceIDField, c.TraceID)
+}
+
+// less returns true if c goes after (timestamp, traceID) in the search result order.
+func (c *TraceCursor) less(timestamp int64, traceID string) bool {
+	if c.Timestamp != timestamp {
+		return c.Timestamp < timestamp
+	}
+	return c.TraceID < traceID
+}
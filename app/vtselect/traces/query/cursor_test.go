@@ -0,0 +1,53 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTraceCursor(t *testing.T) {
+	f := func(c *TraceCursor) {
+		t.Helper()
+
+		result, err := ParseTraceCursor(c.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, c) {
+			t.Fatalf("unexpected cursor; got %+v; want %+v", result, c)
+		}
+	}
+	f(&TraceCursor{Timestamp: 1757320938628000000, TraceID: "7c560adaadc5d505d3e0abd427101aaf"})
+	f(&TraceCursor{Timestamp: 0, TraceID: "a:b"})
+}
+
+func TestParseTraceCursorFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+
+		if _, err := ParseTraceCursor(s); err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+
+	// invalid base64
+	f("!!!")
+
+	// missing trace_id
+	f((&TraceCursor{Timestamp: 1}).String())
+
+	// invalid timestamp
+	f("YWJjOmRlZg") // abc:def
+
+	// invalid trace_id
+	f((&TraceCursor{Timestamp: 1, TraceID: `a"b`}).String())
+}
+
+func TestTraceCursorFilter(t *testing.T) {
+	c := &TraceCursor{Timestamp: 1757320938628000000, TraceID: "7c560adaadc5d505d3e0abd427101aaf"}
+	result := c.filter()
+	resultExpected := `(_time:<2025-09-08T08:42:18.628Z OR (_time:[2025-09-08T08:42:18.628Z, 2025-09-08T08:42:18.628Z] AND trace_id:string_range("", "7c560adaadc5d505d3e0abd427101aaf")))`
+	if result != resultExpected {
+		t.Fatalf("unexpected filter;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
@@ -0,0 +1,290 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/valyala/fastjson"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	vtstoragecommon "github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage/common"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// maxExceptionExampleTraceIDs is the maximum number of example trace IDs returned for every exception group.
+const maxExceptionExampleTraceIDs = 5
+
+// exceptionStacktracePrefix is the prefix of the fields with `exception.stacktrace` event attribute.
+const exceptionStacktracePrefix = otelpb.EventPrefix + otelpb.EventAttrPrefix + otelpb.EventAttrExceptionStacktrace + ":"
+
+// ExceptionQueryParam is the parameters for aggregating exceptions recorded as span events.
+type ExceptionQueryParam struct {
+	// ServiceName is the optional service name. Exceptions of all the services are aggregated if empty.
+	ServiceName string
+	StartTime   time.Time
+	EndTime     time.Time
+	Step        time.Duration
+	// Limit is the maximum number of returned exception groups.
+	Limit int
+}
+
+// ExceptionGroup contains the stats of spans with the same exceptions in a service.
+type ExceptionGroup struct {
+	ServiceName string
+	// ExceptionType contains the exception types of the span. See otelpb.ExceptionTypesField.
+	ExceptionType string
+	// Message contains the exception messages of the span, where numbers, UUIDs, IPs and timestamps are replaced with placeholders,
+	// so the messages which differ only in these values are grouped together.
+	Message string
+	// Count is the number of spans with the exception.
+	Count           uint64
+	FirstSeen       time.Time
+	LastSeen        time.Time
+	ExampleTraceIDs []string
+	// Stacktrace is the `exception.stacktrace` event attribute of the latest span with the exception.
+	Stacktrace string
+	// Points contain the number of spans with the exception for every step.
+	Points []ExceptionPoint
+}
+
+// ExceptionPoint is a point of ExceptionGroup.Points.
+type ExceptionPoint struct {
+	// Timestamp is the start of the step.
+	Timestamp time.Time
+	Count     uint64
+}
+
+type exceptionGroupKey struct {
+	serviceName   string
+	exceptionType string
+	message       string
+}
+
+// exceptionGroupBucket contains the stats of exception group for a step.
+type exceptionGroupBucket struct {
+	count      uint64
+	firstSeen  int64
+	lastSeen   int64
+	traceIDs   []string
+	stacktrace string
+}
+
+// Validate verifies that the params could be used for aggregating exceptions.
+func (p *ExceptionQueryParam) Validate() error {
+	if p.Step <= 0 {
+		return fmt.Errorf("step must be bigger than zero")
+	}
+	if !p.EndTime.After(p.StartTime) {
+		return fmt.Errorf("end must be bigger than start")
+	}
+	if p.EndTime.Sub(p.StartTime)/p.Step > maxSpanMetricsPoints {
+		return fmt.Errorf("too many points requested with start=%s, end=%s and step=%s; the maximum number of points is %d",
+			p.StartTime.Format(time.RFC3339), p.EndTime.Format(time.RFC3339), p.Step, maxSpanMetricsPoints)
+	}
+	if p.Limit <= 0 {
+		return fmt.Errorf("limit must be bigger than zero")
+	}
+	return nil
+}
+
+// GetExceptionGroups returns up to param.Limit groups of spans with exceptions, which have the biggest number of spans.
+//
+// The spans are grouped by service name, exception types and normalized exception messages on the storage side:
+//
+//	exception_types:* | collapse_nums at exception_messages prettify
+//	  | stats by (_time:step, resource_attr:service.name, exception_types, exception_messages) count(), min(_time), max(_time),
+//	    uniq_values(trace_id) limit 5, row_max(end_time_unix_nano, event:event_attr:exception.stacktrace:*)
+func GetExceptionGroups(ctx context.Context, cp *CommonParams, param *ExceptionQueryParam) ([]*ExceptionGroup, error) {
+	qStr := otelpb.ExceptionTypesField + ":* "
+	if param.ServiceName != "" {
+		qStr += fmt.Sprintf("AND _stream:{%q=%q} ", otelpb.ResourceAttrServiceName, param.ServiceName)
+	}
+	qStr += fmt.Sprintf("| collapse_nums at %s prettify ", otelpb.ExceptionMessagesField)
+	qStr += fmt.Sprintf("| stats by (%q, %s, %s) count() as vt_count, min(_time) as vt_first_seen, max(_time) as vt_last_seen, "+
+		"uniq_values(%s) limit %d as vt_trace_ids, row_max(%s, %q) as vt_example",
+		otelpb.ResourceAttrServiceName, otelpb.ExceptionTypesField, otelpb.ExceptionMessagesField,
+		otelpb.TraceIDField, maxExceptionExampleTraceIDs, otelpb.EndTimeUnixNanoField, exceptionStacktracePrefix+"*")
+
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, param.EndTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(param.StartTime.UnixNano(), param.EndTime.UnixNano())
+
+	step := param.Step.Nanoseconds()
+	if _, err := q.GetStatsLabelsAddGroupingByTime(step); err != nil {
+		return nil, fmt.Errorf("cannot add grouping by time to query [%s]: %s", q, err)
+	}
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var bucketsLock sync.Mutex
+	buckets := make(map[exceptionGroupKey]map[int64]*exceptionGroupBucket)
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		for i := 0; i < db.RowsCount(); i++ {
+			var k exceptionGroupKey
+			var bucketStart int64
+			b := &exceptionGroupBucket{}
+			for _, c := range db.Columns {
+				v := c.Values[i]
+				switch c.Name {
+				case "_time":
+					bucketStart, _ = logstorage.TryParseTimestampRFC3339Nano(v)
+				case otelpb.ResourceAttrServiceName:
+					k.serviceName = strings.Clone(v)
+				case otelpb.ExceptionTypesField:
+					k.exceptionType = strings.Clone(v)
+				case otelpb.ExceptionMessagesField:
+					k.message = strings.Clone(v)
+				case "vt_count":
+					b.count, _ = strconv.ParseUint(v, 10, 64)
+				case "vt_first_seen":
+					b.firstSeen, _ = logstorage.TryParseTimestampRFC3339Nano(v)
+				case "vt_last_seen":
+					b.lastSeen, _ = logstorage.TryParseTimestampRFC3339Nano(v)
+				case "vt_trace_ids":
+					b.traceIDs = parseJSONStringArray(v)
+				case "vt_example":
+					b.stacktrace = getExceptionStacktrace(v)
+				}
+			}
+
+			bucketsLock.Lock()
+			groupBuckets, ok := buckets[k]
+			if !ok {
+				groupBuckets = make(map[int64]*exceptionGroupBucket)
+				buckets[k] = groupBuckets
+			}
+			groupBuckets[bucketStart] = b
+			bucketsLock.Unlock()
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+
+	groups := make([]*ExceptionGroup, 0, len(buckets))
+	for k, groupBuckets := range buckets {
+		groups = append(groups, newExceptionGroup(k, groupBuckets, param))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.After(b.LastSeen)
+		}
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		if a.ExceptionType != b.ExceptionType {
+			return a.ExceptionType < b.ExceptionType
+		}
+		return a.Message < b.Message
+	})
+	if len(groups) > param.Limit {
+		groups = groups[:param.Limit]
+	}
+	return groups, nil
+}
+
+// newExceptionGroup merges the step buckets of the exception group.
+func newExceptionGroup(k exceptionGroupKey, groupBuckets map[int64]*exceptionGroupBucket, param *ExceptionQueryParam) *ExceptionGroup {
+	eg := &ExceptionGroup{
+		ServiceName:   k.serviceName,
+		ExceptionType: k.exceptionType,
+		Message:       k.message,
+	}
+
+	var firstSeen, lastSeen, stacktraceSeen int64
+	for _, b := range groupBuckets {
+		eg.Count += b.count
+		if firstSeen == 0 || b.firstSeen < firstSeen {
+			firstSeen = b.firstSeen
+		}
+		lastSeen = max(lastSeen, b.lastSeen)
+		if b.stacktrace != "" && b.lastSeen >= stacktraceSeen {
+			eg.Stacktrace = b.stacktrace
+			stacktraceSeen = b.lastSeen
+		}
+		for _, traceID := range b.traceIDs {
+			if !slices.Contains(eg.ExampleTraceIDs, traceID) {
+				eg.ExampleTraceIDs = append(eg.ExampleTraceIDs, traceID)
+			}
+		}
+	}
+	eg.FirstSeen = time.Unix(0, firstSeen).UTC()
+	eg.LastSeen = time.Unix(0, lastSeen).UTC()
+	sort.Strings(eg.ExampleTraceIDs)
+	if len(eg.ExampleTraceIDs) > maxExceptionExampleTraceIDs {
+		eg.ExampleTraceIDs = eg.ExampleTraceIDs[:maxExceptionExampleTraceIDs]
+	}
+
+	step := param.Step.Nanoseconds()
+	start := param.StartTime.UnixNano()
+	for t := start - start%step; t < param.EndTime.UnixNano(); t += step {
+		var count uint64
+		if b, ok := groupBuckets[t]; ok {
+			count = b.count
+		}
+		eg.Points = append(eg.Points, ExceptionPoint{
+			Timestamp: time.Unix(0, t).UTC(),
+			Count:     count,
+		})
+	}
+	return eg
+}
+
+// parseJSONStringArray parses JSON array of strings returned by `uniq_values` stats function.
+func parseJSONStringArray(s string) []string {
+	var p fastjson.Parser
+	v, err := p.Parse(s)
+	if err != nil {
+		return nil
+	}
+	var result []string
+	for _, item := range v.GetArray() {
+		result = append(result, string(item.GetStringBytes()))
+	}
+	return result
+}
+
+// getExceptionStacktrace returns the stacktrace of the first exception event from JSON object returned by `row_max` stats function.
+func getExceptionStacktrace(s string) string {
+	var p fastjson.Parser
+	v, err := p.Parse(s)
+	if err != nil {
+		return ""
+	}
+	o, err := v.Object()
+	if err != nil {
+		return ""
+	}
+
+	minIdx := -1
+	stacktrace := ""
+	o.Visit(func(key []byte, v *fastjson.Value) {
+		idx, err := strconv.Atoi(strings.TrimPrefix(string(key), exceptionStacktracePrefix))
+		if err != nil || (minIdx >= 0 && idx >= minIdx) {
+			return
+		}
+		minIdx = idx
+		stacktrace = string(v.GetStringBytes())
+	})
+	return stacktrace
+}
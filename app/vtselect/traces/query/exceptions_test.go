@@ -0,0 +1,83 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetExceptionStacktrace(t *testing.T) {
+	f := func(s, resultExpected string) {
+		t.Helper()
+
+		result := getExceptionStacktrace(s)
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	f("", "")
+	f("{}", "")
+	f(`{"event:event_attr:exception.stacktrace:0":"at foo()\nat bar()"}`, "at foo()\nat bar()")
+
+	// the stacktrace of the first exception event is returned
+	f(`{"event:event_attr:exception.stacktrace:10":"at baz()","event:event_attr:exception.stacktrace:2":"at foo()"}`, "at foo()")
+	f(`{"event:event_attr:exception.stacktrace:foo:0":"at baz()"}`, "")
+}
+
+func TestNewExceptionGroup(t *testing.T) {
+	f := func(groupBuckets map[int64]*exceptionGroupBucket, resultExpected *ExceptionGroup) {
+		t.Helper()
+
+		param := &ExceptionQueryParam{
+			StartTime: time.Unix(90, 0),
+			EndTime:   time.Unix(300, 0),
+			Step:      time.Minute,
+		}
+		k := exceptionGroupKey{
+			serviceName:   "svc",
+			exceptionType: "TimeoutException",
+			message:       "timed out after <N>s",
+		}
+		result := newExceptionGroup(k, groupBuckets, param)
+		if !cmp.Equal(result, resultExpected) {
+			t.Fatalf("unexpected result; diff = %s", cmp.Diff(result, resultExpected))
+		}
+	}
+
+	sec := func(n int64) int64 {
+		return n * 1e9
+	}
+
+	f(map[int64]*exceptionGroupBucket{
+		sec(60): {
+			count:      2,
+			firstSeen:  sec(100),
+			lastSeen:   sec(110),
+			traceIDs:   []string{"t3", "t1"},
+			stacktrace: "at foo()",
+		},
+		sec(180): {
+			count:     1,
+			firstSeen: sec(200),
+			lastSeen:  sec(200),
+			traceIDs:  []string{"t1", "t2", "t4", "t5", "t6"},
+		},
+	}, &ExceptionGroup{
+		ServiceName:     "svc",
+		ExceptionType:   "TimeoutException",
+		Message:         "timed out after <N>s",
+		Count:           3,
+		FirstSeen:       time.Unix(100, 0).UTC(),
+		LastSeen:        time.Unix(200, 0).UTC(),
+		ExampleTraceIDs: []string{"t1", "t2", "t3", "t4", "t5"},
+		Stacktrace:      "at foo()",
+		Points: []ExceptionPoint{
+			{Timestamp: time.Unix(60, 0).UTC(), Count: 2},
+			{Timestamp: time.Unix(120, 0).UTC(), Count: 0},
+			{Timestamp: time.Unix(180, 0).UTC(), Count: 1},
+			{Timestamp: time.Unix(240, 0).UTC(), Count: 0},
+		},
+	})
+}
@@ -0,0 +1,362 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AttributeFilter is the parsed attribute filter expression, which can be used in TraceQueryParam.
+//
+// The expression supports the following syntax:
+//   - `key=value` - the attribute equals to the value.
+//   - `key!=value` - the attribute doesn't equal to the value, or it doesn't exist.
+//   - `key=~"regexp"` - the attribute matches the regexp.
+//   - `key!~"regexp"` - the attribute doesn't match the regexp, or it doesn't exist.
+//   - `key^=prefix` - the attribute starts with the prefix.
+//   - `key>number`, `key>=number`, `key<number`, `key<=number` - numeric comparisons.
+//   - `key` - the attribute exists.
+//   - `NOT filter` or `!filter` - negation.
+//   - `filter1 AND filter2` or `filter1 filter2` - both filters match.
+//   - `filter1 OR filter2` - any of the filters match.
+//   - `(filter)` - grouping.
+//
+// Keys and values can be double-quoted if they contain whitespaces or special chars.
+type AttributeFilter struct {
+	root filterNode
+}
+
+// String returns LogsQL representation of af.
+func (af *AttributeFilter) String() string {
+	return string(af.root.appendLogsQL(nil))
+}
+
+// AttributeFieldFunc converts the attribute key and value in filter expression to the field name and value in storage.
+type AttributeFieldFunc func(key, value string) (string, string)
+
+// ParseAttributeFilter parses the attribute filter expression s.
+//
+// The keys and values in s are converted with fieldFunc, so different APIs could apply their own field name conventions.
+func ParseAttributeFilter(s string, fieldFunc AttributeFieldFunc) (*AttributeFilter, error) {
+	tokens, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("filter cannot be empty")
+	}
+	p := &filterParser{
+		tokens:    tokens,
+		fieldFunc: fieldFunc,
+	}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, fmt.Errorf("unexpected token %s", p.tokens[p.pos])
+	}
+	return &AttributeFilter{
+		root: root,
+	}, nil
+}
+
+// maxFilterDepth limits the nesting of the filter expression.
+const maxFilterDepth = 32
+
+type filterNode interface {
+	appendLogsQL(dst []byte) []byte
+}
+
+type filterAnd struct {
+	children []filterNode
+}
+
+func (fa *filterAnd) appendLogsQL(dst []byte) []byte {
+	dst = append(dst, '(')
+	for i, c := range fa.children {
+		if i > 0 {
+			dst = append(dst, " AND "...)
+		}
+		dst = c.appendLogsQL(dst)
+	}
+	return append(dst, ')')
+}
+
+type filterOr struct {
+	children []filterNode
+}
+
+func (fo *filterOr) appendLogsQL(dst []byte) []byte {
+	dst = append(dst, '(')
+	for i, c := range fo.children {
+		if i > 0 {
+			dst = append(dst, " OR "...)
+		}
+		dst = c.appendLogsQL(dst)
+	}
+	return append(dst, ')')
+}
+
+type filterNot struct {
+	child filterNode
+}
+
+func (fn *filterNot) appendLogsQL(dst []byte) []byte {
+	dst = append(dst, '!')
+	return fn.child.appendLogsQL(dst)
+}
+
+type filterCmp struct {
+	field string
+	op    string
+	value string
+}
+
+func (fc *filterCmp) appendLogsQL(dst []byte) []byte {
+	// both field name and value are always quoted, so they cannot break the query.
+	switch fc.op {
+	case "":
+		return fmt.Appendf(dst, "%q:*", fc.field)
+	case "=":
+		return fmt.Appendf(dst, "%q:=%q", fc.field, fc.value)
+	case "!=":
+		return fmt.Appendf(dst, "!%q:=%q", fc.field, fc.value)
+	case "=~":
+		return fmt.Appendf(dst, "%q:~%q", fc.field, fc.value)
+	case "!~":
+		return fmt.Appendf(dst, "!%q:~%q", fc.field, fc.value)
+	case "^=":
+		return fmt.Appendf(dst, "%q:=%q*", fc.field, fc.value)
+	default:
+		// numeric comparisons. the value is verified to be a number during parsing.
+		return fmt.Appendf(dst, "%q:%s%s", fc.field, fc.op, fc.value)
+	}
+}
+
+type filterParser struct {
+	tokens    []filterToken
+	pos       int
+	fieldFunc AttributeFieldFunc
+}
+
+func (p *filterParser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.eof() {
+		return filterToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) parseOr(depth int) (filterNode, error) {
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("filter nesting exceeds %d levels", maxFilterDepth)
+	}
+	node, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	children := []filterNode{node}
+	for p.peek().isKeyword("or") {
+		p.pos++
+		node, err = p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &filterOr{children: children}, nil
+}
+
+func (p *filterParser) parseAnd(depth int) (filterNode, error) {
+	node, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	children := []filterNode{node}
+	for {
+		t := p.peek()
+		if p.eof() || t.isPunct(")") || t.isKeyword("or") {
+			break
+		}
+		if t.isKeyword("and") {
+			p.pos++
+		}
+		node, err = p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &filterAnd{children: children}, nil
+}
+
+func (p *filterParser) parseUnary(depth int) (filterNode, error) {
+	if p.eof() {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	t := p.tokens[p.pos]
+	switch {
+	case t.isKeyword("not") || t.isPunct("!"):
+		if depth+1 > maxFilterDepth {
+			return nil, fmt.Errorf("filter nesting exceeds %d levels", maxFilterDepth)
+		}
+		p.pos++
+		child, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{child: child}, nil
+	case t.isPunct("("):
+		p.pos++
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek().isPunct(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case t.kind == tokenWord && !t.isKeyword("and") && !t.isKeyword("or"):
+		p.pos++
+		return p.parseComparison(t.value)
+	default:
+		return nil, fmt.Errorf("unexpected token %s", t)
+	}
+}
+
+func (p *filterParser) parseComparison(key string) (filterNode, error) {
+	op := p.peek()
+	if op.kind != tokenOperator {
+		// existence check
+		field, _ := p.fieldFunc(key, "")
+		return &filterCmp{field: field}, nil
+	}
+	p.pos++
+
+	if p.eof() || p.tokens[p.pos].kind != tokenWord {
+		return nil, fmt.Errorf("missing value after %q%s", key, op.value)
+	}
+	value := p.tokens[p.pos].value
+	p.pos++
+
+	field, value := p.fieldFunc(key, value)
+	switch op.value {
+	case "=~", "!~":
+		if _, err := regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid regexp %q for %q: %w", value, key, err)
+		}
+	case ">", ">=", "<", "<=":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid number %q for %q", value, key)
+		}
+		value = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return &filterCmp{
+		field: field,
+		op:    op.value,
+		value: value,
+	}, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenWord filterTokenKind = iota + 1
+	tokenOperator
+	tokenPunct
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	// quoted is set if the word is double-quoted. Quoted words are never treated as keywords.
+	quoted bool
+	value  string
+}
+
+func (t filterToken) String() string {
+	return strconv.Quote(t.value)
+}
+
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+func (t filterToken) isPunct(punct string) bool {
+	return t.kind == tokenPunct && t.value == punct
+}
+
+// filterOperators contains all the comparison operators. The longer operators must go first.
+var filterOperators = []string{"!=", "!~", "=~", "^=", ">=", "<=", "=", ">", "<"}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var tokens []filterToken
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			return tokens, nil
+		}
+
+		switch s[0] {
+		case '(', ')':
+			tokens = append(tokens, filterToken{kind: tokenPunct, value: s[:1]})
+			s = s[1:]
+			continue
+		case '"':
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string at %q: %w", s, err)
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("cannot unquote %s: %w", quoted, err)
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, quoted: true, value: value})
+			s = s[len(quoted):]
+			continue
+		}
+
+		if op := operatorPrefix(s); op != "" {
+			tokens = append(tokens, filterToken{kind: tokenOperator, value: op})
+			s = s[len(op):]
+			continue
+		}
+		if s[0] == '!' {
+			tokens = append(tokens, filterToken{kind: tokenPunct, value: "!"})
+			s = s[1:]
+			continue
+		}
+
+		n := strings.IndexAny(s, " \t\r\n()\"=!~^<>")
+		if n < 0 {
+			n = len(s)
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("unexpected char %q", s[0])
+		}
+		tokens = append(tokens, filterToken{kind: tokenWord, value: s[:n]})
+		s = s[n:]
+	}
+}
+
+func operatorPrefix(s string) string {
+	for _, op := range filterOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+)
+
+func TestParseAttributeFilterSuccess(t *testing.T) {
+	fieldFunc := func(key, value string) (string, string) {
+		if key == "error" {
+			return "status_code", map[string]string{"true": "2", "false": "1"}[value]
+		}
+		return "span_attr:" + key, value
+	}
+
+	f := func(s, resultExpected string) {
+		t.Helper()
+
+		af, err := ParseAttributeFilter(s, fieldFunc)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result := af.String()
+		if result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+
+		// the result must be a valid LogsQL filter.
+		if _, err := logstorage.ParseFilter(result); err != nil {
+			t.Fatalf("cannot parse LogsQL filter %q: %s", result, err)
+		}
+	}
+
+	// comparisons
+	f(`http.method=GET`, `"span_attr:http.method":="GET"`)
+	f(`http.method!=GET`, `!"span_attr:http.method":="GET"`)
+	f(`http.url=~"^/api/v[12]/"`, `"span_attr:http.url":~"^/api/v[12]/"`)
+	f(`http.url!~"^/api"`, `!"span_attr:http.url":~"^/api"`)
+	f(`http.url^=/api/`, `"span_attr:http.url":="/api/"*`)
+	f(`http.status_code>=500`, `"span_attr:http.status_code":>=500`)
+	f(`http.status_code>499.5`, `"span_attr:http.status_code":>499.5`)
+	f(`http.status_code<-1`, `"span_attr:http.status_code":<-1`)
+	f(`http.status_code<=1e3`, `"span_attr:http.status_code":<=1000`)
+	f(`http.route`, `"span_attr:http.route":*`)
+	f(`error=true`, `"status_code":="2"`)
+
+	// quoted keys and values
+	f(`"my key"="my \"value\""`, `"span_attr:my key":="my \"value\""`)
+	f(`http.method="OR"`, `"span_attr:http.method":="OR"`)
+
+	// logical operators
+	f(`a=1 b=2`, `("span_attr:a":="1" AND "span_attr:b":="2")`)
+	f(`a=1 AND b=2 and c`, `("span_attr:a":="1" AND "span_attr:b":="2" AND "span_attr:c":*)`)
+	f(`a=1 OR b=2 or c`, `("span_attr:a":="1" OR "span_attr:b":="2" OR "span_attr:c":*)`)
+	f(`a=1 b=2 OR c=3`, `(("span_attr:a":="1" AND "span_attr:b":="2") OR "span_attr:c":="3")`)
+	f(`a=1 (b=2 OR c=3)`, `("span_attr:a":="1" AND ("span_attr:b":="2" OR "span_attr:c":="3"))`)
+	f(`NOT a`, `!"span_attr:a":*`)
+	f(`!a=1`, `!"span_attr:a":="1"`)
+	f(`not (a=1 or b>2)`, `!("span_attr:a":="1" OR "span_attr:b":>2)`)
+	f(`((a))`, `"span_attr:a":*`)
+
+	// injection attempts are quoted
+	f(`a="x\" OR *"`, `"span_attr:a":="x\" OR *"`)
+	f(`"a\":* OR b"=1`, `"span_attr:a\":* OR b":="1"`)
+}
+
+func TestParseAttributeFilterFailure(t *testing.T) {
+	fieldFunc := func(key, value string) (string, string) {
+		return key, value
+	}
+
+	f := func(s string) {
+		t.Helper()
+
+		af, err := ParseAttributeFilter(s, fieldFunc)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q; got %s", s, af)
+		}
+	}
+
+	f(``)
+	f(`   `)
+	f(`a=`)
+	f(`=b`)
+	f(`a==b`)
+	f(`a>b`)
+	f(`a>NaN`)
+	f(`a<=Inf`)
+	f(`a=~"["`)
+	f(`(a=1`)
+	f(`a=1)`)
+	f(`a=1 OR`)
+	f(`AND a=1`)
+	f(`NOT`)
+	f(`"a`)
+	f(`a b ~`)
+	f(`((((((((((((((((((((((((((((((((((a))))))))))))))))))))))))))))))))))`)
+}
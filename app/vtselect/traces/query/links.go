@@ -0,0 +1,171 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	vtstoragecommon "github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage/common"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// maxIncomingLinks is the maximum number of incoming links returned by GetTraceLinks.
+const maxIncomingLinks = 1000
+
+// TraceLink is a link between a span of the trace and a span of the linked trace.
+type TraceLink struct {
+	// SpanID is the span ID in the trace.
+	SpanID string
+	// LinkedTraceID is the trace ID on the other side of the link.
+	LinkedTraceID string
+	// LinkedSpanID is the span ID on the other side of the link.
+	LinkedSpanID string
+}
+
+// TraceLinks contains the links of a trace.
+type TraceLinks struct {
+	// Outgoing contains the links from spans of the trace to other spans.
+	Outgoing []TraceLink
+	// Incoming contains the links from other spans to spans of the trace.
+	Incoming []TraceLink
+}
+
+// GetTraceLinks returns outgoing and incoming links of traceID.
+//
+// The outgoing links are read from `link:*` fields of the trace spans. The incoming links are searched in the link-idx stream,
+// which is written during the ingestion if -insert.indexSpanLinks is set, from the start of the trace till now. Up to maxIncomingLinks incoming links are returned.
+//
+// nil is returned if traceID cannot be found within the retention period.
+func GetTraceLinks(ctx context.Context, cp *CommonParams, traceID string) (*TraceLinks, error) {
+	rows, err := GetTrace(ctx, cp, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	tl := &TraceLinks{}
+	minTimestamp := rows[0].Timestamp
+	for _, row := range rows {
+		tl.Outgoing = appendSpanLinks(tl.Outgoing, row.Fields)
+		minTimestamp = min(minTimestamp, row.Timestamp)
+	}
+	sortTraceLinks(tl.Outgoing)
+
+	startTime := time.Unix(0, minTimestamp).Add(-*traceMaxDurationWindow)
+	tl.Incoming, err = getIncomingLinks(ctx, cp, traceID, startTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return tl, nil
+}
+
+// appendSpanLinks appends the links of the span in fields to dst.
+func appendSpanLinks(dst []TraceLink, fields []logstorage.Field) []TraceLink {
+	const traceIDPrefix = otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":"
+	const spanIDPrefix = otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":"
+
+	spanID := getFieldValue(fields, otelpb.SpanIDField)
+	for _, f := range fields {
+		idx, ok := strings.CutPrefix(f.Name, traceIDPrefix)
+		if !ok || f.Value == "" {
+			continue
+		}
+		dst = append(dst, TraceLink{
+			SpanID:        spanID,
+			LinkedTraceID: f.Value,
+			LinkedSpanID:  getFieldValue(fields, spanIDPrefix+idx),
+		})
+	}
+	return dst
+}
+
+// getIncomingLinks returns the links to traceID from the link-idx stream on [startTime, endTime] time range.
+func getIncomingLinks(ctx context.Context, cp *CommonParams, traceID string, startTime, endTime time.Time) ([]TraceLink, error) {
+	// query: {link_idx_stream="xx"} AND link_idx:=traceID | fields link_idx_span_id, link_idx_source_trace_id, link_idx_source_span_id
+	qStr := fmt.Sprintf(`{%s="%d"} AND %s:=%q | fields %s, %s, %s`, otelpb.LinkIndexStreamName, xxhash.Sum64String(traceID)%otelpb.LinkIndexPartitionCount,
+		otelpb.LinkIndexFieldName, traceID, otelpb.LinkIndexSpanIDFieldName, otelpb.LinkIndexSourceTraceIDFieldName, otelpb.LinkIndexSourceSpanIDFieldName)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(startTime.UnixNano(), endTime.UnixNano())
+	q.AddPipeOffsetLimit(0, maxIncomingLinks)
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var mu sync.Mutex
+	seen := make(map[TraceLink]struct{})
+	var links []TraceLink
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		var spanIDs, sourceTraceIDs, sourceSpanIDs []string
+		for _, c := range db.Columns {
+			switch c.Name {
+			case otelpb.LinkIndexSpanIDFieldName:
+				spanIDs = c.Values
+			case otelpb.LinkIndexSourceTraceIDFieldName:
+				sourceTraceIDs = c.Values
+			case otelpb.LinkIndexSourceSpanIDFieldName:
+				sourceSpanIDs = c.Values
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, sourceTraceID := range sourceTraceIDs {
+			link := TraceLink{
+				LinkedTraceID: sourceTraceID,
+			}
+			if i < len(spanIDs) {
+				link.SpanID = spanIDs[i]
+			}
+			if i < len(sourceSpanIDs) {
+				link.LinkedSpanID = sourceSpanIDs[i]
+			}
+			// the same link could be indexed multiple times if the span is ingested multiple times.
+			if _, ok := seen[link]; ok {
+				continue
+			}
+			link = TraceLink{
+				SpanID:        strings.Clone(link.SpanID),
+				LinkedTraceID: strings.Clone(link.LinkedTraceID),
+				LinkedSpanID:  strings.Clone(link.LinkedSpanID),
+			}
+			seen[link] = struct{}{}
+			links = append(links, link)
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	sortTraceLinks(links)
+	return links, nil
+}
+
+func sortTraceLinks(links []TraceLink) {
+	sort.Slice(links, func(i, j int) bool {
+		a, b := &links[i], &links[j]
+		if a.SpanID != b.SpanID {
+			return a.SpanID < b.SpanID
+		}
+		if a.LinkedTraceID != b.LinkedTraceID {
+			return a.LinkedTraceID < b.LinkedTraceID
+		}
+		return a.LinkedSpanID < b.LinkedSpanID
+	})
+}
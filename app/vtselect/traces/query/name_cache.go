@@ -0,0 +1,207 @@
+package query
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	nameListCacheRefreshInterval = flag.Duration("search.traceServiceAndSpanNameCacheRefreshInterval", 30*time.Second, "The interval for incremental refresh "+
+		"of the cached service names and span names. Only streams seen since the previous refresh are searched on every refresh. Zero disables the cache. "+
+		"It affects Jaeger's /api/services and /api/services/*/operations APIs. See also -search.traceServiceAndSpanNameCacheFullRefreshInterval")
+	nameListCacheFullRefreshInterval = flag.Duration("search.traceServiceAndSpanNameCacheFullRefreshInterval", 5*time.Minute, "The interval for full refresh "+
+		"of the cached service names and span names over -search.traceServiceAndSpanNameLookbehind window. "+
+		"Other vtselect instances may return the names of deleted spans for up to this interval after the delete task is finished, "+
+		"since they aren't notified about the delete task")
+)
+
+const (
+	// nameListCacheOverlap is the overlap between incremental refresh windows. It allows picking up spans, which are ingested with some delay.
+	nameListCacheOverlap = time.Minute
+	// nameListCacheEntryTimeout is the time after which the entry is removed if it isn't accessed.
+	nameListCacheEntryTimeout = 10 * time.Minute
+)
+
+var nameListCacheV = newNameListCache()
+
+var (
+	nameListCacheRequests      = metrics.NewCounter(`vt_name_list_cache_requests_total`)
+	nameListCacheFullRefreshes = metrics.NewCounter(`vt_name_list_cache_refreshes_total{type="full"}`)
+	nameListCacheIncrRefreshes = metrics.NewCounter(`vt_name_list_cache_refreshes_total{type="incremental"}`)
+	nameListCacheRefreshErrors = metrics.NewCounter(`vt_name_list_cache_refresh_errors_total`)
+	nameListCacheInvalidations = metrics.NewCounter(`vt_name_list_cache_invalidations_total`)
+
+	_ = metrics.NewGauge(`vt_name_list_cache_entries`, func() float64 {
+		return float64(nameListCacheV.len())
+	})
+)
+
+// nameListFetchFunc returns the names found in the [start, end] time range.
+type nameListFetchFunc func(ctx context.Context, cp *CommonParams, start, end time.Time) ([]string, error)
+
+// nameListCacheKey identifies the cached list of names for a tenant.
+type nameListCacheKey struct {
+	tenantID logstorage.TenantID
+	// spanNames is set for the span name list of serviceName.
+	// operations is set for the list of span names with span kinds of serviceName. See encodeOperation.
+	// Otherwise, it's the service name list.
+	spanNames   bool
+	operations  bool
+	serviceName string
+}
+
+// nameListCache caches the lists of service names and span names per tenant.
+//
+// Every list is refreshed incrementally: only the streams seen since the previous refresh are searched, and the names found
+// are merged into the list. The names, which haven't been seen during -search.traceServiceAndSpanNameLookbehind, are dropped.
+// Since the lists are always built from the storage, they stay consistent across vtselect replicas, except of the names of deleted spans.
+// Such names are dropped on the next full refresh, which is performed every -search.traceServiceAndSpanNameCacheFullRefreshInterval.
+type nameListCache struct {
+	mu          sync.Mutex
+	entries     map[nameListCacheKey]*nameListCacheEntry
+	lastCleanup time.Time
+}
+
+type nameListCacheEntry struct {
+	// mu serializes refreshes of the entry, so concurrent requests don't query the storage multiple times.
+	mu sync.Mutex
+
+	// names maps the name to the last time it was seen.
+	names map[string]time.Time
+
+	lastRefresh     time.Time
+	lastFullRefresh time.Time
+
+	// lastAccess is protected by nameListCache.mu.
+	lastAccess time.Time
+}
+
+func newNameListCache() *nameListCache {
+	return &nameListCache{
+		entries: make(map[nameListCacheKey]*nameListCacheEntry),
+	}
+}
+
+func (c *nameListCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// getEntry returns the entry for k and removes the entries, which haven't been accessed for a while.
+func (c *nameListCache) getEntry(k nameListCacheKey, now time.Time) *nameListCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastCleanup) > nameListCacheEntryTimeout {
+		for key, e := range c.entries {
+			if now.Sub(e.lastAccess) > nameListCacheEntryTimeout {
+				delete(c.entries, key)
+			}
+		}
+		c.lastCleanup = now
+	}
+
+	e, ok := c.entries[k]
+	if !ok {
+		e = &nameListCacheEntry{}
+		c.entries[k] = e
+	}
+	e.lastAccess = now
+	return e
+}
+
+// invalidate removes all the cached lists of the given tenants.
+func (c *nameListCache) invalidate(tenantIDs []logstorage.TenantID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		for _, tenantID := range tenantIDs {
+			if k.tenantID == tenantID {
+				delete(c.entries, k)
+				break
+			}
+		}
+	}
+}
+
+// getNames returns the sorted names for k, which are seen during lookbehind window, up to limit entries.
+//
+// The names are fetched with fetch on cache miss, and when the cached list must be refreshed.
+func (c *nameListCache) getNames(ctx context.Context, cp *CommonParams, k nameListCacheKey, limit uint64, fetch nameListFetchFunc) ([]string, error) {
+	nameListCacheRequests.Inc()
+	now := time.Now()
+	e := c.getEntry(k, now)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	lookbehind := *traceServiceAndSpanNameLookbehind
+	switch {
+	case e.names == nil || now.Sub(e.lastFullRefresh) >= *nameListCacheFullRefreshInterval:
+		names, err := fetch(ctx, cp, now.Add(-lookbehind), now)
+		if err != nil {
+			nameListCacheRefreshErrors.Inc()
+			return nil, err
+		}
+		nameListCacheFullRefreshes.Inc()
+		e.names = make(map[string]time.Time, len(names))
+		for _, name := range names {
+			e.names[name] = now
+		}
+		e.lastRefresh = now
+		e.lastFullRefresh = now
+	case now.Sub(e.lastRefresh) >= *nameListCacheRefreshInterval:
+		names, err := fetch(ctx, cp, e.lastRefresh.Add(-nameListCacheOverlap), now)
+		if err != nil {
+			nameListCacheRefreshErrors.Inc()
+			return nil, err
+		}
+		nameListCacheIncrRefreshes.Inc()
+		for _, name := range names {
+			e.names[name] = now
+		}
+		e.lastRefresh = now
+	}
+
+	result := make([]string, 0, len(e.names))
+	for name, lastSeen := range e.names {
+		if now.Sub(lastSeen) > lookbehind {
+			delete(e.names, name)
+			continue
+		}
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	if uint64(len(result)) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// getCachedNameList returns the names for k from the cache if it's enabled and the request is for a single tenant.
+// Otherwise, the names are fetched from the storage directly.
+func getCachedNameList(ctx context.Context, cp *CommonParams, k nameListCacheKey, limit uint64, fetch nameListFetchFunc) ([]string, error) {
+	if *nameListCacheRefreshInterval <= 0 || len(cp.TenantIDs) != 1 {
+		now := time.Now()
+		return fetch(ctx, cp, now.Add(-*traceServiceAndSpanNameLookbehind), now)
+	}
+	k.tenantID = cp.TenantIDs[0]
+	return nameListCacheV.getNames(ctx, cp, k, limit, fetch)
+}
+
+// InvalidateNameListCache drops the cached service names and span names of the given tenants.
+//
+// It must be called when a delete task for the tenants is finished. The delete task runs in background, so the names of the spans,
+// which aren't deleted yet, could be cached again while the task is running.
+func InvalidateNameListCache(tenantIDs []logstorage.TenantID) {
+	nameListCacheInvalidations.Inc()
+	nameListCacheV.invalidate(tenantIDs)
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+)
+
+func TestNameListCache(t *testing.T) {
+	c := newNameListCache()
+	cp := &CommonParams{}
+	k := nameListCacheKey{
+		tenantID: logstorage.TenantID{AccountID: 1},
+	}
+
+	var fetchCalls int
+	var fetchStart time.Time
+	var fetchResult []string
+	var fetchErr error
+	fetch := func(_ context.Context, _ *CommonParams, start, _ time.Time) ([]string, error) {
+		fetchCalls++
+		fetchStart = start
+		return fetchResult, fetchErr
+	}
+
+	f := func(limit uint64, resultExpected []string, fetchCallsExpected int) {
+		t.Helper()
+
+		result, err := c.getNames(context.Background(), cp, k, limit, fetch)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+		if fetchCalls != fetchCallsExpected {
+			t.Fatalf("unexpected number of fetch calls; got %d; want %d", fetchCalls, fetchCallsExpected)
+		}
+	}
+
+	// full refresh on cache miss
+	fetchResult = []string{"b", "a"}
+	f(10, []string{"a", "b"}, 1)
+
+	// served from the cache
+	fetchResult = []string{"c"}
+	f(10, []string{"a", "b"}, 1)
+
+	// incremental refresh merges new names, and searches only since the previous refresh.
+	e := c.getEntry(k, time.Now())
+	lastRefresh := time.Now().Add(-time.Hour)
+	e.lastRefresh = lastRefresh
+	e.lastFullRefresh = time.Now()
+	f(10, []string{"a", "b", "c"}, 2)
+	if !fetchStart.Equal(lastRefresh.Add(-nameListCacheOverlap)) {
+		t.Fatalf("unexpected start of incremental refresh; got %s; want %s", fetchStart, lastRefresh.Add(-nameListCacheOverlap))
+	}
+
+	// names not seen during lookbehind window are dropped.
+	e.names["a"] = time.Now().Add(-*traceServiceAndSpanNameLookbehind - time.Minute)
+	f(10, []string{"b", "c"}, 2)
+
+	// limit
+	f(1, []string{"b"}, 2)
+
+	// refresh errors are returned, and the cached names are kept.
+	e.lastRefresh = time.Time{}
+	fetchErr = fmt.Errorf("some error")
+	if _, err := c.getNames(context.Background(), cp, k, 10, fetch); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+	fetchErr = nil
+	e.lastRefresh = time.Now()
+	f(10, []string{"b", "c"}, 3)
+
+	// invalidation of another tenant keeps the entry.
+	c.invalidate([]logstorage.TenantID{{AccountID: 2}})
+	f(10, []string{"b", "c"}, 3)
+
+	// invalidation leads to full refresh.
+	c.invalidate([]logstorage.TenantID{k.tenantID})
+	fetchResult = []string{"d"}
+	f(10, []string{"d"}, 4)
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -33,6 +36,8 @@ var (
 		"This limit affects Jaeger's /api/services API.")
 	traceMaxSpanNameList = flag.Uint64("search.traceMaxSpanNameList", 1000, "The maximum number of span name can return in a get span name request. "+
 		"This limit affects Jaeger's /api/services/*/operations API.")
+	traceMaxRemoteServiceNameList = flag.Uint64("search.traceMaxRemoteServiceNameList", 1000, "The maximum number of remote service name can return in a get remote service name request. "+
+		"This limit affects Zipkin's /api/v2/remoteServices API.")
 )
 
 var (
@@ -88,6 +93,22 @@ type TraceQueryParam struct {
 	DurationMin  time.Duration
 	DurationMax  time.Duration
 	Limit        int
+
+	// AttributeKeys contains the attributes that must exist in the span, regardless of their values.
+	AttributeKeys []string
+	// Filter is the optional attribute filter expression, which the span must match.
+	Filter *AttributeFilter
+	// Cursor is the optional position returned with the previous page of traces. Only the traces after it are returned.
+	// It's supported only for TraceSortOrderRecent.
+	Cursor *TraceCursor
+	// SortOrder is the order of the returned traces. It's applied before the Limit.
+	SortOrder TraceSortOrder
+
+	// EventName, ExceptionType and ExceptionMessage are the optional words or phrases, which must be contained in
+	// the name of any span event, and the type and the message of any exception recorded as span event accordingly.
+	EventName        string
+	ExceptionType    string
+	ExceptionMessage string
 }
 
 // Row represent the query result of a trace span.
@@ -97,17 +118,28 @@ type Row struct {
 }
 
 // GetServiceNameList returns all unique service names within *traceServiceAndSpanNameLookbehind window.
-// todo: cache of recent result.
+//
+// The result is served from the cache, which is refreshed incrementally. See -search.traceServiceAndSpanNameCacheRefreshInterval.
 func GetServiceNameList(ctx context.Context, cp *CommonParams) ([]string, error) {
-	currentTime := time.Now()
+	return getCachedNameList(ctx, cp, nameListCacheKey{}, *traceMaxServiceNameList, getServiceNameList)
+}
+
+// GetServiceNameListInTimeRange returns all unique service names within [start, end] time range.
+//
+// Unlike GetServiceNameList, the result isn't cached.
+func GetServiceNameListInTimeRange(ctx context.Context, cp *CommonParams, start, end time.Time) ([]string, error) {
+	return getServiceNameList(ctx, cp, start, end)
+}
 
+// getServiceNameList returns all unique service names within [start, end] time range from the storage.
+func getServiceNameList(ctx context.Context, cp *CommonParams, start, end time.Time) ([]string, error) {
 	// query: _time:[start, end] *
 	qStr := "*"
-	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, end.UnixNano())
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
 	}
-	q.AddTimeFilter(currentTime.Add(-*traceServiceAndSpanNameLookbehind).UnixNano(), currentTime.UnixNano())
+	q.AddTimeFilter(start.UnixNano(), end.UnixNano())
 
 	cp.Query = q
 	qctx := cp.NewQueryContext(ctx)
@@ -126,17 +158,34 @@ func GetServiceNameList(ctx context.Context, cp *CommonParams) ([]string, error)
 }
 
 // GetSpanNameList returns all unique span names for a service within *traceServiceAndSpanNameLookbehind window.
-// todo: cache of recent result.
+//
+// The result is served from the cache, which is refreshed incrementally. See -search.traceServiceAndSpanNameCacheRefreshInterval.
 func GetSpanNameList(ctx context.Context, cp *CommonParams, serviceName string) ([]string, error) {
-	currentTime := time.Now()
+	k := nameListCacheKey{
+		spanNames:   true,
+		serviceName: serviceName,
+	}
+	return getCachedNameList(ctx, cp, k, *traceMaxSpanNameList, func(ctx context.Context, cp *CommonParams, start, end time.Time) ([]string, error) {
+		return getSpanNameList(ctx, cp, serviceName, start, end)
+	})
+}
 
+// GetSpanNameListInTimeRange returns all unique span names for a service within [start, end] time range.
+//
+// Unlike GetSpanNameList, the result isn't cached.
+func GetSpanNameListInTimeRange(ctx context.Context, cp *CommonParams, serviceName string, start, end time.Time) ([]string, error) {
+	return getSpanNameList(ctx, cp, serviceName, start, end)
+}
+
+// getSpanNameList returns all unique span names for a service within [start, end] time range from the storage.
+func getSpanNameList(ctx context.Context, cp *CommonParams, serviceName string, start, end time.Time) ([]string, error) {
 	// query: _time:[start, end] {"resource_attr:service.name"=serviceName}
 	qStr := fmt.Sprintf("_stream:{%s=%q}", otelpb.ResourceAttrServiceName, serviceName)
-	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.Unix())
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, end.UnixNano())
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
 	}
-	q.AddTimeFilter(currentTime.Add(-*traceServiceAndSpanNameLookbehind).UnixNano(), currentTime.UnixNano())
+	q.AddTimeFilter(start.UnixNano(), end.UnixNano())
 
 	cp.Query = q
 	qctx := cp.NewQueryContext(ctx)
@@ -154,6 +203,135 @@ func GetSpanNameList(ctx context.Context, cp *CommonParams, serviceName string)
 	return spanNameList, nil
 }
 
+// Operation is a span name of a service together with the span kind.
+type Operation struct {
+	Name string
+	// Kind is the span kind in stored format, e.g. `2` for server spans.
+	Kind string
+}
+
+// GetOperationList returns all unique (span name, span kind) pairs for a service within *traceServiceAndSpanNameLookbehind window.
+//
+// The result is served from the cache, which is refreshed incrementally. See -search.traceServiceAndSpanNameCacheRefreshInterval.
+func GetOperationList(ctx context.Context, cp *CommonParams, serviceName string) ([]*Operation, error) {
+	k := nameListCacheKey{
+		operations:  true,
+		serviceName: serviceName,
+	}
+	operations, err := getCachedNameList(ctx, cp, k, *traceMaxSpanNameList, func(ctx context.Context, cp *CommonParams, start, end time.Time) ([]string, error) {
+		return getOperationList(ctx, cp, serviceName, start, end)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeOperations(operations), nil
+}
+
+// GetOperationListInTimeRange returns all unique (span name, span kind) pairs for a service within [start, end] time range.
+//
+// Unlike GetOperationList, the result isn't cached.
+func GetOperationListInTimeRange(ctx context.Context, cp *CommonParams, serviceName string, start, end time.Time) ([]*Operation, error) {
+	operations, err := getOperationList(ctx, cp, serviceName, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOperations(operations), nil
+}
+
+// getOperationList returns all unique (span name, span kind) pairs for a service within [start, end] time range from the storage.
+//
+// The pairs are encoded with encodeOperation, so they could be stored in the name list cache.
+func getOperationList(ctx context.Context, cp *CommonParams, serviceName string, start, end time.Time) ([]string, error) {
+	// query: _time:[start, end] {"resource_attr:service.name"=serviceName} | uniq by (name, kind) limit N
+	qStr := fmt.Sprintf("_stream:{%s=%q} | uniq by (%s, %s) limit %d", otelpb.ResourceAttrServiceName, serviceName, otelpb.NameField, otelpb.KindField, *traceMaxSpanNameList)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, end.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(start.UnixNano(), end.UnixNano())
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var operationsLock sync.Mutex
+	var operations []string
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		var names, kinds []string
+		for _, c := range db.Columns {
+			switch c.Name {
+			case otelpb.NameField:
+				names = c.Values
+			case otelpb.KindField:
+				kinds = c.Values
+			}
+		}
+
+		operationsLock.Lock()
+		defer operationsLock.Unlock()
+		for i, name := range names {
+			kind := ""
+			if i < len(kinds) {
+				kind = kinds[i]
+			}
+			operations = append(operations, encodeOperation(name, kind))
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return operations, nil
+}
+
+// encodeOperation encodes the span name and span kind into a string.
+func encodeOperation(name, kind string) string {
+	// the kind is a number, so it cannot contain the separator.
+	return kind + ":" + name
+}
+
+// decodeOperations decodes the operations encoded with encodeOperation.
+func decodeOperations(operations []string) []*Operation {
+	result := make([]*Operation, 0, len(operations))
+	for _, s := range operations {
+		kind, name, _ := strings.Cut(s, ":")
+		result = append(result, &Operation{
+			Name: name,
+			Kind: kind,
+		})
+	}
+	return result
+}
+
+// GetRemoteServiceNameList returns all unique remote service names (the `peer.service` span attribute) called by a service
+// within *traceServiceAndSpanNameLookbehind window.
+func GetRemoteServiceNameList(ctx context.Context, cp *CommonParams, serviceName string) ([]string, error) {
+	currentTime := time.Now()
+
+	// query: _time:[start, end] {"resource_attr:service.name"=serviceName} AND "span_attr:peer.service":*
+	qStr := fmt.Sprintf("_stream:{%s=%q} AND %q:*", otelpb.ResourceAttrServiceName, serviceName, otelpb.SpanAttrPeerServiceField)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(currentTime.Add(-*traceServiceAndSpanNameLookbehind).UnixNano(), currentTime.UnixNano())
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	remoteServiceHits, err := vtstorage.GetFieldValues(qctx, otelpb.SpanAttrPeerServiceField, *traceMaxRemoteServiceNameList)
+	if err != nil {
+		return nil, fmt.Errorf("get remote service name hits error: %s", err)
+	}
+
+	remoteServiceList := make([]string, 0, len(remoteServiceHits))
+	for i := range remoteServiceHits {
+		remoteServiceList = append(remoteServiceList, remoteServiceHits[i].Value)
+	}
+	return remoteServiceList, nil
+}
+
 // GetTrace returns all spans of a trace in []*Row format.
 // It search in the index stream for the approximate timestamp.
 // If found:
@@ -161,8 +339,30 @@ func GetSpanNameList(ctx context.Context, cp *CommonParams, serviceName string)
 // If not found:
 // - search span by step via findSpansByTraceID.
 //
-// todo in-memory cache of hot traces.
+// The traces, whose newest span is older than -search.traceMaxDurationWindow, are cached in memory. See -search.traceCacheSize.
+// The returned rows must not be modified.
 func GetTrace(ctx context.Context, cp *CommonParams, traceID string) ([]*Row, error) {
+	return getCachedTrace(cp, traceID, func() ([]*Row, error) {
+		return getTrace(ctx, cp, traceID)
+	})
+}
+
+func getTrace(ctx context.Context, cp *CommonParams, traceID string) ([]*Row, error) {
+	startTime, endTime, ok, err := findTraceTimeRange(ctx, cp, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return findSpansByTraceIDAndTime(ctx, cp, traceID, startTime, endTime)
+}
+
+// findTraceTimeRange returns the time range, which contains all the spans of traceID.
+// It search in the index stream for the approximate timestamp of the trace, and extends it by -search.traceMaxDurationWindow.
+//
+// false is returned if the traceID cannot be found within the retention period.
+func findTraceTimeRange(ctx context.Context, cp *CommonParams, traceID string) (time.Time, time.Time, bool, error) {
 	currentTime := time.Now()
 
 	// possible partition
@@ -170,21 +370,260 @@ func GetTrace(ctx context.Context, cp *CommonParams, traceID string) ([]*Row, er
 	qStr := fmt.Sprintf(`{%s="%d"} AND %s:=%q | fields _time`, otelpb.TraceIDIndexStreamName, xxhash.Sum64String(traceID)%otelpb.TraceIDIndexPartitionCount, otelpb.TraceIDIndexFieldName, traceID)
 	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
 	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal query=%q: %w", qStr, err)
+		return time.Time{}, time.Time{}, false, fmt.Errorf("cannot unmarshal query=%q: %w", qStr, err)
 	}
 	q.AddPipeOffsetLimit(0, 1)
 	traceTimestamp, err := findTraceIDTimeSplitTimeRange(ctx, q, cp)
 	if err != nil && errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
 		// no hit in the retention period, simply returns empty.
-		return nil, nil
+		return time.Time{}, time.Time{}, false, nil
 	}
 	if err != nil {
 		// something wrong when trying to find the trace_id's start time.
-		return nil, fmt.Errorf("cannot find trace_id %q start time: %s", traceID, err)
+		return time.Time{}, time.Time{}, false, fmt.Errorf("cannot find trace_id %q start time: %s", traceID, err)
+	}
+
+	// trace start time found, search in [trace start time - *traceMaxDurationWindow, trace start time + *traceMaxDurationWindow] time range.
+	return traceTimestamp.Add(-*traceMaxDurationWindow), traceTimestamp.Add(*traceMaxDurationWindow), true, nil
+}
+
+// GetTracesByIDs returns the found traceIDs and all spans of them in []*Row format.
+//
+// Unlike calling GetTrace for each traceID, it searches the index stream for all traceIDs in a single pass:
+// - search for `{trace_id_idx_stream in (...)} AND trace_id_idx:in(...)` by -search.traceSearchStep until all traceIDs are found.
+// - search for spans with `trace_id:in(...)` in time range [min(aTimestamp)-traceMaxDurationWindow, max(aTimestamp)+traceMaxDurationWindow].
+//
+// To avoid scanning a huge time range for traces that are far away from each other, the traces are grouped
+// by their time windows, and the spans search is performed for each group.
+//
+// The returned traceIDs keep the order of the input, and the traceIDs that cannot be found are omitted.
+func GetTracesByIDs(ctx context.Context, cp *CommonParams, traceIDs []string) ([]string, []*Row, error) {
+	traceIDs = uniqTraceIDList(checkTraceIDList(traceIDs))
+	if len(traceIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	traceTimestamps, err := findTraceIDsTimeSplitTimeRange(ctx, cp, traceIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot find trace_id start time: %w", err)
+	}
+
+	foundTraceIDs := make([]string, 0, len(traceTimestamps))
+	for _, traceID := range traceIDs {
+		if _, ok := traceTimestamps[traceID]; ok {
+			foundTraceIDs = append(foundTraceIDs, traceID)
+		}
+	}
+	if len(foundTraceIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	var rows []*Row
+	for _, g := range groupTraceIDsByTimeWindow(traceTimestamps, *traceMaxDurationWindow) {
+		groupRows, err := findSpansByTraceIDsAndTime(ctx, cp, g.traceIDs, g.startTime, g.endTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, groupRows...)
+	}
+	return foundTraceIDs, rows, nil
+}
+
+// traceIDGroup is a group of traceIDs whose spans could be searched in the same time range.
+type traceIDGroup struct {
+	traceIDs  []string
+	startTime time.Time
+	endTime   time.Time
+}
+
+// groupTraceIDsByTimeWindow groups traceIDs whose [timestamp-window, timestamp+window] time ranges overlap.
+func groupTraceIDsByTimeWindow(traceTimestamps map[string]time.Time, window time.Duration) []*traceIDGroup {
+	traceIDs := make([]string, 0, len(traceTimestamps))
+	for traceID := range traceTimestamps {
+		traceIDs = append(traceIDs, traceID)
+	}
+	sort.Slice(traceIDs, func(i, j int) bool {
+		ti, tj := traceTimestamps[traceIDs[i]], traceTimestamps[traceIDs[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return traceIDs[i] < traceIDs[j]
+	})
+
+	var groups []*traceIDGroup
+	var g *traceIDGroup
+	for _, traceID := range traceIDs {
+		startTime, endTime := traceTimestamps[traceID].Add(-window), traceTimestamps[traceID].Add(window)
+		if g == nil || startTime.After(g.endTime) {
+			g = &traceIDGroup{
+				startTime: startTime,
+			}
+			groups = append(groups, g)
+		}
+		g.traceIDs = append(g.traceIDs, traceID)
+		g.endTime = endTime
+	}
+	return groups
+}
+
+// findTraceIDsTimeSplitTimeRange is similar to findTraceIDTimeSplitTimeRange, but searches for multiple traceIDs at once.
+// It returns the start time of the found traceIDs. The traceIDs that cannot be found within the retention period are omitted.
+func findTraceIDsTimeSplitTimeRange(ctx context.Context, cp *CommonParams, traceIDs []string) (map[string]time.Time, error) {
+	currentTime := time.Now()
+
+	// query: {trace_id_idx_stream in (...)} AND trace_id_idx:in(...)
+	partitions := make(map[uint64]struct{})
+	partitionList := make([]string, 0, len(traceIDs))
+	for _, traceID := range traceIDs {
+		partition := xxhash.Sum64String(traceID) % otelpb.TraceIDIndexPartitionCount
+		if _, ok := partitions[partition]; ok {
+			continue
+		}
+		partitions[partition] = struct{}{}
+		partitionList = append(partitionList, fmt.Sprintf("%q", strconv.FormatUint(partition, 10)))
+	}
+	quotedTraceIDs := make([]string, len(traceIDs))
+	for i := range traceIDs {
+		quotedTraceIDs[i] = fmt.Sprintf("%q", traceIDs[i])
+	}
+	qStr := fmt.Sprintf(`{%s in (%s)} AND %s:in(%s) | fields _time, %s`, otelpb.TraceIDIndexStreamName, strings.Join(partitionList, ","),
+		otelpb.TraceIDIndexFieldName, strings.Join(quotedTraceIDs, ","), otelpb.TraceIDIndexFieldName)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+
+	var traceTimestampsLock sync.Mutex
+	traceTimestamps := make(map[string]time.Time, len(traceIDs))
+	var missingTimeColumn atomic.Bool
+
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctxWithCancel)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		if missingTimeColumn.Load() {
+			return
+		}
+
+		timestamps, ok := db.GetTimestamps(nil)
+		if !ok {
+			missingTimeColumn.Store(true)
+			cancel()
+			return
+		}
+
+		c := db.GetColumnByName(otelpb.TraceIDIndexFieldName)
+		if c == nil {
+			return
+		}
+
+		traceTimestampsLock.Lock()
+		for i, v := range c.Values {
+			ts := time.Unix(0, timestamps[i])
+			if prevTs, ok := traceTimestamps[v]; !ok || ts.Before(prevTs) {
+				traceTimestamps[strings.Clone(v)] = ts
+			}
+		}
+		traceTimestampsLock.Unlock()
+	}
+
+	startTime := currentTime.Add(-*traceSearchStep)
+	endTime := currentTime
+	for startTime.UnixNano() > 0 && len(traceTimestamps) < len(traceIDs) {
+		qq := q.CloneWithTimeFilter(currentTime.UnixNano(), startTime.UnixNano(), endTime.UnixNano())
+		qctx = qctx.WithQuery(qq)
+
+		if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+			if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+				break
+			}
+			return nil, err
+		}
+
+		if missingTimeColumn.Load() {
+			return nil, fmt.Errorf("missing _time column in the result for the query [%s]", qq)
+		}
+
+		endTime = startTime
+		startTime = startTime.Add(-*traceSearchStep)
+	}
+	return traceTimestamps, nil
+}
+
+// findSpansByTraceIDsAndTime search for spans of multiple traceIDs in given time range.
+func findSpansByTraceIDsAndTime(ctx context.Context, cp *CommonParams, traceIDs []string, startTime, endTime time.Time) ([]*Row, error) {
+	// query: trace_id:in(traceID, traceID, ...)
+	quotedTraceIDs := make([]string, len(traceIDs))
+	for i := range traceIDs {
+		quotedTraceIDs[i] = fmt.Sprintf("%q", traceIDs[i])
+	}
+	qStr := fmt.Sprintf(otelpb.TraceIDField+":in(%s)", strings.Join(quotedTraceIDs, ","))
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(startTime.UnixNano(), endTime.UnixNano())
+
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctxWithCancel)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	// search for trace spans and write to `rows []*Row`
+	var rowsLock sync.Mutex
+	var rows []*Row
+	var missingTimeColumn atomic.Bool
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		if missingTimeColumn.Load() {
+			return
+		}
+
+		columns := db.Columns
+		clonedColumnNames := make([]string, len(columns))
+		for i, c := range columns {
+			clonedColumnNames[i] = strings.Clone(c.Name)
+		}
+
+		timestamps, ok := db.GetTimestamps(nil)
+		if !ok {
+			missingTimeColumn.Store(true)
+			cancel()
+			return
+		}
+
+		for i, timestamp := range timestamps {
+			fields := make([]logstorage.Field, 0, len(columns))
+			for j := range columns {
+				// column could be empty if this span does not contain such field.
+				// only append non-empty columns.
+				if columns[j].Values[i] != "" {
+					fields = append(fields, logstorage.Field{
+						Name:  clonedColumnNames[j],
+						Value: strings.Clone(columns[j].Values[i]),
+					})
+				}
+			}
+
+			rowsLock.Lock()
+			rows = append(rows, &Row{
+				Timestamp: timestamp,
+				Fields:    fields,
+			})
+			rowsLock.Unlock()
+		}
 	}
 
-	// trace start time found, search in [trace start time, trace start time + *traceMaxDurationWindow] time range.
-	return findSpansByTraceIDAndTime(ctx, cp, traceID, traceTimestamp.Add(-*traceMaxDurationWindow), traceTimestamp.Add(*traceMaxDurationWindow))
+	if err = vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, err
+	}
+	if missingTimeColumn.Load() {
+		return nil, fmt.Errorf("missing _time column in the result for the query [%s]", q)
+	}
+	return rows, nil
 }
 
 // GetTraceList returns multiple traceIDs and spans of them in []*Row format.
@@ -196,27 +635,54 @@ func GetTrace(ctx context.Context, cp *CommonParams, traceID string) ([]*Row, er
 // 1. input time range: [00:00, 09:00]
 // 2. found 20 trace id, and adjust time range to: [08:00, 09:00]
 // 3. find spans on time range: [08:00-traceMaxDurationWindow, 09:00+traceMaxDurationWindow]
-func GetTraceList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, []*Row, error) {
-	currentTime := time.Now()
-
-	// query 1: * AND filter_conditions | last 1 by (_time) partition by (trace_id) | fields _time, trace_id | sort by (_time) desc
-	traceIDs, startTime, err := getTraceIDList(ctx, cp, param)
+//
+// It also returns the cursor for the next page of traces, which could be passed via param.Cursor.
+// The cursor is nil if there are no more traces.
+func GetTraceList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, []*Row, *TraceCursor, error) {
+	// query 1: * AND filter_conditions | last 1 by (_time) partition by (trace_id) | fields _time, trace_id | sort by (_time desc, trace_id desc)
+	traceIDs, startTime, nextCursor, err := getTraceIDList(ctx, cp, param)
 	if err != nil {
-		return nil, nil, fmt.Errorf("get trace id error: %w", err)
+		return nil, nil, nil, fmt.Errorf("get trace id error: %w", err)
 	}
 	if len(traceIDs) == 0 {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 
 	// query 2: trace_id:in(traceID, traceID, ...)
+	rows, err := GetTraceRows(ctx, cp, traceIDs, startTime, param.StartTimeMax)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return traceIDs, rows, nextCursor, nil
+}
+
+// GetTraceIDs returns the IDs of up to param.Limit most recent traces matching param,
+// and the earliest time of the matching spans of these traces.
+//
+// Unlike GetTraceList, it searches for the trace IDs only, so param.Limit could be much bigger than the limit of the trace search APIs.
+// The spans of the returned traces could be obtained in batches via GetTraceRows.
+func GetTraceIDs(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, time.Time, error) {
+	traceIDs, startTime, _, err := getTraceIDList(ctx, cp, param)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get trace id error: %w", err)
+	}
+	return traceIDs, startTime, nil
+}
+
+// GetTraceRows returns the spans of the given traceIDs, which have matching spans in the [startTime, endTime] time range.
+//
+// The time range is extended by -search.traceMaxDurationWindow in both directions, so the spans on the edges aren't missed.
+func GetTraceRows(ctx context.Context, cp *CommonParams, traceIDs []string, startTime, endTime time.Time) ([]*Row, error) {
+	currentTime := time.Now()
+
 	qStr := fmt.Sprintf(otelpb.TraceIDField+":in(%s)", strings.Join(traceIDs, ","))
 	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
 	}
 
 	// adjust start time and end time with max duration window to make sure all spans are included.
-	q.AddTimeFilter(startTime.Add(-*traceMaxDurationWindow).UnixNano(), param.StartTimeMax.Add(*traceMaxDurationWindow).UnixNano())
+	q.AddTimeFilter(startTime.Add(-*traceMaxDurationWindow).UnixNano(), endTime.Add(*traceMaxDurationWindow).UnixNano())
 
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	cp.Query = q
@@ -265,22 +731,51 @@ func GetTraceList(ctx context.Context, cp *CommonParams, param *TraceQueryParam)
 	}
 
 	if err = vtstorage.RunQuery(qctx, writeBlock); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if missingTimeColumn.Load() {
-		return nil, nil, fmt.Errorf("missing _time column in the result for the query [%s]", q)
+		return nil, fmt.Errorf("missing _time column in the result for the query [%s]", q)
 	}
-	return traceIDs, rows, nil
+	return rows, nil
 }
 
 // getTraceIDList returns traceIDs according to the search params.
-// It also returns the earliest start time of these traces, to help reducing the time range for spans search.
-func getTraceIDList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, time.Time, error) {
+// It also returns the earliest start time of these traces, to help reducing the time range for spans search,
+// and the cursor for the next page of traces if the limit is reached.
+func getTraceIDList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, time.Time, *TraceCursor, error) {
+	if param.SortOrder != TraceSortOrderRecent {
+		return getSortedTraceIDList(ctx, cp, param)
+	}
+
 	currentTime := time.Now()
-	// query: * AND <filter> | last 1 by (_time) partition by (trace_id) | fields _time, trace_id | sort by (_time) desc
+	// query: * AND <filter> | last 1 by (_time) partition by (trace_id) | fields _time, trace_id | [filter <cursor>] | sort by (_time desc, trace_id desc)
+	qStr := getSpanFilter(param)
+	qStr += " | last 1 by (_time) partition by (" + otelpb.TraceIDField + ") | fields _time, " + otelpb.TraceIDField
+	if param.Cursor != nil {
+		// skip the traces returned on the previous pages.
+		qStr += " | filter " + param.Cursor.filter()
+	}
+	// sort by trace_id as well, so the order of traces with the same _time is stable between pages.
+	qStr += " | sort by (_time desc, " + otelpb.TraceIDField + " desc)"
+
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	if err != nil {
+		return nil, time.Time{}, nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddPipeOffsetLimit(0, uint64(param.Limit))
+
+	return findTraceIDsSplitTimeRange(ctx, q, cp, param.StartTimeMin, param.StartTimeMax, param.Cursor, param.Limit)
+}
+
+// getSpanFilter returns LogsQL filter for the spans matching the search params.
+func getSpanFilter(param *TraceQueryParam) string {
 	qStr := "* "
 	if param.ServiceName != "" {
 		qStr += fmt.Sprintf("AND _stream:{"+otelpb.ResourceAttrServiceName+"=%q} ", param.ServiceName)
+	} else {
+		// without the service name filter, rows from the internal streams (such as trace_id index) could be hit as well.
+		// make sure only spans are returned.
+		qStr += "AND " + otelpb.TraceIDField + ":* "
 	}
 	if param.SpanName != "" {
 		qStr += fmt.Sprintf("AND _stream:{"+otelpb.NameField+"=%q} ", param.SpanName)
@@ -290,67 +785,98 @@ func getTraceIDList(ctx context.Context, cp *CommonParams, param *TraceQueryPara
 			qStr += fmt.Sprintf(`AND %q:=%q `, k, v)
 		}
 	}
+	for _, k := range param.AttributeKeys {
+		qStr += fmt.Sprintf(`AND %q:* `, k)
+	}
+	if param.Filter != nil {
+		qStr += "AND " + param.Filter.String() + " "
+	}
+	if param.EventName != "" {
+		qStr += fmt.Sprintf("AND "+otelpb.EventNamesField+":%q ", param.EventName)
+	}
+	if param.ExceptionType != "" {
+		qStr += fmt.Sprintf("AND "+otelpb.ExceptionTypesField+":%q ", param.ExceptionType)
+	}
+	if param.ExceptionMessage != "" {
+		qStr += fmt.Sprintf("AND "+otelpb.ExceptionMessagesField+":%q ", param.ExceptionMessage)
+	}
 	if param.DurationMin > 0 {
 		qStr += fmt.Sprintf("AND "+otelpb.DurationField+":>%d ", param.DurationMin.Nanoseconds())
 	}
 	if param.DurationMax > 0 {
 		qStr += fmt.Sprintf("AND duration:<%d ", param.DurationMax.Nanoseconds())
 	}
-	qStr += " | last 1 by (_time) partition by (" + otelpb.TraceIDField + ") | fields _time, " + otelpb.TraceIDField + " | sort by (_time) desc"
-
-	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
-	if err != nil {
-		return nil, time.Time{}, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
-	}
-	q.AddPipeOffsetLimit(0, uint64(param.Limit))
-
-	traceIDs, maxStartTime, err := findTraceIDsSplitTimeRange(ctx, q, cp, param.StartTimeMin, param.StartTimeMax, param.Limit)
-	if err != nil {
-		return nil, time.Time{}, err
-	}
-
-	return traceIDs, maxStartTime, nil
+	return qStr
 }
 
 // findTraceIDsSplitTimeRange try to search from the nearest time range of the end time.
 // if the result already met requirement of `limit`, return.
 // otherwise, amplify the time range to 5x and search again, until the start time exceed the input.
-func findTraceIDsSplitTimeRange(ctx context.Context, q *logstorage.Query, cp *CommonParams, startTime, endTime time.Time, limit int) ([]string, time.Time, error) {
+//
+// If cursor is set, the search starts from the cursor time instead of the end time, since q skips the traces before the cursor.
+// The cursor for the next page is returned if the result met requirement of `limit`.
+func findTraceIDsSplitTimeRange(ctx context.Context, q *logstorage.Query, cp *CommonParams, startTime, endTime time.Time, cursor *TraceCursor, limit int) ([]string, time.Time, *TraceCursor, error) {
 	currentTime := time.Now()
 
 	step := time.Minute
-	currentStartTime := endTime.Add(-step)
+	resumeTime := endTime
+	if cursor != nil && cursor.Timestamp < endTime.UnixNano() {
+		resumeTime = time.Unix(0, cursor.Timestamp)
+	}
+	currentStartTime := resumeTime.Add(-step)
 
 	var traceIDListLock sync.Mutex
 	traceIDList := make([]string, 0, limit)
-	maxStartTimeStr := endTime.Format(time.RFC3339)
+	maxStartTime := endTime.UnixNano()
+	// lastTrace is the position of the last trace in the result.
+	var lastTrace *TraceCursor
 
 	cp.Query = q
 	qctx := cp.NewQueryContext(ctx)
 	defer cp.UpdatePerQueryStatsMetrics()
 
 	writeBlock := func(_ uint, db *logstorage.DataBlock) {
-		columns := db.Columns
-		clonedColumnNames := make([]string, len(columns))
-		for i, c := range columns {
-			clonedColumnNames[i] = strings.Clone(c.Name)
-		}
-		for i := range clonedColumnNames {
-			switch clonedColumnNames[i] {
-			case "trace_id":
-				traceIDListLock.Lock()
-				for _, v := range columns[i].Values {
-					traceIDList = append(traceIDList, strings.Clone(v))
-				}
-				traceIDListLock.Unlock()
+		var traceIDColumn, timeColumn *logstorage.BlockColumn
+		for i := range db.Columns {
+			switch db.Columns[i].Name {
+			case otelpb.TraceIDField:
+				traceIDColumn = &db.Columns[i]
 			case "_time":
-				for _, v := range columns[i].Values {
-					if v < maxStartTimeStr {
-						maxStartTimeStr = strings.Clone(v)
-					}
-				}
+				timeColumn = &db.Columns[i]
 			}
 		}
+		if traceIDColumn == nil {
+			return
+		}
+
+		traceIDListLock.Lock()
+		defer traceIDListLock.Unlock()
+		for i, v := range traceIDColumn.Values {
+			traceID := strings.Clone(v)
+			traceIDList = append(traceIDList, traceID)
+			if timeColumn == nil {
+				continue
+			}
+			timestamp, ok := logstorage.TryParseTimestampRFC3339Nano(timeColumn.Values[i])
+			if !ok {
+				continue
+			}
+			maxStartTime = min(maxStartTime, timestamp)
+			if lastTrace != nil && lastTrace.less(timestamp, traceID) {
+				continue
+			}
+			lastTrace = &TraceCursor{
+				Timestamp: timestamp,
+				TraceID:   traceID,
+			}
+		}
+	}
+	result := func() ([]string, time.Time, *TraceCursor, error) {
+		var nextCursor *TraceCursor
+		if len(traceIDList) == limit {
+			nextCursor = lastTrace
+		}
+		return checkTraceIDList(traceIDList), time.Unix(0, maxStartTime), nextCursor, nil
 	}
 
 	for currentStartTime.After(startTime) {
@@ -358,22 +884,20 @@ func findTraceIDsSplitTimeRange(ctx context.Context, q *logstorage.Query, cp *Co
 		qctx = qctx.WithQuery(qClone)
 		if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
 			if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
-				return nil, time.Time{}, nil
+				return nil, time.Time{}, nil, nil
 			}
-			return nil, time.Time{}, err
+			return nil, time.Time{}, nil, err
 		}
 
 		// found enough trace_id, return directly
 		if len(traceIDList) == limit {
-			maxStartTime, err := time.Parse(time.RFC3339, maxStartTimeStr)
-			if err != nil {
-				return nil, maxStartTime, err
-			}
-			return checkTraceIDList(traceIDList), maxStartTime, nil
+			return result()
 		}
 
 		// not enough trace_id, clear the result, extend the time range and try again.
 		traceIDList = traceIDList[:0]
+		maxStartTime = endTime.UnixNano()
+		lastTrace = nil
 		step *= 5
 		currentStartTime = currentStartTime.Add(-step)
 	}
@@ -386,15 +910,10 @@ func findTraceIDsSplitTimeRange(ctx context.Context, q *logstorage.Query, cp *Co
 	qClone := q.CloneWithTimeFilter(currentTime.UnixNano(), currentStartTime.UnixNano(), endTime.UnixNano())
 	qctx = qctx.WithQuery(qClone)
 	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
-		return nil, time.Time{}, err
-	}
-
-	maxStartTime, err := time.Parse(time.RFC3339, maxStartTimeStr)
-	if err != nil {
-		return nil, maxStartTime, err
+		return nil, time.Time{}, nil, err
 	}
 
-	return checkTraceIDList(traceIDList), maxStartTime, nil
+	return result()
 }
 
 // findTraceIDTimeSplitTimeRange try to search from {trace_id_idx_stream="xx"} stream, which contains
@@ -541,27 +1060,85 @@ func checkTraceIDList(traceIDList []string) []string {
 	return result
 }
 
+// uniqTraceIDList removes empty and duplicated `trace_id` and keeps the order of the first occurrence.
+func uniqTraceIDList(traceIDList []string) []string {
+	m := make(map[string]struct{}, len(traceIDList))
+	result := make([]string, 0, len(traceIDList))
+	for _, traceID := range traceIDList {
+		if _, ok := m[traceID]; ok || traceID == "" {
+			continue
+		}
+		m[traceID] = struct{}{}
+		result = append(result, traceID)
+	}
+	return result
+}
+
 type ServiceGraphQueryParameters struct {
 	EndTs    time.Time
 	Lookback time.Duration
+	// ServiceName is the optional filter on the parent or the child of the relations.
+	ServiceName string
+}
+
+// ServiceGraphEdge is the service dependencies graph edge with the stats of calls from Parent to Child.
+type ServiceGraphEdge struct {
+	Parent    string
+	Child     string
+	CallCount uint64
+	// FailedCount is the number of calls, where either the client span or the server span has the error status.
+	FailedCount uint64
+	// Client contains the stats of the client (parent) spans of the calls.
+	Client ServiceGraphEdgeStats
+	// Server contains the stats of the server (child) spans of the calls.
+	Server ServiceGraphEdgeStats
 }
 
-// GetServiceGraphList returns service dependencies graph edges (parent, child, callCount) in []*Row format.
+// ServiceGraphEdgeStats contains the error count and the latency percentiles of the calls from one side of ServiceGraphEdge.
 //
-// TODO: currently this function can only handle request from Jaeger dependencies API. Since Tempo provides similar service graph
+// The percentiles are estimated from the latency histogram with ServiceGraphQuantileBuckets.
+// They are zero for the relations calculated before the histogram was added. The server stats are zero for the relations with virtual child nodes.
+type ServiceGraphEdgeStats struct {
+	ErrorCount uint64
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+}
+
+// GetServiceGraphEdges returns service dependencies graph edges with the call stats on the (param.EndTs-param.Lookback, param.EndTs] time range.
+//
+// The edges are sorted by parent and child.
+//
+// TODO: currently this function can only handle request from Jaeger, Zipkin and native dependencies APIs. Since Tempo provides similar service graph
 // feature, it would be great to add support for Tempo service graph API as well.
-func GetServiceGraphList(ctx context.Context, cp *CommonParams, param *ServiceGraphQueryParameters) ([]*Row, error) {
-	// {trace_service_graph_stream="-"} | fields parent, child, callCount | stats by (parent, child) sum(callCount) as callCount
-	qStr := fmt.Sprintf(`{%s="-"} | fields %s, %s, %s | stats by (%s, %s) sum(%s) as %s`,
-		otelpb.ServiceGraphStreamName,
-		otelpb.ServiceGraphParentFieldName,
-		otelpb.ServiceGraphChildFieldName,
-		otelpb.ServiceGraphCallCountFieldName,
-		otelpb.ServiceGraphParentFieldName,
-		otelpb.ServiceGraphChildFieldName,
-		otelpb.ServiceGraphCallCountFieldName,
+func GetServiceGraphEdges(ctx context.Context, cp *CommonParams, param *ServiceGraphQueryParameters) ([]*ServiceGraphEdge, error) {
+	// {trace_service_graph_stream="-"} | stats by (parent, child) sum(callCount) as callCount, sum(failedCount) as failedCount, ...
+	qStr := fmt.Sprintf(`{%s="-"} `, otelpb.ServiceGraphStreamName)
+	if param.ServiceName != "" {
+		qStr += fmt.Sprintf("AND (%s:=%q OR %s:=%q) ", otelpb.ServiceGraphParentFieldName, param.ServiceName, otelpb.ServiceGraphChildFieldName, param.ServiceName)
+	}
+	statsFields := []string{
 		otelpb.ServiceGraphCallCountFieldName,
-	)
+		otelpb.ServiceGraphServerCallCountFieldName,
+		otelpb.ServiceGraphFailedCountFieldName,
+		otelpb.ServiceGraphClientFailedCountFieldName,
+		otelpb.ServiceGraphServerFailedCountFieldName,
+	}
+	for _, b := range ServiceGraphQuantileBuckets {
+		le := strconv.FormatFloat(b, 'f', -1, 64)
+		statsFields = append(statsFields, otelpb.ServiceGraphClientLatencyBucketFieldPrefix+le, otelpb.ServiceGraphServerLatencyBucketFieldPrefix+le)
+	}
+	statsFuncs := make([]string, 0, len(statsFields)+1)
+	for _, f := range statsFields {
+		statsFuncs = append(statsFuncs, fmt.Sprintf("sum(%q) as %q", f, f))
+	}
+	// The relations calculated before the histogram was added must be excluded from the total number of calls in the client histogram.
+	// The server histogram contains only the calls with the server span, e.g. it doesn't contain the calls to virtual nodes.
+	lastBucket := strconv.FormatFloat(ServiceGraphQuantileBuckets[len(ServiceGraphQuantileBuckets)-1], 'f', -1, 64)
+	statsFuncs = append(statsFuncs, fmt.Sprintf("sum(%s) if (%q:*) as vt_client_histogram_count",
+		otelpb.ServiceGraphCallCountFieldName, otelpb.ServiceGraphClientLatencyBucketFieldPrefix+lastBucket))
+	qStr += fmt.Sprintf("| stats by (%s, %s) %s", otelpb.ServiceGraphParentFieldName, otelpb.ServiceGraphChildFieldName, strings.Join(statsFuncs, ", "))
+
 	startTime := param.EndTs.Add(-param.Lookback).UnixNano()
 	endTime := param.EndTs.UnixNano()
 	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime)
@@ -572,95 +1149,254 @@ func GetServiceGraphList(ctx context.Context, cp *CommonParams, param *ServiceGr
 
 	cp.Query = q
 	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
 
-	var rowsLock sync.Mutex
-	var rows []*Row
+	var edgesLock sync.Mutex
+	var edges []*ServiceGraphEdge
 	writeBlock := func(_ uint, db *logstorage.DataBlock) {
-		columns := db.Columns
-		if len(columns) == 0 {
-			return
-		}
-		clonedColumnNames := make([]string, len(columns))
-		valuesCount := 0
-		for i, c := range columns {
-			clonedColumnNames[i] = strings.Clone(c.Name)
-			if len(c.Values) > valuesCount {
-				valuesCount = len(c.Values)
+		for i := 0; i < db.RowsCount(); i++ {
+			fields := make([]logstorage.Field, len(db.Columns))
+			for j, c := range db.Columns {
+				fields[j] = logstorage.Field{
+					Name:  c.Name,
+					Value: c.Values[i],
+				}
 			}
-		}
-		if valuesCount == 0 {
-			return
-		}
-		for i := 0; i < valuesCount; i++ {
-			fields := make([]logstorage.Field, 0, len(columns))
-			for j := range columns {
-				fields = append(
-					fields,
-					logstorage.Field{
-						Name:  clonedColumnNames[j],
-						Value: strings.Clone(columns[j].Values[i]),
-					},
-				)
+			edge := newServiceGraphEdge(fields)
+			if edge.Parent == "" || edge.Child == "" || edge.CallCount == 0 {
+				continue
 			}
-			rowsLock.Lock()
-			rows = append(rows, &Row{
-				Fields: fields,
-			})
-			rowsLock.Unlock()
+
+			edgesLock.Lock()
+			edges = append(edges, edge)
+			edgesLock.Unlock()
 		}
 	}
 
 	if err = vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	return rows, nil
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Parent != edges[j].Parent {
+			return edges[i].Parent < edges[j].Parent
+		}
+		return edges[i].Child < edges[j].Child
+	})
+	return edges, nil
+}
+
+// newServiceGraphEdge creates ServiceGraphEdge from the result row of the query made by GetServiceGraphEdges.
+func newServiceGraphEdge(fields []logstorage.Field) *ServiceGraphEdge {
+	edge := &ServiceGraphEdge{}
+	var clientHistogramCount, serverHistogramCount uint64
+	clientBuckets := make([]uint64, len(ServiceGraphQuantileBuckets))
+	serverBuckets := make([]uint64, len(ServiceGraphQuantileBuckets))
+	bucketIdx := func(le string) int {
+		for i, b := range ServiceGraphQuantileBuckets {
+			if strconv.FormatFloat(b, 'f', -1, 64) == le {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, f := range fields {
+		switch {
+		case f.Name == otelpb.ServiceGraphParentFieldName:
+			edge.Parent = strings.Clone(f.Value)
+		case f.Name == otelpb.ServiceGraphChildFieldName:
+			edge.Child = strings.Clone(f.Value)
+		case f.Name == otelpb.ServiceGraphCallCountFieldName:
+			edge.CallCount = parseSumValue(f.Value)
+		case f.Name == otelpb.ServiceGraphFailedCountFieldName:
+			edge.FailedCount = parseSumValue(f.Value)
+		case f.Name == otelpb.ServiceGraphClientFailedCountFieldName:
+			edge.Client.ErrorCount = parseSumValue(f.Value)
+		case f.Name == otelpb.ServiceGraphServerFailedCountFieldName:
+			edge.Server.ErrorCount = parseSumValue(f.Value)
+		case f.Name == otelpb.ServiceGraphServerCallCountFieldName:
+			serverHistogramCount = parseSumValue(f.Value)
+		case f.Name == "vt_client_histogram_count":
+			clientHistogramCount = parseSumValue(f.Value)
+		case strings.HasPrefix(f.Name, otelpb.ServiceGraphClientLatencyBucketFieldPrefix):
+			if idx := bucketIdx(strings.TrimPrefix(f.Name, otelpb.ServiceGraphClientLatencyBucketFieldPrefix)); idx >= 0 {
+				clientBuckets[idx] = parseSumValue(f.Value)
+			}
+		case strings.HasPrefix(f.Name, otelpb.ServiceGraphServerLatencyBucketFieldPrefix):
+			if idx := bucketIdx(strings.TrimPrefix(f.Name, otelpb.ServiceGraphServerLatencyBucketFieldPrefix)); idx >= 0 {
+				serverBuckets[idx] = parseSumValue(f.Value)
+			}
+		}
+	}
+
+	edge.Client.setPercentiles(clientBuckets, clientHistogramCount)
+	edge.Server.setPercentiles(serverBuckets, serverHistogramCount)
+	return edge
+}
+
+func (s *ServiceGraphEdgeStats) setPercentiles(buckets []uint64, count uint64) {
+	s.P50 = estimateLatencyQuantile(0.5, buckets, count)
+	s.P90 = estimateLatencyQuantile(0.9, buckets, count)
+	s.P99 = estimateLatencyQuantile(0.99, buckets, count)
+}
+
+// estimateLatencyQuantile estimates the phi-quantile of the latency from the cumulative histogram buckets with ServiceGraphQuantileBuckets upper bounds
+// and the total number of observations count in the same way as histogram_quantile() function in Prometheus,
+// e.g. the quantile is linearly interpolated inside the bucket, where it falls into.
+//
+// The upper bound of the last bucket is returned if the quantile exceeds it.
+func estimateLatencyQuantile(phi float64, buckets []uint64, count uint64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	rank := phi * float64(count)
+	lowerBound, lowerCount := 0.0, 0.0
+	for i, b := range ServiceGraphQuantileBuckets {
+		n := float64(buckets[i])
+		if n >= rank && n > lowerCount {
+			secs := lowerBound + (b-lowerBound)*(rank-lowerCount)/(n-lowerCount)
+			return time.Duration(math.Round(secs * 1e9))
+		}
+		lowerBound, lowerCount = b, n
+	}
+	return time.Duration(math.Round(lowerBound * 1e9))
 }
 
+// parseSumValue parses the result of `sum` stats function as an unsigned integer.
+//
+// Zero is returned for `NaN`, which is returned when the summed field is missing in all the rows.
+func parseSumValue(s string) uint64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || f < 0 {
+		return 0
+	}
+	return uint64(f)
+}
+
+// ServiceGraphLatencyBuckets contains the upper bounds in seconds of the latency histogram buckets for service graph relations.
+//
+// They are the same as the default buckets of Tempo service graph metrics.
+var ServiceGraphLatencyBuckets = []float64{0.1, 0.2, 0.4, 0.8, 1.6, 3.2, 6.4, 12.8}
+
+// ServiceGraphQuantileBuckets contains the upper bounds in seconds of the latency histogram buckets,
+// which are used for estimating latency percentiles of service graph relations.
+var ServiceGraphQuantileBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
 // GetServiceGraphTimeRange is an internal function used by service graph background task.
-// It calculates the service graph relation within the time range in (parent, child, callCount) format for specific tenant.
-func GetServiceGraphTimeRange(ctx context.Context, tenantID logstorage.TenantID, startTime, endTime time.Time, limit uint64) ([][]logstorage.Field, error) {
+// It calculates the service graph relation within the time range in (parent, child, callCount, failedCounts, latency histograms) format
+// for specific tenant. The failed counts and the latency histograms are calculated from both client (parent) and server (child) spans.
+// See ServiceGraphLatencyBuckets and ServiceGraphQuantileBuckets.
+//
+// The client and producer spans without the matching server or consumer span are used for generating relations with virtual child nodes,
+// e.g. databases, caches and third-party APIs. The name of the virtual node is the value of the first non-empty span attribute from peerAttributes.
+// The server side stats are empty for such relations. Virtual nodes aren't generated if peerAttributes is empty.
+func GetServiceGraphTimeRange(ctx context.Context, tenantID logstorage.TenantID, startTime, endTime time.Time, limit uint64, peerAttributes []string) ([][]logstorage.Field, error) {
 	cp := &CommonParams{
 		TenantIDs: []logstorage.TenantID{tenantID},
 	}
 
-	// (NOT parent_span_id:"") AND (kind:~"2|5")  | fields parent_span_id, resource_attr:service.name | rename parent_span_id as span_id, resource_attr:service.name as child
+	// (NOT parent_span_id:"") AND (kind:~"2|5")  | fields parent_span_id, resource_attr:service.name, duration, status_code
+	//   | rename parent_span_id as span_id, resource_attr:service.name as child, duration as vt_server_duration, status_code as vt_server_status_code
 	qStrChildSpans := fmt.Sprintf(
-		`(NOT %s:"") AND (%s:~"%d|%d")  | fields %s, %s | rename %s as %s, %s as %s`,
+		`(NOT %s:"") AND (%s:~"%d|%d")  | fields %s, %s, %s, %s | rename %s as %s, %s as %s, %s as vt_server_duration, %s as vt_server_status_code`,
 		otelpb.ParentSpanIDField, // parent span id not empty means this span is a child span.
 		otelpb.KindField,         // only server(2) and consumer(5) span could be used as a child. It helps reduce the spans it needs to fetch.
 		otelpb.SpanKind(2),
 		otelpb.SpanKind(5),
 		otelpb.ParentSpanIDField,
 		otelpb.ResourceAttrServiceName,
+		otelpb.DurationField,
+		otelpb.StatusCodeField,
 		otelpb.ParentSpanIDField,
 		otelpb.SpanIDField,
 		otelpb.ResourceAttrServiceName,
 		otelpb.ServiceGraphChildFieldName,
+		otelpb.DurationField,
+		otelpb.StatusCodeField,
 	)
-	// (NOT span_id:"") AND (kind:~"3|4")  | fields span_id, resource_attr:service.name | rename resource_attr:service.name as parent
+	// (NOT span_id:"") AND (kind:~"3|4") | fields span_id, resource_attr:service.name, duration, status_code, span_attr:peer.service, ...
+	//   | rename resource_attr:service.name as parent, duration as vt_client_duration, status_code as vt_client_status_code, span_attr:peer.service as vt_peer_0, ...
+	//
+	// The peer attributes are renamed, since `format` pipe cannot refer to the fields with `:` in placeholders.
+	peerFields := ""
+	peerRenames := ""
+	for i, attr := range peerAttributes {
+		peerFields += fmt.Sprintf(", %q", otelpb.SpanAttrPrefixField+attr)
+		peerRenames += fmt.Sprintf(", %q as vt_peer_%d", otelpb.SpanAttrPrefixField+attr, i)
+	}
 	qStrParentSpans := fmt.Sprintf(
-		`(NOT %s:"") AND (%s:~"%d|%d") | fields %s, %s | rename %s as %s`,
+		`(NOT %s:"") AND (%s:~"%d|%d") | fields %s, %s, %s, %s%s | rename %s as %s, %s as vt_client_duration, %s as vt_client_status_code%s`,
 		otelpb.SpanIDField, // Any span could be a parent span, as long as it has a span ID.
 		otelpb.KindField,   // only client(3) and producer(4) span could be used as a parent. It helps reduce the spans it needs to fetch.
 		otelpb.SpanKind(3),
 		otelpb.SpanKind(4),
 		otelpb.SpanIDField,
 		otelpb.ResourceAttrServiceName,
+		otelpb.DurationField,
+		otelpb.StatusCodeField,
+		peerFields,
 		otelpb.ResourceAttrServiceName,
 		otelpb.ServiceGraphParentFieldName,
+		otelpb.DurationField,
+		otelpb.StatusCodeField,
+		peerRenames,
+	)
+	// the call is failed if either the client span or the server span has the error status.
+	statsFuncs := fmt.Sprintf(
+		`count() %s, count() if (vt_server_duration:*) %s, count() if (vt_client_status_code:=2 OR vt_server_status_code:=2) %s, `+
+			`count() if (vt_client_status_code:=2) %s, count() if (vt_server_status_code:=2) %s, sum(vt_client_duration) %s, sum(vt_server_duration) %s`,
+		otelpb.ServiceGraphCallCountFieldName,
+		otelpb.ServiceGraphServerCallCountFieldName,
+		otelpb.ServiceGraphFailedCountFieldName,
+		otelpb.ServiceGraphClientFailedCountFieldName,
+		otelpb.ServiceGraphServerFailedCountFieldName,
+		otelpb.ServiceGraphClientSecondsSumFieldName,
+		otelpb.ServiceGraphServerSecondsSumFieldName,
 	)
-	// join by span_id
+	for _, b := range ServiceGraphLatencyBuckets {
+		le := strconv.FormatFloat(b, 'f', -1, 64)
+		// duration is stored in nanoseconds.
+		nsecs := int64(b * 1e9)
+		statsFuncs += fmt.Sprintf(`, count() if (vt_client_duration:<=%d) %q, count() if (vt_server_duration:<=%d) %q`,
+			nsecs, otelpb.ServiceGraphClientSecondsBucketFieldPrefix+le, nsecs, otelpb.ServiceGraphServerSecondsBucketFieldPrefix+le)
+	}
+	for _, b := range ServiceGraphQuantileBuckets {
+		le := strconv.FormatFloat(b, 'f', -1, 64)
+		nsecs := int64(b * 1e9)
+		statsFuncs += fmt.Sprintf(`, count() if (vt_client_duration:<=%d) %q, count() if (vt_server_duration:<=%d) %q`,
+			nsecs, otelpb.ServiceGraphClientLatencyBucketFieldPrefix+le, nsecs, otelpb.ServiceGraphServerLatencyBucketFieldPrefix+le)
+	}
+
+	// join by span_id. The parent spans without the child span are kept by the join and get the virtual child node name
+	// from the peer attributes, while the rest of them are dropped by `child:*` filter.
+	joinMode := ""
+	if len(peerAttributes) == 0 {
+		joinMode = " inner"
+	}
+	virtualNodes := ""
+	for i := range peerAttributes {
+		virtualNodes += fmt.Sprintf(`| format if (%s:"") "<vt_peer_%d>" as %s `, otelpb.ServiceGraphChildFieldName, i, otelpb.ServiceGraphChildFieldName)
+	}
 	qStr := fmt.Sprintf(
-		`%s | join by (%s) (%s) inner | NOT %s:eq_field(%s) | stats by (%s, %s) count() %s`,
-		qStrChildSpans,
-		otelpb.SpanIDField,
+		`%s | join by (%s) (%s)%s %s| %s:* | NOT %s:eq_field(%s) | stats by (%s, %s) %s | math %s / 1e9 as %s, %s / 1e9 as %s`,
 		qStrParentSpans,
+		otelpb.SpanIDField,
+		qStrChildSpans,
+		joinMode,
+		virtualNodes,
+		otelpb.ServiceGraphChildFieldName,
 		otelpb.ServiceGraphParentFieldName,
 		otelpb.ServiceGraphChildFieldName,
 		otelpb.ServiceGraphParentFieldName,
 		otelpb.ServiceGraphChildFieldName,
-		otelpb.ServiceGraphCallCountFieldName,
+		statsFuncs,
+		otelpb.ServiceGraphClientSecondsSumFieldName,
+		otelpb.ServiceGraphClientSecondsSumFieldName,
+		otelpb.ServiceGraphServerSecondsSumFieldName,
+		otelpb.ServiceGraphServerSecondsSumFieldName,
 	)
 
 	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime.UnixNano())
@@ -695,6 +1431,10 @@ func GetServiceGraphTimeRange(ctx context.Context, tenantID logstorage.TenantID,
 		for i := 0; i < valuesCount; i++ {
 			fields := make([]logstorage.Field, 0, len(columns))
 			for j := range clonedColumnNames {
+				if columns[j].Values[i] == "NaN" {
+					// `sum` returns NaN for the relations without the server spans. Such fields are omitted.
+					continue
+				}
 				fields = append(
 					fields,
 					logstorage.Field{
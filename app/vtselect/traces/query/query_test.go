@@ -1,7 +1,13 @@
 package query
 
 import (
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
 )
 
 func TestCheckTraceIDList(t *testing.T) {
@@ -21,3 +27,226 @@ func TestCheckTraceIDList(t *testing.T) {
 	f("abcd bcad", false)
 	f("abcd\"", false)
 }
+
+func TestUniqTraceIDList(t *testing.T) {
+	f := func(traceIDList, resultExpected []string) {
+		t.Helper()
+
+		result := uniqTraceIDList(traceIDList)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+	f(nil, []string{})
+	f([]string{"a", "", "b"}, []string{"a", "b"})
+	f([]string{"b", "a", "b", "c", "a"}, []string{"b", "a", "c"})
+}
+
+func TestGroupTraceIDsByTimeWindow(t *testing.T) {
+	f := func(traceTimestamps map[string]time.Time, window time.Duration, groupsExpected []*traceIDGroup) {
+		t.Helper()
+
+		groups := groupTraceIDsByTimeWindow(traceTimestamps, window)
+		if !reflect.DeepEqual(groups, groupsExpected) {
+			t.Fatalf("unexpected groups; got %v; want %v", groups, groupsExpected)
+		}
+	}
+
+	ts := time.Unix(1000, 0)
+
+	// empty
+	f(nil, time.Second, nil)
+
+	// single trace
+	f(map[string]time.Time{
+		"a": ts,
+	}, time.Second, []*traceIDGroup{
+		{traceIDs: []string{"a"}, startTime: ts.Add(-time.Second), endTime: ts.Add(time.Second)},
+	})
+
+	// overlapping time windows
+	f(map[string]time.Time{
+		"c": ts.Add(2 * time.Second),
+		"a": ts,
+		"b": ts.Add(time.Second),
+	}, time.Second, []*traceIDGroup{
+		{traceIDs: []string{"a", "b", "c"}, startTime: ts.Add(-time.Second), endTime: ts.Add(3 * time.Second)},
+	})
+
+	// traces far away from each other
+	f(map[string]time.Time{
+		"a": ts,
+		"b": ts.Add(time.Hour),
+		"c": ts.Add(time.Hour + time.Second),
+	}, time.Second, []*traceIDGroup{
+		{traceIDs: []string{"a"}, startTime: ts.Add(-time.Second), endTime: ts.Add(time.Second)},
+		{traceIDs: []string{"b", "c"}, startTime: ts.Add(time.Hour - time.Second), endTime: ts.Add(time.Hour + 2*time.Second)},
+	})
+}
+
+func TestEncodeDecodeOperations(t *testing.T) {
+	f := func(name, kind string) {
+		t.Helper()
+
+		result := decodeOperations([]string{encodeOperation(name, kind)})
+		resultExpected := []*Operation{{Name: name, Kind: kind}}
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %+v; want %+v", result[0], resultExpected[0])
+		}
+	}
+	f("", "")
+	f("GET /", "2")
+	f("db:query", "3")
+	f("a:b:c", "")
+}
+
+func TestAppendSpanLinks(t *testing.T) {
+	f := func(fields []logstorage.Field, resultExpected []TraceLink) {
+		t.Helper()
+
+		result := appendSpanLinks(nil, fields)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %+v; want %+v", result, resultExpected)
+		}
+	}
+
+	// no links
+	f([]logstorage.Field{
+		{Name: otelpb.SpanIDField, Value: "a"},
+	}, nil)
+
+	// multiple links
+	f([]logstorage.Field{
+		{Name: otelpb.SpanIDField, Value: "a"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":0", Value: "t1"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkSpanIDField + ":0", Value: "s1"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkAttrPrefix + "foo:0", Value: "bar"},
+		{Name: otelpb.LinkPrefix + otelpb.LinkTraceIDField + ":1", Value: "t2"},
+	}, []TraceLink{
+		{SpanID: "a", LinkedTraceID: "t1", LinkedSpanID: "s1"},
+		{SpanID: "a", LinkedTraceID: "t2"},
+	})
+}
+
+func TestEstimateLatencyQuantile(t *testing.T) {
+	f := func(phi float64, buckets map[float64]uint64, count uint64, resultExpected time.Duration) {
+		t.Helper()
+
+		// convert non-cumulative buckets to cumulative buckets with ServiceGraphQuantileBuckets upper bounds
+		cumulative := make([]uint64, len(ServiceGraphQuantileBuckets))
+		var n uint64
+		for i, b := range ServiceGraphQuantileBuckets {
+			n += buckets[b]
+			cumulative[i] = n
+		}
+		result := estimateLatencyQuantile(phi, cumulative, count)
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %s; want %s", result, resultExpected)
+		}
+	}
+
+	// no calls
+	f(0.5, nil, 0, 0)
+
+	// all the calls in the first bucket
+	f(0.5, map[float64]uint64{0.001: 100}, 100, 500*time.Microsecond)
+	f(0.99, map[float64]uint64{0.001: 100}, 100, 990*time.Microsecond)
+
+	// the quantile is interpolated inside the bucket, where it falls into
+	f(0.5, map[float64]uint64{0.01: 50, 0.1: 50}, 100, 10*time.Millisecond)
+	f(0.9, map[float64]uint64{0.01: 50, 0.1: 50}, 100, 90*time.Millisecond)
+	f(0.99, map[float64]uint64{0.01: 50, 0.1: 50}, 100, 99*time.Millisecond)
+
+	// the upper bound of the last bucket is returned for the calls exceeding it
+	f(0.5, map[float64]uint64{0.01: 10}, 100, time.Minute)
+	f(0.05, map[float64]uint64{0.01: 10}, 100, 7500*time.Microsecond)
+}
+
+func TestNewServiceGraphEdge(t *testing.T) {
+	f := func(fields []logstorage.Field, resultExpected *ServiceGraphEdge) {
+		t.Helper()
+
+		result := newServiceGraphEdge(fields)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result\ngot\n%+v\nwant\n%+v", result, resultExpected)
+		}
+	}
+
+	// relations without error counts and histograms
+	f([]logstorage.Field{
+		{Name: "parent", Value: "frontend"},
+		{Name: "child", Value: "checkout"},
+		{Name: "callCount", Value: "10"},
+		{Name: "failedCount", Value: "NaN"},
+		{Name: "clientFailedCount", Value: "NaN"},
+		{Name: "serverFailedCount", Value: "NaN"},
+		{Name: "clientLatencyBucket:0.001", Value: "NaN"},
+		{Name: "serverCallCount", Value: "NaN"},
+		{Name: "vt_client_histogram_count", Value: "NaN"},
+	}, &ServiceGraphEdge{
+		Parent:    "frontend",
+		Child:     "checkout",
+		CallCount: 10,
+	})
+
+	// relations with error counts and histograms
+	f([]logstorage.Field{
+		{Name: "parent", Value: "frontend"},
+		{Name: "child", Value: "checkout"},
+		{Name: "callCount", Value: "12"},
+		{Name: "failedCount", Value: "3"},
+		{Name: "clientFailedCount", Value: "3"},
+		{Name: "serverFailedCount", Value: "1"},
+		{Name: "clientLatencyBucket:0.005", Value: "5"},
+		{Name: "clientLatencyBucket:0.01", Value: "10"},
+		{Name: "clientLatencyBucket:0.025", Value: "10"},
+		{Name: "serverLatencyBucket:0.0025", Value: "10"},
+		{Name: "serverLatencyBucket:0.005", Value: "10"},
+		{Name: "serverLatencyBucket:0.01", Value: "10"},
+		{Name: "serverLatencyBucket:0.025", Value: "10"},
+		{Name: "serverCallCount", Value: "10"},
+		{Name: "vt_client_histogram_count", Value: "10"},
+	}, &ServiceGraphEdge{
+		Parent:      "frontend",
+		Child:       "checkout",
+		CallCount:   12,
+		FailedCount: 3,
+		Client: ServiceGraphEdgeStats{
+			ErrorCount: 3,
+			P50:        5 * time.Millisecond,
+			P90:        9 * time.Millisecond,
+			P99:        9900 * time.Microsecond,
+		},
+		Server: ServiceGraphEdgeStats{
+			ErrorCount: 1,
+			P50:        1750 * time.Microsecond,
+			P90:        2350 * time.Microsecond,
+			P99:        2485 * time.Microsecond,
+		},
+	})
+
+	// relations with virtual child node
+	f([]logstorage.Field{
+		{Name: "parent", Value: "checkout"},
+		{Name: "child", Value: "postgresql"},
+		{Name: "callCount", Value: "10"},
+		{Name: "serverCallCount", Value: "0"},
+		{Name: "failedCount", Value: "1"},
+		{Name: "clientFailedCount", Value: "1"},
+		{Name: "serverFailedCount", Value: "0"},
+		{Name: "clientLatencyBucket:0.001", Value: "10"},
+		{Name: "serverLatencyBucket:0.001", Value: "0"},
+		{Name: "vt_client_histogram_count", Value: "10"},
+	}, &ServiceGraphEdge{
+		Parent:      "checkout",
+		Child:       "postgresql",
+		CallCount:   10,
+		FailedCount: 1,
+		Client: ServiceGraphEdgeStats{
+			ErrorCount: 1,
+			P50:        500 * time.Microsecond,
+			P90:        900 * time.Microsecond,
+			P99:        990 * time.Microsecond,
+		},
+	})
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	vtstoragecommon "github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage/common"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// GetSpanBySpanID returns the trace ID and the span with the given spanID.
+//
+// It searches the span-id-idx stream by -search.traceSearchStep for the trace ID and the timestamp of the span,
+// and then fetches the span at this timestamp. The stream is written only if -insert.indexSpanID is set during the ingestion.
+//
+// Empty trace ID and nil span are returned if the spanID cannot be found within the retention period.
+// Note that the lookup of a missing spanID walks -search.traceSearchStep windows from now back to the retention boundary,
+// so it costs a query per every step.
+func GetSpanBySpanID(ctx context.Context, cp *CommonParams, spanID string) (string, *Row, error) {
+	// query: {span_id_idx_stream="xx"} AND span_id_idx:=spanID | fields _time, span_id_idx_trace_id
+	qStr := fmt.Sprintf(`{%s="%d"} AND %s:=%q | fields _time, %s`, otelpb.SpanIDIndexStreamName, xxhash.Sum64String(spanID)%otelpb.SpanIDIndexPartitionCount,
+		otelpb.SpanIDIndexFieldName, spanID, otelpb.SpanIDIndexTraceIDFieldName)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, time.Now().UnixNano())
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot unmarshal query=%q: %w", qStr, err)
+	}
+	q.AddPipeOffsetLimit(0, 1)
+
+	traceID, timestamp, err := findSpanIDIndexSplitTimeRange(ctx, q, cp)
+	if err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("cannot find span_id %q in the index: %s", spanID, err)
+	}
+
+	row, err := findSpanByIDAndTime(ctx, cp, traceID, spanID, timestamp)
+	if err != nil {
+		return "", nil, err
+	}
+	if row == nil {
+		// the span has been deleted, while its index entry is kept.
+		return "", nil, nil
+	}
+	return traceID, row, nil
+}
+
+// findSpanIDIndexSplitTimeRange runs q over the span-id-idx stream by -search.traceSearchStep from now to the past,
+// and returns the trace ID and the timestamp of the first found entry.
+//
+// ErrOutOfRetention is returned if nothing is found within the retention period.
+func findSpanIDIndexSplitTimeRange(ctx context.Context, q *logstorage.Query, cp *CommonParams) (string, int64, error) {
+	var mu sync.Mutex
+	var traceID string
+	var timestamp int64
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		timestamps, ok := db.GetTimestamps(nil)
+		if !ok || len(timestamps) == 0 {
+			return
+		}
+		c := db.GetColumnByName(otelpb.SpanIDIndexTraceIDFieldName)
+		if c == nil || len(c.Values) == 0 || c.Values[0] == "" {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if traceID == "" {
+			traceID = strings.Clone(c.Values[0])
+			timestamp = timestamps[0]
+		}
+	}
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	currentTime := time.Now()
+	startTime := currentTime.Add(-*traceSearchStep)
+	endTime := currentTime
+	for startTime.UnixNano() > 0 {
+		qq := q.CloneWithTimeFilter(currentTime.UnixNano(), startTime.UnixNano(), endTime.UnixNano())
+		qctx = qctx.WithQuery(qq)
+
+		if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+			// this could be either a ErrOutOfRetention, or a real error.
+			return "", 0, err
+		}
+		if traceID != "" {
+			return traceID, timestamp, nil
+		}
+
+		endTime = startTime
+		startTime = startTime.Add(-*traceSearchStep)
+	}
+	return "", 0, vtstoragecommon.ErrOutOfRetention
+}
+
+// findSpanByIDAndTime returns the span with the given traceID and spanID at the given timestamp.
+//
+// nil is returned if the span isn't found.
+func findSpanByIDAndTime(ctx context.Context, cp *CommonParams, traceID, spanID string, timestamp int64) (*Row, error) {
+	// query: trace_id:=traceID AND span_id:=spanID
+	qStr := fmt.Sprintf("%s:=%q AND %s:=%q", otelpb.TraceIDField, traceID, otelpb.SpanIDField, spanID)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(timestamp, timestamp)
+	q.AddPipeOffsetLimit(0, 1)
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var mu sync.Mutex
+	var row *Row
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		timestamps, ok := db.GetTimestamps(nil)
+		if !ok || len(timestamps) == 0 {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if row != nil {
+			return
+		}
+		row = &Row{
+			Timestamp: timestamps[0],
+		}
+		for _, c := range db.Columns {
+			// column could be empty if this span does not contain such field.
+			if v := c.Values[0]; v != "" {
+				row.Fields = append(row.Fields, logstorage.Field{Name: strings.Clone(c.Name), Value: strings.Clone(v)})
+			}
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return row, nil
+}
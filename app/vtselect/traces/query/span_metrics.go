@@ -0,0 +1,295 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// maxSpanMetricsPoints is the maximum number of points per series, which could be requested with lookback and step.
+const maxSpanMetricsPoints = 11000
+
+// SpanMetricsQueryParam is the parameters for calculating RED metrics (rate, errors, duration) from spans.
+type SpanMetricsQueryParam struct {
+	ServiceNames []string
+	// SpanKinds contains the OpenTelemetry span kinds in stored format (e.g. `2` for server spans). All span kinds are used if empty.
+	SpanKinds        []string
+	GroupByOperation bool
+	EndTime          time.Time
+	Lookback         time.Duration
+	Step             time.Duration
+	// RatePer is the window for calculating call rate and error rate.
+	RatePer time.Duration
+}
+
+// SpanMetricsSeries is the time series of the metric for a service or a (service, operation) pair.
+type SpanMetricsSeries struct {
+	ServiceName string
+	// Operation is empty if SpanMetricsQueryParam.GroupByOperation is false.
+	Operation string
+	Points    []SpanMetricsPoint
+}
+
+// SpanMetricsPoint is a point of SpanMetricsSeries.
+type SpanMetricsPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+type spanMetricsSeriesKey struct {
+	serviceName string
+	operation   string
+}
+
+// spanMetricsBuckets contains the values of stats funcs for every step bucket of every series.
+//
+// series key -> bucket start time in nanoseconds -> stats func result name -> value.
+type spanMetricsBuckets map[spanMetricsSeriesKey]map[int64]map[string]float64
+
+// GetSpanCallRates returns the calls per second for every series.
+//
+// The rate at every point is calculated over the last param.RatePer window.
+func GetSpanCallRates(ctx context.Context, cp *CommonParams, param *SpanMetricsQueryParam) ([]*SpanMetricsSeries, error) {
+	buckets, err := getSpanMetricsBuckets(ctx, cp, param, "count() as calls", param.rateWindowBuckets())
+	if err != nil {
+		return nil, err
+	}
+	return param.rateSeries(buckets, func(sum map[string]float64, windowSeconds float64) (float64, bool) {
+		return sum["calls"] / windowSeconds, true
+	}), nil
+}
+
+// GetSpanErrorRates returns the ratio of calls with error status to all the calls for every series.
+//
+// The ratio at every point is calculated over the last param.RatePer window. Points without calls are omitted.
+func GetSpanErrorRates(ctx context.Context, cp *CommonParams, param *SpanMetricsQueryParam) ([]*SpanMetricsSeries, error) {
+	statsFuncs := fmt.Sprintf("count() as calls, count() if (%s:=2) as errors", otelpb.StatusCodeField)
+	buckets, err := getSpanMetricsBuckets(ctx, cp, param, statsFuncs, param.rateWindowBuckets())
+	if err != nil {
+		return nil, err
+	}
+	return param.rateSeries(buckets, func(sum map[string]float64, _ float64) (float64, bool) {
+		if sum["calls"] <= 0 {
+			return 0, false
+		}
+		return sum["errors"] / sum["calls"], true
+	}), nil
+}
+
+// GetSpanLatencies returns the given quantile of span duration in milliseconds for every series.
+//
+// Unlike call rate and error rate, the quantile is calculated over the spans of every step, since quantiles cannot be merged.
+// Points without spans are omitted.
+func GetSpanLatencies(ctx context.Context, cp *CommonParams, param *SpanMetricsQueryParam, quantile float64) ([]*SpanMetricsSeries, error) {
+	statsFuncs := fmt.Sprintf("quantile(%s, %s) as latency", strconv.FormatFloat(quantile, 'f', -1, 64), otelpb.DurationField)
+	buckets, err := getSpanMetricsBuckets(ctx, cp, param, statsFuncs, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*SpanMetricsSeries, 0, len(buckets))
+	for k, seriesBuckets := range buckets {
+		s := &SpanMetricsSeries{
+			ServiceName: k.serviceName,
+			Operation:   k.operation,
+		}
+		for _, bucketStart := range param.pointBuckets() {
+			values, ok := seriesBuckets[bucketStart]
+			if !ok {
+				continue
+			}
+			s.Points = append(s.Points, SpanMetricsPoint{
+				Timestamp: time.Unix(0, bucketStart+param.Step.Nanoseconds()),
+				// duration is stored in nanoseconds.
+				Value: values["latency"] / 1e6,
+			})
+		}
+		result = append(result, s)
+	}
+	sortSpanMetricsSeries(result)
+	return result, nil
+}
+
+// Validate verifies that the params could be used for calculating metrics.
+func (p *SpanMetricsQueryParam) Validate() error {
+	if len(p.ServiceNames) == 0 {
+		return fmt.Errorf("at least one service name must be provided")
+	}
+	if p.Step <= 0 {
+		return fmt.Errorf("step must be bigger than zero")
+	}
+	if p.Lookback <= 0 {
+		return fmt.Errorf("lookback must be bigger than zero")
+	}
+	if p.RatePer <= 0 {
+		return fmt.Errorf("ratePer must be bigger than zero")
+	}
+	if p.Lookback/p.Step > maxSpanMetricsPoints {
+		return fmt.Errorf("too many points requested with lookback=%s and step=%s; the maximum number of points is %d", p.Lookback, p.Step, maxSpanMetricsPoints)
+	}
+	return nil
+}
+
+// pointBuckets returns the start time of step buckets in nanoseconds, for which the points should be returned.
+func (p *SpanMetricsQueryParam) pointBuckets() []int64 {
+	step := p.Step.Nanoseconds()
+	start := p.EndTime.Add(-p.Lookback).UnixNano()
+	end := p.EndTime.UnixNano()
+	var result []int64
+	for t := start - start%step; t < end; t += step {
+		result = append(result, t)
+	}
+	return result
+}
+
+// rateWindowBuckets returns the number of step buckets in the RatePer window.
+func (p *SpanMetricsQueryParam) rateWindowBuckets() int {
+	n := int((p.RatePer + p.Step - 1) / p.Step)
+	return max(n, 1)
+}
+
+// rateSeries calculates the series from the sum of bucket values over the rate window. The point is omitted if f returns false.
+func (p *SpanMetricsQueryParam) rateSeries(buckets spanMetricsBuckets, f func(sum map[string]float64, windowSeconds float64) (float64, bool)) []*SpanMetricsSeries {
+	windowBuckets := p.rateWindowBuckets()
+	step := p.Step.Nanoseconds()
+	windowSeconds := float64(windowBuckets) * p.Step.Seconds()
+
+	result := make([]*SpanMetricsSeries, 0, len(buckets))
+	for k, seriesBuckets := range buckets {
+		s := &SpanMetricsSeries{
+			ServiceName: k.serviceName,
+			Operation:   k.operation,
+		}
+		for _, bucketStart := range p.pointBuckets() {
+			sum := make(map[string]float64)
+			for i := 0; i < windowBuckets; i++ {
+				for name, v := range seriesBuckets[bucketStart-int64(i)*step] {
+					sum[name] += v
+				}
+			}
+			v, ok := f(sum, windowSeconds)
+			if !ok {
+				continue
+			}
+			s.Points = append(s.Points, SpanMetricsPoint{
+				Timestamp: time.Unix(0, bucketStart+step),
+				Value:     v,
+			})
+		}
+		result = append(result, s)
+	}
+	sortSpanMetricsSeries(result)
+	return result
+}
+
+// getSpanMetricsBuckets executes the statsFuncs over the spans matching param for every step bucket.
+//
+// windowBuckets is the number of buckets in the window, which is used for calculating a point. The extra buckets
+// before the start of lookback are requested, so the points at the beginning have full windows.
+func getSpanMetricsBuckets(ctx context.Context, cp *CommonParams, param *SpanMetricsQueryParam, statsFuncs string, windowBuckets int) (spanMetricsBuckets, error) {
+	// query: _stream:{resource_attr:service.name in (...)} AND kind:in(...)
+	//   | stats by (resource_attr:service.name, name) <stats funcs>
+	quotedServiceNames := make([]string, len(param.ServiceNames))
+	for i, serviceName := range param.ServiceNames {
+		quotedServiceNames[i] = strconv.Quote(serviceName)
+	}
+	qStr := fmt.Sprintf("_stream:{%q in (%s)} ", otelpb.ResourceAttrServiceName, strings.Join(quotedServiceNames, ","))
+	if len(param.SpanKinds) > 0 {
+		quotedSpanKinds := make([]string, len(param.SpanKinds))
+		for i, kind := range param.SpanKinds {
+			quotedSpanKinds[i] = strconv.Quote(kind)
+		}
+		qStr += fmt.Sprintf("AND %s:in(%s) ", otelpb.KindField, strings.Join(quotedSpanKinds, ","))
+	}
+	byFields := strconv.Quote(otelpb.ResourceAttrServiceName)
+	if param.GroupByOperation {
+		byFields += ", " + otelpb.NameField
+	}
+	qStr += fmt.Sprintf("| stats by (%s) %s", byFields, statsFuncs)
+
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, param.EndTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	step := param.Step.Nanoseconds()
+	pointBuckets := param.pointBuckets()
+	startTime := pointBuckets[0] - int64(windowBuckets-1)*step
+	q.AddTimeFilter(startTime, param.EndTime.UnixNano())
+
+	labelFields, err := q.GetStatsLabelsAddGroupingByTime(step)
+	if err != nil {
+		return nil, fmt.Errorf("cannot add grouping by time to query [%s]: %s", q, err)
+	}
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var bucketsLock sync.Mutex
+	buckets := make(spanMetricsBuckets)
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		columns := db.Columns
+		for i := 0; i < db.RowsCount(); i++ {
+			var k spanMetricsSeriesKey
+			var bucketStart int64
+			values := make(map[string]float64, len(columns))
+			for _, c := range columns {
+				v := c.Values[i]
+				switch {
+				case c.Name == "_time":
+					nsec, ok := logstorage.TryParseTimestampRFC3339Nano(v)
+					if !ok {
+						continue
+					}
+					bucketStart = nsec
+				case c.Name == otelpb.ResourceAttrServiceName:
+					k.serviceName = strings.Clone(v)
+				case c.Name == otelpb.NameField:
+					k.operation = strings.Clone(v)
+				case slices.Contains(labelFields, c.Name):
+					// other label fields are not expected.
+				default:
+					f, err := strconv.ParseFloat(v, 64)
+					if err != nil {
+						// quantile() returns empty value if there are no spans.
+						continue
+					}
+					values[strings.Clone(c.Name)] = f
+				}
+			}
+
+			bucketsLock.Lock()
+			seriesBuckets, ok := buckets[k]
+			if !ok {
+				seriesBuckets = make(map[int64]map[string]float64)
+				buckets[k] = seriesBuckets
+			}
+			seriesBuckets[bucketStart] = values
+			bucketsLock.Unlock()
+		}
+	}
+
+	if err = vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return buckets, nil
+}
+
+func sortSpanMetricsSeries(series []*SpanMetricsSeries) {
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].ServiceName != series[j].ServiceName {
+			return series[i].ServiceName < series[j].ServiceName
+		}
+		return series[i].Operation < series[j].Operation
+	})
+}
@@ -0,0 +1,80 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSpanMetricsQueryParamValidate(t *testing.T) {
+	f := func(p *SpanMetricsQueryParam, errExpected bool) {
+		t.Helper()
+
+		err := p.Validate()
+		if (err != nil) != errExpected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	f(&SpanMetricsQueryParam{ServiceNames: []string{"a"}, Lookback: time.Hour, Step: time.Minute, RatePer: time.Minute}, false)
+	f(&SpanMetricsQueryParam{Lookback: time.Hour, Step: time.Minute, RatePer: time.Minute}, true)
+	f(&SpanMetricsQueryParam{ServiceNames: []string{"a"}, Lookback: time.Hour, RatePer: time.Minute}, true)
+	f(&SpanMetricsQueryParam{ServiceNames: []string{"a"}, Lookback: 24 * time.Hour, Step: time.Second, RatePer: time.Minute}, true)
+}
+
+func TestSpanMetricsRateSeries(t *testing.T) {
+	p := &SpanMetricsQueryParam{
+		EndTime:  time.Unix(300, 0),
+		Lookback: 3 * time.Minute,
+		Step:     time.Minute,
+		RatePer:  90 * time.Second,
+	}
+
+	pointBuckets := p.pointBuckets()
+	pointBucketsExpected := []int64{120e9, 180e9, 240e9}
+	if !reflect.DeepEqual(pointBuckets, pointBucketsExpected) {
+		t.Fatalf("unexpected point buckets; got %v; want %v", pointBuckets, pointBucketsExpected)
+	}
+	if n := p.rateWindowBuckets(); n != 2 {
+		t.Fatalf("unexpected rate window buckets; got %d; want 2", n)
+	}
+
+	k := spanMetricsSeriesKey{serviceName: "frontend"}
+	buckets := spanMetricsBuckets{
+		k: {
+			60e9:  {"calls": 60, "errors": 6},
+			180e9: {"calls": 120},
+		},
+	}
+
+	f := func(series []*SpanMetricsSeries, pointsExpected []SpanMetricsPoint) {
+		t.Helper()
+
+		if len(series) != 1 || series[0].ServiceName != "frontend" {
+			t.Fatalf("unexpected series: %v", series)
+		}
+		if !reflect.DeepEqual(series[0].Points, pointsExpected) {
+			t.Fatalf("unexpected points; got %v; want %v", series[0].Points, pointsExpected)
+		}
+	}
+
+	// calls per second over 2 buckets window
+	f(p.rateSeries(buckets, func(sum map[string]float64, windowSeconds float64) (float64, bool) {
+		return sum["calls"] / windowSeconds, true
+	}), []SpanMetricsPoint{
+		{Timestamp: time.Unix(180, 0), Value: 0.5},
+		{Timestamp: time.Unix(240, 0), Value: 1},
+		{Timestamp: time.Unix(300, 0), Value: 1},
+	})
+
+	// error ratio
+	f(p.rateSeries(buckets, func(sum map[string]float64, _ float64) (float64, bool) {
+		if sum["calls"] <= 0 {
+			return 0, false
+		}
+		return sum["errors"] / sum["calls"], true
+	}), []SpanMetricsPoint{
+		{Timestamp: time.Unix(180, 0), Value: 0.1},
+		{Timestamp: time.Unix(240, 0), Value: 0},
+		{Timestamp: time.Unix(300, 0), Value: 0},
+	})
+}
@@ -0,0 +1,341 @@
+package query
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+var traceStructuralMaxTimeRange = flag.Duration("search.traceStructuralMaxTimeRange", 24*time.Hour, "The maximum time range for searching traces "+
+	"by the relationship between spans. Such search collects the IDs of all the traces with the matching ancestor spans in the time range, "+
+	"so bigger time ranges are rejected. Zero means no limit")
+
+// SpanRelation is the structural relationship between the ancestor span and the descendant span.
+type SpanRelation int
+
+const (
+	// SpanRelationChild means the descendant span is a direct child of the ancestor span.
+	SpanRelationChild SpanRelation = iota
+	// SpanRelationDescendant means the descendant span is a child, grandchild, etc. of the ancestor span.
+	SpanRelationDescendant
+)
+
+// ParseSpanRelation parses s to SpanRelation.
+func ParseSpanRelation(s string) (SpanRelation, error) {
+	switch s {
+	case "child":
+		return SpanRelationChild, nil
+	case "descendant":
+		return SpanRelationDescendant, nil
+	default:
+		return 0, fmt.Errorf("unsupported relation %q; supported values: child, descendant", s)
+	}
+}
+
+// SpanRelationQueryParam is the parameters for searching traces by the relationship between spans.
+type SpanRelationQueryParam struct {
+	// AncestorFilter is the filter of the ancestor span.
+	AncestorFilter *AttributeFilter
+	// DescendantFilter is the filter of the descendant span.
+	DescendantFilter *AttributeFilter
+	Relation         SpanRelation
+	StartTimeMin     time.Time
+	StartTimeMax     time.Time
+	Limit            int
+}
+
+// SpanRelationMatch is a pair of spans matching the SpanRelationQueryParam.
+type SpanRelationMatch struct {
+	AncestorSpanID   string
+	DescendantSpanID string
+}
+
+// TraceSpanRelationMatches contains all the matched span pairs of a trace.
+type TraceSpanRelationMatches struct {
+	TraceID string
+	Matches []SpanRelationMatch
+}
+
+// internal fields for marking the spans matching the ancestor filter and the descendant filter.
+const (
+	isAncestorFieldName   = "vt_is_ancestor"
+	isDescendantFieldName = "vt_is_descendant"
+)
+
+// GetTracesBySpanRelation returns traces, in which some spans matching param.DescendantFilter are
+// children or descendants of spans matching param.AncestorFilter. The matched span pairs are returned as well.
+//
+// It's performed in 2 steps:
+//  1. search for candidate traces in [param.StartTimeMin, param.StartTimeMax] via `join by (trace_id, span_id)` for SpanRelationChild,
+//     or via `join by (trace_id)` for SpanRelationDescendant.
+//  2. fetch the span tree of candidate traces with the spans marked by filters, and evaluate the relationship.
+//
+// For SpanRelationDescendant, the candidate traces may not contain matched span pairs, so fewer than param.Limit traces could be returned.
+//
+// The IDs of all the traces with the matching ancestor spans are collected in the first step,
+// so the time range is limited by -search.traceStructuralMaxTimeRange.
+func GetTracesBySpanRelation(ctx context.Context, cp *CommonParams, param *SpanRelationQueryParam) ([]*TraceSpanRelationMatches, error) {
+	if err := checkSpanRelationQueryParam(param, *traceStructuralMaxTimeRange); err != nil {
+		return nil, err
+	}
+
+	traceIDs, minTime, maxTime, err := getSpanRelationCandidateTraceIDs(ctx, cp, param)
+	if err != nil {
+		return nil, fmt.Errorf("cannot search for candidate traces: %w", err)
+	}
+	if len(traceIDs) == 0 {
+		return nil, nil
+	}
+
+	// query: trace_id:in(...) | format if (<ancestor_filter>) "1" as vt_is_ancestor | format if (<descendant_filter>) "1" as vt_is_descendant
+	//   | fields trace_id, span_id, parent_span_id, vt_is_ancestor, vt_is_descendant
+	quotedTraceIDs := make([]string, len(traceIDs))
+	for i := range traceIDs {
+		quotedTraceIDs[i] = fmt.Sprintf("%q", traceIDs[i])
+	}
+	qStr := fmt.Sprintf(`%s:in(%s) | format if (%s) "1" as %s | format if (%s) "1" as %s | fields %s, %s, %s, %s, %s`,
+		otelpb.TraceIDField, strings.Join(quotedTraceIDs, ","),
+		param.AncestorFilter, isAncestorFieldName,
+		param.DescendantFilter, isDescendantFieldName,
+		otelpb.TraceIDField, otelpb.SpanIDField, otelpb.ParentSpanIDField, isAncestorFieldName, isDescendantFieldName,
+	)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, maxTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	// adjust start time and end time with max duration window to make sure all spans are included.
+	q.AddTimeFilter(minTime.Add(-*traceMaxDurationWindow).UnixNano(), maxTime.Add(*traceMaxDurationWindow).UnixNano())
+
+	rows, err := runQueryAndCollectRows(ctx, cp, q)
+	if err != nil {
+		return nil, err
+	}
+
+	spansByTraceID := make(map[string][]relationSpan, len(traceIDs))
+	for _, fields := range rows {
+		var traceID string
+		var sp relationSpan
+		for _, f := range fields {
+			switch f.Name {
+			case otelpb.TraceIDField:
+				traceID = f.Value
+			case otelpb.SpanIDField:
+				sp.spanID = f.Value
+			case otelpb.ParentSpanIDField:
+				sp.parentSpanID = f.Value
+			case isAncestorFieldName:
+				sp.isAncestor = f.Value != ""
+			case isDescendantFieldName:
+				sp.isDescendant = f.Value != ""
+			}
+		}
+		if traceID == "" || sp.spanID == "" {
+			continue
+		}
+		spansByTraceID[traceID] = append(spansByTraceID[traceID], sp)
+	}
+
+	result := make([]*TraceSpanRelationMatches, 0, len(traceIDs))
+	for _, traceID := range traceIDs {
+		matches := matchSpanRelation(spansByTraceID[traceID], param.Relation)
+		if len(matches) == 0 {
+			continue
+		}
+		result = append(result, &TraceSpanRelationMatches{
+			TraceID: traceID,
+			Matches: matches,
+		})
+	}
+	return result, nil
+}
+
+// checkSpanRelationQueryParam returns an error if the time range of param exceeds maxTimeRange.
+func checkSpanRelationQueryParam(param *SpanRelationQueryParam, maxTimeRange time.Duration) error {
+	if maxTimeRange > 0 && param.StartTimeMax.Sub(param.StartTimeMin) > maxTimeRange {
+		return fmt.Errorf("the time range [%s, %s] is too big for structural search; it mustn't exceed -search.traceStructuralMaxTimeRange=%s; "+
+			"narrow down the time range via start and end params",
+			param.StartTimeMin.UTC().Format(time.RFC3339), param.StartTimeMax.UTC().Format(time.RFC3339), maxTimeRange)
+	}
+	return nil
+}
+
+// getSpanRelationCandidateTraceIDs returns the latest trace IDs that may match param, and the time range of them.
+func getSpanRelationCandidateTraceIDs(ctx context.Context, cp *CommonParams, param *SpanRelationQueryParam) ([]string, time.Time, time.Time, error) {
+	var qStr string
+	switch param.Relation {
+	case SpanRelationChild:
+		// trace_id:* AND (<descendant_filter>) | fields _time, trace_id, parent_span_id | rename parent_span_id as span_id
+		//   | join by (trace_id, span_id) (trace_id:* AND (<ancestor_filter>) | fields trace_id, span_id) inner
+		//   | stats by (trace_id) max(_time) as _time | sort by (_time) desc
+		qStr = fmt.Sprintf(`%s:* AND (%s) | fields _time, %s, %s | rename %s as %s | join by (%s, %s) (%s:* AND (%s) | fields %s, %s) inner`,
+			otelpb.TraceIDField, param.DescendantFilter,
+			otelpb.TraceIDField, otelpb.ParentSpanIDField,
+			otelpb.ParentSpanIDField, otelpb.SpanIDField,
+			otelpb.TraceIDField, otelpb.SpanIDField,
+			otelpb.TraceIDField, param.AncestorFilter, otelpb.TraceIDField, otelpb.SpanIDField,
+		)
+	case SpanRelationDescendant:
+		// trace_id:* AND (<descendant_filter>) | fields _time, trace_id
+		//   | join by (trace_id) (trace_id:* AND (<ancestor_filter>) | uniq by (trace_id)) inner
+		//   | stats by (trace_id) max(_time) as _time | sort by (_time) desc
+		qStr = fmt.Sprintf(`%s:* AND (%s) | fields _time, %s | join by (%s) (%s:* AND (%s) | uniq by (%s)) inner`,
+			otelpb.TraceIDField, param.DescendantFilter,
+			otelpb.TraceIDField,
+			otelpb.TraceIDField,
+			otelpb.TraceIDField, param.AncestorFilter, otelpb.TraceIDField,
+		)
+	default:
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("unsupported relation %d", param.Relation)
+	}
+	qStr += fmt.Sprintf(` | stats by (%s) max(_time) as _time | sort by (_time) desc`, otelpb.TraceIDField)
+
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, param.StartTimeMax.UnixNano())
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(param.StartTimeMin.UnixNano(), param.StartTimeMax.UnixNano())
+	q.AddPipeOffsetLimit(0, uint64(param.Limit))
+
+	rows, err := runQueryAndCollectRows(ctx, cp, q)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+
+	type traceIDWithTime struct {
+		traceID   string
+		timestamp time.Time
+	}
+	traces := make([]traceIDWithTime, 0, len(rows))
+	for _, fields := range rows {
+		var t traceIDWithTime
+		for _, f := range fields {
+			switch f.Name {
+			case otelpb.TraceIDField:
+				t.traceID = f.Value
+			case "_time":
+				t.timestamp, err = time.Parse(time.RFC3339Nano, f.Value)
+				if err != nil {
+					return nil, time.Time{}, time.Time{}, fmt.Errorf("cannot parse _time [%s]: %w", f.Value, err)
+				}
+			}
+		}
+		if t.traceID != "" {
+			traces = append(traces, t)
+		}
+	}
+	if len(traces) == 0 {
+		return nil, time.Time{}, time.Time{}, nil
+	}
+
+	// the result of the query is sorted at vtselect. keep the order for the response.
+	sort.SliceStable(traces, func(i, j int) bool {
+		return traces[i].timestamp.After(traces[j].timestamp)
+	})
+	traceIDs := make([]string, len(traces))
+	minTime, maxTime := traces[0].timestamp, traces[0].timestamp
+	for i, t := range traces {
+		traceIDs[i] = t.traceID
+		if t.timestamp.Before(minTime) {
+			minTime = t.timestamp
+		}
+		if t.timestamp.After(maxTime) {
+			maxTime = t.timestamp
+		}
+	}
+	return checkTraceIDList(traceIDs), minTime, maxTime, nil
+}
+
+// relationSpan is the span with the minimum info for evaluating the relationship.
+type relationSpan struct {
+	spanID       string
+	parentSpanID string
+	isAncestor   bool
+	isDescendant bool
+}
+
+// matchSpanRelation returns all the (ancestor, descendant) span pairs in spans of a trace, which satisfy the relation.
+func matchSpanRelation(spans []relationSpan, relation SpanRelation) []SpanRelationMatch {
+	spansByID := make(map[string]*relationSpan, len(spans))
+	for i := range spans {
+		spansByID[spans[i].spanID] = &spans[i]
+	}
+
+	var matches []SpanRelationMatch
+	for i := range spans {
+		sp := &spans[i]
+		if !sp.isDescendant {
+			continue
+		}
+
+		// walk up the tree from the descendant span. `depth` prevents from infinite loop on broken data with cycles.
+		parentSpanID := sp.parentSpanID
+		for depth := 0; parentSpanID != "" && depth < len(spans); depth++ {
+			parent, ok := spansByID[parentSpanID]
+			if !ok {
+				// the parent span is missing.
+				break
+			}
+			if parent.isAncestor && parent.spanID != sp.spanID {
+				matches = append(matches, SpanRelationMatch{
+					AncestorSpanID:   parent.spanID,
+					DescendantSpanID: sp.spanID,
+				})
+			}
+			if relation == SpanRelationChild {
+				break
+			}
+			parentSpanID = parent.parentSpanID
+		}
+	}
+	return matches
+}
+
+// runQueryAndCollectRows executes q and returns all the result rows.
+func runQueryAndCollectRows(ctx context.Context, cp *CommonParams, q *logstorage.Query) ([][]logstorage.Field, error) {
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var rowsLock sync.Mutex
+	var rows [][]logstorage.Field
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		columns := db.Columns
+		if len(columns) == 0 {
+			return
+		}
+		clonedColumnNames := make([]string, len(columns))
+		for i, c := range columns {
+			clonedColumnNames[i] = strings.Clone(c.Name)
+		}
+		for i := range columns[0].Values {
+			fields := make([]logstorage.Field, 0, len(columns))
+			for j := range columns {
+				// column could be empty if this span does not contain such field.
+				// only append non-empty columns.
+				if columns[j].Values[i] != "" {
+					fields = append(fields, logstorage.Field{
+						Name:  clonedColumnNames[j],
+						Value: strings.Clone(columns[j].Values[i]),
+					})
+				}
+			}
+			rowsLock.Lock()
+			rows = append(rows, fields)
+			rowsLock.Unlock()
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return rows, nil
+}
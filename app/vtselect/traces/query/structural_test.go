@@ -0,0 +1,108 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSpanRelation(t *testing.T) {
+	f := func(s string, relationExpected SpanRelation, errExpected bool) {
+		t.Helper()
+
+		relation, err := ParseSpanRelation(s)
+		if (err != nil) != errExpected {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+		if relation != relationExpected {
+			t.Fatalf("unexpected relation for %q; got %d; want %d", s, relation, relationExpected)
+		}
+	}
+	f("child", SpanRelationChild, false)
+	f("descendant", SpanRelationDescendant, false)
+	f("", 0, true)
+	f("parent", 0, true)
+}
+
+func TestCheckSpanRelationQueryParam(t *testing.T) {
+	f := func(startTimeMin, startTimeMax time.Time, maxTimeRange time.Duration, errExpected bool) {
+		t.Helper()
+
+		param := &SpanRelationQueryParam{
+			StartTimeMin: startTimeMin,
+			StartTimeMax: startTimeMax,
+		}
+		err := checkSpanRelationQueryParam(param, maxTimeRange)
+		if errExpected != (err != nil) {
+			t.Fatalf("unexpected error: %v; want error: %v", err, errExpected)
+		}
+	}
+
+	end := time.Unix(1700000000, 0)
+
+	// the time range within the limit
+	f(end.Add(-time.Hour), end, 24*time.Hour, false)
+	f(end.Add(-24*time.Hour), end, 24*time.Hour, false)
+
+	// the time range exceeds the limit, e.g. if start param is missing and the search starts from the unix epoch
+	f(end.Add(-24*time.Hour-time.Second), end, 24*time.Hour, true)
+	f(time.Unix(0, 0), end, 24*time.Hour, true)
+
+	// zero limit means no limit
+	f(time.Unix(0, 0), end, 0, false)
+}
+
+func TestMatchSpanRelation(t *testing.T) {
+	f := func(spans []relationSpan, relation SpanRelation, matchesExpected []SpanRelationMatch) {
+		t.Helper()
+
+		matches := matchSpanRelation(spans, relation)
+		if !reflect.DeepEqual(matches, matchesExpected) {
+			t.Fatalf("unexpected matches; got %v; want %v", matches, matchesExpected)
+		}
+	}
+
+	// root(A) -> a(A) -> b -> c(D)
+	//         -> d(D)
+	spans := []relationSpan{
+		{spanID: "root", isAncestor: true},
+		{spanID: "a", parentSpanID: "root", isAncestor: true},
+		{spanID: "b", parentSpanID: "a"},
+		{spanID: "c", parentSpanID: "b", isDescendant: true},
+		{spanID: "d", parentSpanID: "root", isDescendant: true},
+	}
+	f(spans, SpanRelationChild, []SpanRelationMatch{
+		{AncestorSpanID: "root", DescendantSpanID: "d"},
+	})
+	f(spans, SpanRelationDescendant, []SpanRelationMatch{
+		{AncestorSpanID: "a", DescendantSpanID: "c"},
+		{AncestorSpanID: "root", DescendantSpanID: "c"},
+		{AncestorSpanID: "root", DescendantSpanID: "d"},
+	})
+
+	// no matches
+	f(nil, SpanRelationDescendant, nil)
+	f([]relationSpan{
+		{spanID: "a", isDescendant: true},
+		{spanID: "b", parentSpanID: "a", isAncestor: true},
+	}, SpanRelationDescendant, nil)
+
+	// the span matching both filters isn't paired with itself
+	f([]relationSpan{
+		{spanID: "a", parentSpanID: "a", isAncestor: true, isDescendant: true},
+	}, SpanRelationChild, nil)
+
+	// missing parent span
+	f([]relationSpan{
+		{spanID: "root", isAncestor: true},
+		{spanID: "b", parentSpanID: "a", isDescendant: true},
+	}, SpanRelationDescendant, nil)
+
+	// cycle in broken data
+	f([]relationSpan{
+		{spanID: "a", parentSpanID: "b", isAncestor: true},
+		{spanID: "b", parentSpanID: "a", isDescendant: true},
+	}, SpanRelationDescendant, []SpanRelationMatch{
+		{AncestorSpanID: "a", DescendantSpanID: "b"},
+	})
+}
@@ -0,0 +1,226 @@
+package query
+
+import (
+	"container/list"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	traceCacheSize = flagutil.NewBytes("search.traceCacheSize", 64*1024*1024, "The maximum memory size of the in-memory cache of assembled traces. "+
+		"Only traces, whose newest span is older than -search.traceMaxDurationWindow, are cached, so incomplete traces are never served from the cache. "+
+		"Zero disables the cache. It affects Jaeger's /api/traces/<trace_id> API. See also -search.traceCacheTTL")
+	traceCacheTTL = flag.Duration("search.traceCacheTTL", 5*time.Minute, "The maximum time a trace is kept in the in-memory cache of assembled traces. "+
+		"It limits the staleness of the cache after deleting spans via other vtselect instances. See also -search.traceCacheSize")
+)
+
+var traceCacheV = newTraceCache()
+
+var (
+	traceCacheHits          = metrics.NewCounter(`vt_trace_cache_hits_total`)
+	traceCacheMisses        = metrics.NewCounter(`vt_trace_cache_misses_total`)
+	traceCacheEvictions     = metrics.NewCounter(`vt_trace_cache_evictions_total`)
+	traceCacheInvalidations = metrics.NewCounter(`vt_trace_cache_invalidations_total`)
+
+	_ = metrics.NewGauge(`vt_trace_cache_entries`, func() float64 {
+		n, _ := traceCacheV.stats()
+		return float64(n)
+	})
+	_ = metrics.NewGauge(`vt_trace_cache_size_bytes`, func() float64 {
+		_, size := traceCacheV.stats()
+		return float64(size)
+	})
+	_ = metrics.NewGauge(`vt_trace_cache_size_max_bytes`, func() float64 {
+		return float64(traceCacheSize.N)
+	})
+)
+
+type traceCacheKey struct {
+	tenantID logstorage.TenantID
+	traceID  string
+}
+
+type traceCacheEntry struct {
+	key      traceCacheKey
+	rows     []*Row
+	size     int
+	expireAt time.Time
+}
+
+// traceCache is a bounded LRU cache of assembled traces.
+type traceCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List // the most recently used entries are at the front.
+	items map[traceCacheKey]*list.Element
+}
+
+func newTraceCache() *traceCache {
+	return &traceCache{
+		ll:    list.New(),
+		items: make(map[traceCacheKey]*list.Element),
+	}
+}
+
+func (c *traceCache) stats() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items), c.size
+}
+
+// get returns the rows of k if they are cached and not expired at now.
+//
+// The returned rows are shared between callers and must not be modified.
+func (c *traceCache) get(k traceCacheKey, now time.Time) ([]*Row, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[k]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*traceCacheEntry)
+	if now.After(e.expireAt) {
+		c.removeElement(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.rows, true
+}
+
+// put stores rows of k in the cache, evicting the least recently used entries, so the cache size doesn't exceed maxSize.
+//
+// Traces bigger than 1/4 of maxSize aren't cached, so a single huge trace cannot wipe out the cache.
+func (c *traceCache) put(k traceCacheKey, rows []*Row, maxSize int, ttl time.Duration, now time.Time) {
+	size := traceRowsSize(k.traceID, rows)
+	if size > maxSize/4 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[k]; ok {
+		c.removeElement(el)
+	}
+	for c.size+size > maxSize {
+		el := c.ll.Back()
+		if el == nil {
+			break
+		}
+		c.removeElement(el)
+		traceCacheEvictions.Inc()
+	}
+	e := &traceCacheEntry{
+		key:      k,
+		rows:     rows,
+		size:     size,
+		expireAt: now.Add(ttl),
+	}
+	c.items[k] = c.ll.PushFront(e)
+	c.size += size
+}
+
+// invalidate removes all the cached traces of the given tenants.
+func (c *traceCache) invalidate(tenantIDs []logstorage.TenantID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, el := range c.items {
+		for _, tenantID := range tenantIDs {
+			if k.tenantID == tenantID {
+				c.removeElement(el)
+				break
+			}
+		}
+	}
+}
+
+func (c *traceCache) removeElement(el *list.Element) {
+	e := c.ll.Remove(el).(*traceCacheEntry)
+	delete(c.items, e.key)
+	c.size -= e.size
+}
+
+// traceRowsSize returns the approximate memory size of the cached trace.
+func traceRowsSize(traceID string, rows []*Row) int {
+	// the overhead of the entry, list element and map item.
+	n := 128 + len(traceID)
+	for _, r := range rows {
+		n += 48
+		for _, f := range r.Fields {
+			n += 32 + len(f.Name) + len(f.Value)
+		}
+	}
+	return n
+}
+
+// isTraceCacheable returns true if rows could be cached at now.
+//
+// The trace is cacheable only if its newest span is older than -search.traceMaxDurationWindow,
+// since newer traces may still receive spans.
+func isTraceCacheable(rows []*Row, now time.Time) bool {
+	if len(rows) == 0 {
+		return false
+	}
+	deadline := now.Add(-*traceMaxDurationWindow).UnixNano()
+	for _, r := range rows {
+		if r.Timestamp >= deadline {
+			return false
+		}
+	}
+	return true
+}
+
+// getCachedTrace returns the spans of traceID from the cache if it's enabled and the request is for a single tenant.
+// Otherwise, or on cache miss, the spans are fetched with fetch.
+func getCachedTrace(cp *CommonParams, traceID string, fetch func() ([]*Row, error)) ([]*Row, error) {
+	k, maxSize, ok := getTraceCacheKey(cp, traceID)
+	if !ok {
+		return fetch()
+	}
+
+	if rows, ok := traceCacheV.get(k, time.Now()); ok {
+		traceCacheHits.Inc()
+		return rows, nil
+	}
+	traceCacheMisses.Inc()
+
+	rows, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if isTraceCacheable(rows, now) {
+		traceCacheV.put(k, rows, maxSize, *traceCacheTTL, now)
+	}
+	return rows, nil
+}
+
+// getTraceCacheKey returns the cache key for traceID and the max cache size.
+//
+// false is returned if the cache is disabled, or the request cannot be served from the cache.
+func getTraceCacheKey(cp *CommonParams, traceID string) (traceCacheKey, int, bool) {
+	maxSize := traceCacheSize.IntN()
+	if maxSize <= 0 || *traceCacheTTL <= 0 || len(cp.TenantIDs) != 1 || len(cp.HiddenFieldsFilters) > 0 {
+		return traceCacheKey{}, 0, false
+	}
+	k := traceCacheKey{
+		tenantID: cp.TenantIDs[0],
+		traceID:  traceID,
+	}
+	return k, maxSize, true
+}
+
+// InvalidateTraceCache drops the cached traces of the given tenants.
+//
+// It must be called when a delete task for the tenants is finished, so the deleted spans aren't returned from the cache.
+func InvalidateTraceCache(tenantIDs []logstorage.TenantID) {
+	traceCacheInvalidations.Inc()
+	traceCacheV.invalidate(tenantIDs)
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+)
+
+func TestTraceCache(t *testing.T) {
+	c := newTraceCache()
+	now := time.Now()
+
+	newKey := func(traceID string) traceCacheKey {
+		return traceCacheKey{
+			tenantID: logstorage.TenantID{AccountID: 1},
+			traceID:  traceID,
+		}
+	}
+	rows := []*Row{{
+		Timestamp: 1,
+		Fields:    []logstorage.Field{{Name: "name", Value: "foo"}},
+	}}
+	entrySize := traceRowsSize("a", rows)
+	maxSize := 4 * entrySize
+
+	f := func(k traceCacheKey, at time.Time, okExpected bool) {
+		t.Helper()
+
+		_, ok := c.get(k, at)
+		if ok != okExpected {
+			t.Fatalf("unexpected cache hit for %q; got %v; want %v", k.traceID, ok, okExpected)
+		}
+	}
+
+	// miss
+	f(newKey("a"), now, false)
+
+	// hit
+	c.put(newKey("a"), rows, maxSize, time.Minute, now)
+	f(newKey("a"), now, true)
+
+	// another tenant
+	f(traceCacheKey{traceID: "a"}, now, false)
+
+	// the least recently used entry is evicted
+	c.put(newKey("b"), rows, maxSize, time.Minute, now)
+	c.put(newKey("c"), rows, maxSize, time.Minute, now)
+	c.put(newKey("d"), rows, maxSize, time.Minute, now)
+	f(newKey("a"), now, true)
+	c.put(newKey("e"), rows, maxSize, time.Minute, now)
+	f(newKey("a"), now, true)
+	f(newKey("b"), now, false)
+	f(newKey("c"), now, true)
+	if n, size := c.stats(); n != 4 || size != maxSize {
+		t.Fatalf("unexpected cache stats; got entries=%d, size=%d; want entries=4, size=%d", n, size, maxSize)
+	}
+
+	// expiration
+	f(newKey("d"), now.Add(2*time.Minute), false)
+
+	// traces bigger than 1/4 of the cache aren't cached
+	c.put(newKey("f"), rows, maxSize-1, time.Minute, now)
+	f(newKey("f"), now, false)
+
+	// invalidation
+	c.invalidate([]logstorage.TenantID{{AccountID: 1}})
+	f(newKey("a"), now, false)
+	if n, size := c.stats(); n != 0 || size != 0 {
+		t.Fatalf("unexpected cache stats after invalidation; got entries=%d, size=%d", n, size)
+	}
+}
+
+func TestIsTraceCacheable(t *testing.T) {
+	now := time.Now()
+
+	f := func(timestamps []time.Time, resultExpected bool) {
+		t.Helper()
+
+		rows := make([]*Row, len(timestamps))
+		for i, ts := range timestamps {
+			rows[i] = &Row{Timestamp: ts.UnixNano()}
+		}
+		result := isTraceCacheable(rows, now)
+		if result != resultExpected {
+			t.Fatalf("unexpected result; got %v; want %v", result, resultExpected)
+		}
+	}
+
+	// empty trace
+	f(nil, false)
+
+	// all spans are older than -search.traceMaxDurationWindow
+	f([]time.Time{now.Add(-time.Hour), now.Add(-*traceMaxDurationWindow - time.Second)}, true)
+
+	// the newest span is within -search.traceMaxDurationWindow
+	f([]time.Time{now.Add(-time.Hour), now.Add(-time.Second)}, false)
+}
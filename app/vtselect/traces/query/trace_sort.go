@@ -0,0 +1,168 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	vtstoragecommon "github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage/common"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+var traceSortMaxTimeRange = flag.Duration("search.traceSortMaxTimeRange", 24*time.Hour, "The maximum time range for searching traces with non-default sort order. "+
+	"Such search loads the IDs of all the matching traces in the time range into memory, so bigger time ranges are rejected. Zero means no limit")
+
+// TraceSortOrder is the order of traces in the search result.
+type TraceSortOrder int
+
+const (
+	// TraceSortOrderRecent sorts traces by the time of their last matching span in descending order.
+	TraceSortOrderRecent TraceSortOrder = iota
+	// TraceSortOrderSlowest sorts traces by the trace duration in descending order.
+	TraceSortOrderSlowest
+	// TraceSortOrderMostSpans sorts traces by the number of spans in descending order.
+	TraceSortOrderMostSpans
+	// TraceSortOrderErrorsFirst sorts traces by the number of spans with error status in descending order,
+	// and then by the time of their last span in descending order.
+	TraceSortOrderErrorsFirst
+)
+
+// ParseTraceSortOrder parses s to TraceSortOrder. Empty s means TraceSortOrderRecent.
+func ParseTraceSortOrder(s string) (TraceSortOrder, error) {
+	switch s {
+	case "", "recent":
+		return TraceSortOrderRecent, nil
+	case "slowest":
+		return TraceSortOrderSlowest, nil
+	case "most_spans":
+		return TraceSortOrderMostSpans, nil
+	case "errors_first":
+		return TraceSortOrderErrorsFirst, nil
+	default:
+		return 0, fmt.Errorf("unsupported sort order %q; supported values: recent, slowest, most_spans, errors_first", s)
+	}
+}
+
+// String returns the name of so, which could be parsed by ParseTraceSortOrder.
+func (so TraceSortOrder) String() string {
+	switch so {
+	case TraceSortOrderSlowest:
+		return "slowest"
+	case TraceSortOrderMostSpans:
+		return "most_spans"
+	case TraceSortOrderErrorsFirst:
+		return "errors_first"
+	default:
+		return "recent"
+	}
+}
+
+// sortFields returns the fields for `sort by (...)` pipe over the result of `stats by (trace_id)` pipe in getSortedTraceIDList.
+func (so TraceSortOrder) sortFields() string {
+	switch so {
+	case TraceSortOrderSlowest:
+		return "vt_duration desc, " + otelpb.TraceIDField + " desc"
+	case TraceSortOrderMostSpans:
+		return "vt_spans desc, " + otelpb.TraceIDField + " desc"
+	case TraceSortOrderErrorsFirst:
+		return "vt_errors desc, _time desc, " + otelpb.TraceIDField + " desc"
+	default:
+		return "_time desc, " + otelpb.TraceIDField + " desc"
+	}
+}
+
+// getSortedTraceIDList returns traceIDs according to the search params in param.SortOrder.
+// It also returns the earliest time of spans of these traces, to help reducing the time range for spans search.
+//
+// Unlike the search for the most recent traces, the time range cannot be split for non-time orders,
+// since the traces at the beginning of the time range could go first. So the whole time range is searched for the matching traces,
+// and the sort keys are calculated from all the spans of the matching traces:
+//
+//	trace_id:in(_time:[start, end] AND <filter> | fields trace_id)
+//	  | stats by (trace_id) <sort keys>
+//	  | sort by (<sort keys> desc, trace_id desc) | limit N
+//
+// The IDs of all the matching traces are loaded into memory, so the time range is limited by -search.traceSortMaxTimeRange.
+func getSortedTraceIDList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]string, time.Time, *TraceCursor, error) {
+	if err := checkSortedTraceSearchParam(param, *traceSortMaxTimeRange); err != nil {
+		return nil, time.Time{}, nil, err
+	}
+
+	currentTime := time.Now()
+	start := param.StartTimeMin.UTC().Format(time.RFC3339Nano)
+	end := param.StartTimeMax.UTC().Format(time.RFC3339Nano)
+	qStr := fmt.Sprintf("%s:in(_time:[%s, %s] AND %s | fields %s)", otelpb.TraceIDField, start, end, getSpanFilter(param), otelpb.TraceIDField)
+	qStr += fmt.Sprintf(" | stats by (%s) min(%s) as vt_start, max(%s) as vt_end, count() as vt_spans, count() if (%s:=2) as vt_errors, max(_time) as _time, min(_time) as vt_min_time",
+		otelpb.TraceIDField, otelpb.StartTimeUnixNanoField, otelpb.EndTimeUnixNanoField, otelpb.StatusCodeField)
+	qStr += " | math vt_end - vt_start as vt_duration"
+	qStr += " | sort by (" + param.SortOrder.sortFields() + ")"
+
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	if err != nil {
+		return nil, time.Time{}, nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddPipeOffsetLimit(0, uint64(param.Limit))
+	// the spans of the matching traces could be outside the search time range.
+	q.AddTimeFilter(param.StartTimeMin.Add(-*traceMaxDurationWindow).UnixNano(), param.StartTimeMax.Add(*traceMaxDurationWindow).UnixNano())
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var mu sync.Mutex
+	traceIDList := make([]string, 0, param.Limit)
+	minTime := param.StartTimeMax.UnixNano()
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		var traceIDs, minTimes []string
+		for _, c := range db.Columns {
+			switch c.Name {
+			case otelpb.TraceIDField:
+				traceIDs = c.Values
+			case "vt_min_time":
+				minTimes = c.Values
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, traceID := range traceIDs {
+			traceIDList = append(traceIDList, strings.Clone(traceID))
+			if i >= len(minTimes) {
+				continue
+			}
+			if nsec, ok := logstorage.TryParseTimestampRFC3339Nano(minTimes[i]); ok {
+				minTime = min(minTime, nsec)
+			}
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if errors.Is(err, vtstoragecommon.ErrOutOfRetention) {
+			return nil, time.Time{}, nil, nil
+		}
+		return nil, time.Time{}, nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return checkTraceIDList(traceIDList), time.Unix(0, minTime), nil, nil
+}
+
+// checkSortedTraceSearchParam verifies that param could be used for the search with non-default sort order.
+//
+// The time range of the search must not exceed maxTimeRange. Zero maxTimeRange means no limit.
+func checkSortedTraceSearchParam(param *TraceQueryParam, maxTimeRange time.Duration) error {
+	if param.Cursor != nil {
+		return fmt.Errorf("cursor isn't supported for non-default sort order")
+	}
+	if maxTimeRange > 0 && param.StartTimeMax.Sub(param.StartTimeMin) > maxTimeRange {
+		return fmt.Errorf("the time range [%s, %s] is too big for %q sort order; it mustn't exceed -search.traceSortMaxTimeRange=%s; "+
+			"narrow down the time range via start and end params or use the default recent sort order",
+			param.StartTimeMin.UTC().Format(time.RFC3339), param.StartTimeMax.UTC().Format(time.RFC3339), param.SortOrder, maxTimeRange)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTraceSortOrder(t *testing.T) {
+	f := func(s string, resultExpected TraceSortOrder) {
+		t.Helper()
+
+		result, err := ParseTraceSortOrder(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected sort order for %q; got %d; want %d", s, result, resultExpected)
+		}
+	}
+	f("", TraceSortOrderRecent)
+	f("recent", TraceSortOrderRecent)
+	f("slowest", TraceSortOrderSlowest)
+	f("most_spans", TraceSortOrderMostSpans)
+	f("errors_first", TraceSortOrderErrorsFirst)
+
+	if _, err := ParseTraceSortOrder("fastest"); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
+
+func TestCheckSortedTraceSearchParam(t *testing.T) {
+	f := func(param *TraceQueryParam, maxTimeRange time.Duration, errExpected bool) {
+		t.Helper()
+
+		err := checkSortedTraceSearchParam(param, maxTimeRange)
+		if errExpected != (err != nil) {
+			t.Fatalf("unexpected error: %v; want error: %v", err, errExpected)
+		}
+	}
+
+	end := time.Unix(1700000000, 0)
+	newParam := func(timeRange time.Duration) *TraceQueryParam {
+		return &TraceQueryParam{
+			StartTimeMin: end.Add(-timeRange),
+			StartTimeMax: end,
+			SortOrder:    TraceSortOrderSlowest,
+		}
+	}
+
+	// the time range within the limit
+	f(newParam(time.Hour), 24*time.Hour, false)
+	f(newParam(24*time.Hour), 24*time.Hour, false)
+
+	// the time range exceeds the limit, e.g. if start param is missing and the search starts from the unix epoch
+	f(newParam(24*time.Hour+time.Second), 24*time.Hour, true)
+	f(&TraceQueryParam{StartTimeMin: time.Unix(0, 0), StartTimeMax: end, SortOrder: TraceSortOrderMostSpans}, 24*time.Hour, true)
+
+	// zero limit means no limit
+	f(newParam(365*24*time.Hour), 0, false)
+
+	// cursor isn't supported
+	param := newParam(time.Hour)
+	param.Cursor = &TraceCursor{}
+	f(param, 24*time.Hour, true)
+}
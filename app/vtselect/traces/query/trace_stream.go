@@ -0,0 +1,281 @@
+package query
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+var traceMaxSpans = flag.Int("search.traceMaxSpans", 0, "The maximum number of spans returned for a single trace. "+
+	"The rest of spans are dropped, and the trace is returned with the truncation warning. Zero means no limit. "+
+	"It affects Jaeger's /api/traces/<trace_id> API")
+
+// TraceStreamParam is the parameters for streaming spans of a trace.
+type TraceStreamParam struct {
+	// MaxDepth is the maximum depth of spans in the span tree, which are returned. Root spans have depth 1.
+	// The spans without parent span in the trace are treated as root spans. Zero means no limit.
+	MaxDepth int
+}
+
+// TraceStreamResult contains the stats of the streamed trace.
+type TraceStreamResult struct {
+	// SpansCount is the number of spans passed to writeSpan.
+	SpansCount int
+	// Truncated is set if the trace contains more spans than -search.traceMaxSpans.
+	Truncated bool
+	// PrunedSpansCount is the number of spans skipped because of TraceStreamParam.MaxDepth.
+	PrunedSpansCount int
+}
+
+// Warnings returns the human-readable warnings about the spans missing in the streamed trace.
+func (tsr *TraceStreamResult) Warnings() []string {
+	var warnings []string
+	if tsr.Truncated {
+		warnings = append(warnings, fmt.Sprintf("the trace is truncated to %d spans according to -search.traceMaxSpans", tsr.SpansCount))
+	}
+	if tsr.PrunedSpansCount > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d spans below the max depth are pruned", tsr.PrunedSpansCount))
+	}
+	return warnings
+}
+
+// StreamTrace calls writeSpan for every span of traceID as soon as the span is read from the storage,
+// so the whole trace isn't kept in memory.
+//
+// writeSpan calls are serialized, and the fields passed to writeSpan are valid only until it returns.
+// The spans are passed in arbitrary order.
+//
+// Only up to -search.traceMaxSpans spans are passed to writeSpan. If param.MaxDepth is set, the span tree is built
+// from span_id and parent_span_id fields before streaming the spans, and the spans below the max depth are skipped.
+func StreamTrace(ctx context.Context, cp *CommonParams, traceID string, param *TraceStreamParam, writeSpan func(fields []logstorage.Field)) (*TraceStreamResult, error) {
+	maxSpans := *traceMaxSpans
+	tsr := &TraceStreamResult{}
+
+	k, maxCacheSize, cacheEnabled := getTraceCacheKey(cp, traceID)
+	cacheEnabled = cacheEnabled && param.MaxDepth <= 0
+	if cacheEnabled {
+		if rows, ok := traceCacheV.get(k, time.Now()); ok {
+			traceCacheHits.Inc()
+			for _, row := range rows {
+				if maxSpans > 0 && tsr.SpansCount >= maxSpans {
+					tsr.Truncated = true
+					break
+				}
+				writeSpan(row.Fields)
+				tsr.SpansCount++
+			}
+			return tsr, nil
+		}
+		traceCacheMisses.Inc()
+	}
+
+	startTime, endTime, ok, err := findTraceTimeRange(ctx, cp, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return tsr, nil
+	}
+
+	var allowedSpanIDs map[string]struct{}
+	if param.MaxDepth > 0 {
+		spanParents, err := getSpanParents(ctx, cp, traceID, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		allowedSpanIDs = getSpanIDsUpToDepth(spanParents, param.MaxDepth)
+	}
+
+	// query: trace_id:traceID
+	qStr := fmt.Sprintf(otelpb.TraceIDField+": %q", traceID)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(startTime.UnixNano(), endTime.UnixNano())
+
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctxWithCancel)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	// the rows are collected for the cache until they become too big for it.
+	var rows []*Row
+	rowsSize := 0
+
+	var mu sync.Mutex
+	var fields []logstorage.Field
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if tsr.Truncated {
+			return
+		}
+		timestamps, _ := db.GetTimestamps(nil)
+		columns := db.Columns
+		for i := 0; i < db.RowsCount(); i++ {
+			fields = fields[:0]
+			for j := range columns {
+				// column could be empty if this span does not contain such field.
+				// only append non-empty columns.
+				if v := columns[j].Values[i]; v != "" {
+					fields = append(fields, logstorage.Field{Name: columns[j].Name, Value: v})
+				}
+			}
+
+			if allowedSpanIDs != nil {
+				if _, ok := allowedSpanIDs[getFieldValue(fields, otelpb.SpanIDField)]; !ok {
+					tsr.PrunedSpansCount++
+					continue
+				}
+			}
+			if maxSpans > 0 && tsr.SpansCount >= maxSpans {
+				tsr.Truncated = true
+				cancel()
+				return
+			}
+
+			writeSpan(fields)
+			tsr.SpansCount++
+
+			if !cacheEnabled || rowsSize < 0 || i >= len(timestamps) {
+				continue
+			}
+			row := &Row{
+				Timestamp: timestamps[i],
+				Fields:    make([]logstorage.Field, len(fields)),
+			}
+			for j, f := range fields {
+				row.Fields[j] = logstorage.Field{Name: strings.Clone(f.Name), Value: strings.Clone(f.Value)}
+			}
+			rows = append(rows, row)
+			rowsSize += traceRowsSize("", []*Row{row})
+			if rowsSize > maxCacheSize/4 {
+				// the trace is too big for the cache.
+				rows = nil
+				rowsSize = -1
+			}
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		if !tsr.Truncated || ctx.Err() != nil {
+			return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+		}
+	}
+
+	if cacheEnabled && !tsr.Truncated && rowsSize >= 0 {
+		now := time.Now()
+		if isTraceCacheable(rows, now) {
+			traceCacheV.put(k, rows, maxCacheSize, *traceCacheTTL, now)
+		}
+	}
+	return tsr, nil
+}
+
+// getSpanParents returns span_id -> parent_span_id map for the spans of traceID in the given time range.
+func getSpanParents(ctx context.Context, cp *CommonParams, traceID string, startTime, endTime time.Time) (map[string]string, error) {
+	// query: trace_id:traceID | fields span_id, parent_span_id
+	qStr := fmt.Sprintf("%s: %q | fields %s, %s", otelpb.TraceIDField, traceID, otelpb.SpanIDField, otelpb.ParentSpanIDField)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, endTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	q.AddTimeFilter(startTime.UnixNano(), endTime.UnixNano())
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var mu sync.Mutex
+	spanParents := make(map[string]string)
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		var spanIDs, parentSpanIDs []string
+		for _, c := range db.Columns {
+			switch c.Name {
+			case otelpb.SpanIDField:
+				spanIDs = c.Values
+			case otelpb.ParentSpanIDField:
+				parentSpanIDs = c.Values
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, spanID := range spanIDs {
+			if spanID == "" {
+				continue
+			}
+			parentSpanID := ""
+			if i < len(parentSpanIDs) {
+				parentSpanID = strings.Clone(parentSpanIDs[i])
+			}
+			spanParents[strings.Clone(spanID)] = parentSpanID
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+	return spanParents, nil
+}
+
+// getSpanIDsUpToDepth returns the span IDs, whose depth in the span tree doesn't exceed maxDepth.
+//
+// The spans with missing parent span are treated as root spans with depth 1. The spans in parent cycles are skipped.
+func getSpanIDsUpToDepth(spanParents map[string]string, maxDepth int) map[string]struct{} {
+	depths := make(map[string]int, len(spanParents))
+	var path []string
+	for spanID := range spanParents {
+		// walk up to the span with the known depth or to the root.
+		path = path[:0]
+		depth := 0
+		for id := spanID; ; {
+			if d, ok := depths[id]; ok {
+				depth = d
+				break
+			}
+			parentSpanID, ok := spanParents[id]
+			if !ok {
+				// the parent span is missing in the trace.
+				break
+			}
+			depths[id] = -1
+			path = append(path, id)
+			id = parentSpanID
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			if depth >= 0 {
+				depth++
+			}
+			depths[path[i]] = depth
+		}
+	}
+
+	result := make(map[string]struct{})
+	for spanID, depth := range depths {
+		if depth > 0 && depth <= maxDepth {
+			result[spanID] = struct{}{}
+		}
+	}
+	return result
+}
+
+func getFieldValue(fields []logstorage.Field, name string) string {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGetSpanIDsUpToDepth(t *testing.T) {
+	f := func(spanParents map[string]string, maxDepth int, resultExpected []string) {
+		t.Helper()
+
+		var result []string
+		for spanID := range getSpanIDsUpToDepth(spanParents, maxDepth) {
+			result = append(result, spanID)
+		}
+		sort.Strings(result)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %q; want %q", result, resultExpected)
+		}
+	}
+
+	// root -> a -> b -> c
+	//      -> d
+	spanParents := map[string]string{
+		"root": "",
+		"a":    "root",
+		"b":    "a",
+		"c":    "b",
+		"d":    "root",
+	}
+	f(spanParents, 1, []string{"root"})
+	f(spanParents, 2, []string{"a", "d", "root"})
+	f(spanParents, 3, []string{"a", "b", "d", "root"})
+	f(spanParents, 10, []string{"a", "b", "c", "d", "root"})
+
+	// the span with missing parent is a root
+	f(map[string]string{
+		"a": "missing",
+		"b": "a",
+		"c": "b",
+	}, 2, []string{"a", "b"})
+
+	// spans in the cycle and their children are skipped
+	f(map[string]string{
+		"root": "",
+		"a":    "b",
+		"b":    "a",
+		"c":    "a",
+	}, 10, []string{"root"})
+}
+
+func TestTraceStreamResultWarnings(t *testing.T) {
+	f := func(tsr *TraceStreamResult, warningsExpected []string) {
+		t.Helper()
+
+		warnings := tsr.Warnings()
+		if !reflect.DeepEqual(warnings, warningsExpected) {
+			t.Fatalf("unexpected warnings; got %q; want %q", warnings, warningsExpected)
+		}
+	}
+	f(&TraceStreamResult{SpansCount: 10}, nil)
+	f(&TraceStreamResult{SpansCount: 10, Truncated: true, PrunedSpansCount: 3}, []string{
+		"the trace is truncated to 10 spans according to -search.traceMaxSpans",
+		"3 spans below the max depth are pruned",
+	})
+}
@@ -0,0 +1,143 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/valyala/fastjson"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtstorage"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// TraceSummary is the summary of a trace, which could be used for listing traces without fetching all their spans.
+type TraceSummary struct {
+	TraceID string
+	// RootServiceName and RootTraceName are the service name and the span name of the root span.
+	// If the root span is missing, they are taken from the earliest span of the trace.
+	RootServiceName string
+	RootTraceName   string
+	// StartTimeUnixNano is the start time of the earliest span of the trace.
+	StartTimeUnixNano int64
+	// DurationMs is the time between the start of the earliest span and the end of the latest span of the trace.
+	DurationMs int64
+}
+
+// GetTraceSummaryList returns the summary of traces matching the given param.
+//
+// The traces are searched in the same way as GetTraceList does. But instead of fetching all the spans of the matching traces,
+// only the root span and the timing of every trace are calculated on the storage side:
+//
+//	trace_id:in(...) | stats by (trace_id) min(start_time_unix_nano), max(end_time_unix_nano),
+//	  row_min(start_time_unix_nano, name, resource_attr:service.name) if (parent_span_id:""),
+//	  row_min(start_time_unix_nano, name, resource_attr:service.name)
+func GetTraceSummaryList(ctx context.Context, cp *CommonParams, param *TraceQueryParam) ([]*TraceSummary, *TraceCursor, error) {
+	currentTime := time.Now()
+
+	traceIDs, startTime, nextCursor, err := getTraceIDList(ctx, cp, param)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get trace id error: %w", err)
+	}
+	if len(traceIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	rootFields := fmt.Sprintf("%s, %s, %q", otelpb.StartTimeUnixNanoField, otelpb.NameField, otelpb.ResourceAttrServiceName)
+	qStr := fmt.Sprintf("%s:in(%s) | stats by (%s) min(%s) as vt_start, max(%s) as vt_end, row_min(%s) if (%s:\"\") as vt_root, row_min(%s) as vt_first",
+		otelpb.TraceIDField, strings.Join(traceIDs, ","), otelpb.TraceIDField, otelpb.StartTimeUnixNanoField, otelpb.EndTimeUnixNanoField,
+		rootFields, otelpb.ParentSpanIDField, rootFields)
+	q, err := logstorage.ParseQueryAtTimestamp(qStr, currentTime.UnixNano())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse query [%s]: %s", qStr, err)
+	}
+	// adjust start time and end time with max duration window to make sure all spans are included.
+	q.AddTimeFilter(startTime.Add(-*traceMaxDurationWindow).UnixNano(), param.StartTimeMax.Add(*traceMaxDurationWindow).UnixNano())
+
+	cp.Query = q
+	qctx := cp.NewQueryContext(ctx)
+	defer cp.UpdatePerQueryStatsMetrics()
+
+	var mu sync.Mutex
+	summaries := make(map[string]*TraceSummary, len(traceIDs))
+	writeBlock := func(_ uint, db *logstorage.DataBlock) {
+		var ids, starts, ends, roots, firsts []string
+		for _, c := range db.Columns {
+			switch c.Name {
+			case otelpb.TraceIDField:
+				ids = c.Values
+			case "vt_start":
+				starts = c.Values
+			case "vt_end":
+				ends = c.Values
+			case "vt_root":
+				roots = c.Values
+			case "vt_first":
+				firsts = c.Values
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i, traceID := range ids {
+			summaries[strings.Clone(traceID)] = newTraceSummary(traceID, getValueAt(starts, i), getValueAt(ends, i), getValueAt(roots, i), getValueAt(firsts, i))
+		}
+	}
+
+	if err := vtstorage.RunQuery(qctx, writeBlock); err != nil {
+		return nil, nil, fmt.Errorf("cannot execute query [%s]: %s", q, err)
+	}
+
+	// keep the order of traceIDs.
+	result := make([]*TraceSummary, 0, len(traceIDs))
+	for _, traceID := range traceIDs {
+		if ts, ok := summaries[traceID]; ok {
+			result = append(result, ts)
+		}
+	}
+	return result, nextCursor, nil
+}
+
+// newTraceSummary creates TraceSummary from the results of `stats by (trace_id)` pipe in GetTraceSummaryList.
+//
+// root and first are JSON objects returned by `row_min` function for the root span and the earliest span.
+func newTraceSummary(traceID, start, end, root, first string) *TraceSummary {
+	ts := &TraceSummary{
+		TraceID: strings.Clone(traceID),
+	}
+	startNsec, _ := strconv.ParseInt(start, 10, 64)
+	endNsec, _ := strconv.ParseInt(end, 10, 64)
+	ts.StartTimeUnixNano = startNsec
+	if endNsec > startNsec {
+		ts.DurationMs = (endNsec - startNsec) / 1e6
+	}
+
+	var p fastjson.Parser
+	for _, s := range []string{root, first} {
+		v, err := p.Parse(s)
+		if err != nil {
+			continue
+		}
+		name := v.GetStringBytes(otelpb.NameField)
+		serviceName := v.GetStringBytes(otelpb.ResourceAttrServiceName)
+		if len(name) == 0 && len(serviceName) == 0 {
+			// `row_min` returns `{}` if there are no matching spans, e.g. the root span hasn't arrived yet.
+			continue
+		}
+		ts.RootTraceName = string(name)
+		ts.RootServiceName = string(serviceName)
+		break
+	}
+	return ts
+}
+
+func getValueAt(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
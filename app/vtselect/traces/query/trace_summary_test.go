@@ -0,0 +1,39 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewTraceSummary(t *testing.T) {
+	f := func(start, end, root, first string, resultExpected *TraceSummary) {
+		t.Helper()
+
+		result := newTraceSummary("abc", start, end, root, first)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected result; got %+v; want %+v", result, resultExpected)
+		}
+	}
+
+	// root span
+	f("1000000000", "3500000000", `{"name":"GET /","resource_attr:service.name":"frontend"}`, `{"name":"dns","resource_attr:service.name":"backend"}`, &TraceSummary{
+		TraceID:           "abc",
+		RootServiceName:   "frontend",
+		RootTraceName:     "GET /",
+		StartTimeUnixNano: 1000000000,
+		DurationMs:        2500,
+	})
+
+	// missing root span falls back to the earliest span
+	f("1000000000", "1000000000", `{}`, `{"name":"dns","resource_attr:service.name":"backend"}`, &TraceSummary{
+		TraceID:           "abc",
+		RootServiceName:   "backend",
+		RootTraceName:     "dns",
+		StartTimeUnixNano: 1000000000,
+	})
+
+	// invalid values
+	f("", "", "", "", &TraceSummary{
+		TraceID: "abc",
+	})
+}
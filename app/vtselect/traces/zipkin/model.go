@@ -0,0 +1,230 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+// span is the Zipkin v2 span model.
+//
+// See: https://github.com/openzipkin/zipkin-api/blob/8e8fc6a46bb3a0b4dfe2a8b5c4ea1d8a4fa98e73/zipkin2-api.yaml#L382
+type span struct {
+	traceID        string
+	id             string
+	parentID       string
+	name           string
+	kind           string
+	timestamp      int64
+	duration       int64
+	localEndpoint  endpoint
+	remoteEndpoint endpoint
+	annotations    []annotation
+	tags           []keyValue
+}
+
+type endpoint struct {
+	serviceName string
+}
+
+type annotation struct {
+	timestamp int64
+	value     string
+}
+
+type keyValue struct {
+	key   string
+	value string
+}
+
+// spanKindMap maps OpenTelemetry span kind to Zipkin span kind.
+// The internal(1) and unspecified(0) span kinds have no Zipkin equivalent, and the kind should be omitted.
+var spanKindMap = map[string]string{
+	"2": "SERVER",
+	"3": "CLIENT",
+	"4": "PRODUCER",
+	"5": "CONSUMER",
+}
+
+// fieldsToSpan converts OTLP span in fields to Zipkin span.
+//
+// The conversion follows the OpenTelemetry Zipkin exporter, see:
+// https://opentelemetry.io/docs/specs/otel/trace/sdk_exporters/zipkin/
+func fieldsToSpan(fields []logstorage.Field) (*span, error) {
+	sp := &span{}
+
+	var statusCode, statusMessage string
+	resourceTagList, scopeTagList, spanTagList := make([]keyValue, 0, len(fields)), make([]keyValue, 0, len(fields)), make([]keyValue, 0, len(fields))
+	eventsMap := make(map[int]*event) // idx -> *event
+	for _, field := range fields {
+		switch field.Name {
+		case "_stream", "_msg":
+			// no-op
+		case otelpb.TraceIDField:
+			sp.traceID = field.Value
+		case otelpb.SpanIDField:
+			sp.id = field.Value
+		case otelpb.ParentSpanIDField:
+			sp.parentID = field.Value
+		case otelpb.NameField:
+			sp.name = field.Value
+		case otelpb.KindField:
+			sp.kind = spanKindMap[field.Value]
+		case otelpb.StartTimeUnixNanoField:
+			unixNano, err := strconv.ParseInt(field.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start_time_unix_nano field: %s", err)
+			}
+			sp.timestamp = unixNano / 1000
+		case otelpb.DurationField:
+			nano, err := strconv.ParseInt(field.Value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration field: %s", err)
+			}
+			sp.duration = nano / 1000
+		case otelpb.StatusCodeField:
+			statusCode = field.Value
+		case otelpb.StatusMessageField:
+			statusMessage = field.Value
+		case otelpb.ResourceAttrServiceName:
+			sp.localEndpoint.serviceName = field.Value
+		case otelpb.SpanAttrPeerServiceField:
+			sp.remoteEndpoint.serviceName = field.Value
+			spanTagList = append(spanTagList, keyValue{key: strings.TrimPrefix(field.Name, otelpb.SpanAttrPrefixField), value: field.Value})
+		case otelpb.InstrumentationScopeName:
+			if field.Value != "" {
+				spanTagList = append(spanTagList, keyValue{key: "otel.scope.name", value: field.Value})
+			}
+		case otelpb.InstrumentationScopeVersion:
+			if field.Value != "" {
+				spanTagList = append(spanTagList, keyValue{key: "otel.scope.version", value: field.Value})
+			}
+		default:
+			if strings.HasPrefix(field.Name, otelpb.ResourceAttrPrefix) { // resource attributes
+				resourceTagList = append(resourceTagList, keyValue{key: strings.TrimPrefix(field.Name, otelpb.ResourceAttrPrefix), value: field.Value})
+			} else if strings.HasPrefix(field.Name, otelpb.SpanAttrPrefixField) { // span attributes
+				spanTagList = append(spanTagList, keyValue{key: strings.TrimPrefix(field.Name, otelpb.SpanAttrPrefixField), value: field.Value})
+			} else if strings.HasPrefix(field.Name, otelpb.InstrumentationScopeAttrPrefix) { // instrumentation scope attributes
+				scopeTagList = append(scopeTagList, keyValue{key: strings.TrimPrefix(field.Name, otelpb.InstrumentationScopeAttrPrefix), value: field.Value})
+			} else if strings.HasPrefix(field.Name, otelpb.EventPrefix) { // event list
+				fieldName, idxStr := extraAttributeNameAndIndex(strings.TrimPrefix(field.Name, otelpb.EventPrefix))
+				if idxStr == "" {
+					return nil, fmt.Errorf("invalid event field: %s", field.Name)
+				}
+				idx, _ := strconv.Atoi(idxStr)
+				if _, ok := eventsMap[idx]; !ok {
+					eventsMap[idx] = &event{}
+				}
+				ev := eventsMap[idx]
+				switch fieldName {
+				case otelpb.EventTimeUnixNanoField:
+					unixNano, _ := strconv.ParseInt(field.Value, 10, 64)
+					ev.timestamp = unixNano / 1000
+				case otelpb.EventNameField:
+					ev.name = field.Value
+				case otelpb.EventDroppedAttributesCountField:
+					// no need to display
+				default:
+					if ev.attributes == nil {
+						ev.attributes = make(map[string]string)
+					}
+					ev.attributes[strings.TrimPrefix(fieldName, otelpb.EventAttrPrefix)] = field.Value
+				}
+			}
+		}
+	}
+
+	if sp.id == "" || sp.traceID == "" {
+		return nil, fmt.Errorf("invalid fields: %v", fields)
+	}
+
+	// the status is converted to `otel.status_code` and `error` tags.
+	switch statusCode {
+	case "1":
+		spanTagList = append(spanTagList, keyValue{key: "otel.status_code", value: "OK"})
+	case "2":
+		spanTagList = append(spanTagList, keyValue{key: "otel.status_code", value: "ERROR"})
+		if statusMessage == "" {
+			statusMessage = "true"
+		}
+		spanTagList = append(spanTagList, keyValue{key: "error", value: statusMessage})
+	}
+
+	// Zipkin does not have resource and instrumentation scope attributes, so they're displayed as span tags.
+	// The tags must have unique keys, so span attributes take precedence over scope attributes, and scope attributes take precedence over resource attributes.
+	sp.tags = appendUniqueTags(make([]keyValue, 0, len(spanTagList)+len(scopeTagList)+len(resourceTagList)), spanTagList, scopeTagList, resourceTagList)
+
+	// the events are converted in the order of their indexes. The indexes could be sparse if some fields are missing.
+	for _, idx := range sortedIndexes(eventsMap) {
+		ev := eventsMap[idx]
+		sp.annotations = append(sp.annotations, annotation{
+			timestamp: ev.timestamp,
+			value:     ev.annotationValue(),
+		})
+	}
+
+	return sp, nil
+}
+
+// appendUniqueTags appends tags from tagLists to dst, skipping the tags with keys, which were already appended.
+func appendUniqueTags(dst []keyValue, tagLists ...[]keyValue) []keyValue {
+	seen := make(map[string]struct{})
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			if _, ok := seen[tag.key]; ok {
+				continue
+			}
+			seen[tag.key] = struct{}{}
+			dst = append(dst, tag)
+		}
+	}
+	return dst
+}
+
+// sortedIndexes returns the sorted keys of m.
+func sortedIndexes[T any](m map[int]T) []int {
+	idxs := make([]int, 0, len(m))
+	for idx := range m {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	return idxs
+}
+
+type event struct {
+	timestamp  int64
+	name       string
+	attributes map[string]string
+}
+
+// annotationValue returns the Zipkin annotation value of the event.
+// It's the event name if there's no attribute. Otherwise, the attributes are appended in JSON format as `<name>: {<attributes>}`.
+func (ev *event) annotationValue() string {
+	if len(ev.attributes) == 0 {
+		return ev.name
+	}
+	// json.Marshal sorts the map keys, so the result is stable.
+	attrs, err := json.Marshal(ev.attributes)
+	if err != nil {
+		return ev.name
+	}
+	return ev.name + ": " + string(attrs)
+}
+
+func extraAttributeNameAndIndex(input string) (string, string) {
+	splitIdx := strings.LastIndex(input, ":")
+	if splitIdx == -1 {
+		return input, ""
+	}
+	idx := input[splitIdx+1:]
+	if _, err := strconv.Atoi(idx); err != nil {
+		return input, ""
+	}
+	return input[:splitIdx], idx
+}
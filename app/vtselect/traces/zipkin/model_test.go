@@ -0,0 +1,142 @@
+package zipkin
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaLogs/lib/logstorage"
+	"github.com/google/go-cmp/cmp"
+
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+func TestFieldsToSpan(t *testing.T) {
+	f := func(input []logstorage.Field, want *span, errorMsg string) {
+		t.Helper()
+
+		var errMsgGot string
+		got, err := fieldsToSpan(input)
+		if err != nil {
+			errMsgGot = err.Error()
+		}
+		if errMsgGot != errorMsg {
+			t.Fatalf("fieldsToSpan() error = %v, want err: %v", err, errorMsg)
+		}
+		cmpOpts := cmp.AllowUnexported(span{}, endpoint{}, annotation{}, keyValue{})
+		if !cmp.Equal(got, want, cmpOpts) {
+			t.Fatalf("fieldsToSpan() diff = %v", cmp.Diff(got, want, cmpOpts))
+		}
+	}
+
+	// case 1: empty
+	f([]logstorage.Field{}, nil, "invalid fields: []")
+
+	// case 2: without span_id
+	fields := []logstorage.Field{
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+	}
+	f(fields, nil, "invalid fields: [{trace_id 1234567890}]")
+
+	// case 3: with basic fields
+	fields = []logstorage.Field{
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+		{Name: otelpb.SpanIDField, Value: "12345"},
+	}
+	sp := &span{
+		traceID: "1234567890", id: "12345", tags: []keyValue{},
+	}
+	f(fields, sp, "")
+
+	// case 4: with all fields
+	// see: lib/protoparser/opentelemetry/pb/trace_fields.go
+	fields = []logstorage.Field{
+		{Name: otelpb.ResourceAttrServiceName, Value: "service_name_1"},
+		{Name: otelpb.ResourceAttrPrefix + "resource_attr_1", Value: "resource_attr_1"},
+		{Name: otelpb.InstrumentationScopeName, Value: "scope_name_1"},
+		{Name: otelpb.InstrumentationScopeVersion, Value: "v1.0.0"},
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+		{Name: otelpb.SpanIDField, Value: "12345"},
+		{Name: otelpb.ParentSpanIDField, Value: "23456"},
+		{Name: otelpb.NameField, Value: "span_name_1"},
+		{Name: otelpb.KindField, Value: "3"},
+		{Name: otelpb.StartTimeUnixNanoField, Value: "0"},
+		{Name: otelpb.DurationField, Value: "1000000"},
+		{Name: otelpb.SpanAttrPrefixField + "span_attr_1", Value: "span_attr_1"},
+		{Name: otelpb.SpanAttrPeerServiceField, Value: "remote_service_1"},
+		{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":0", Value: "1000"},
+		{Name: otelpb.EventPrefix + otelpb.EventNameField + ":0", Value: "event_0"},
+		{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":1", Value: "2000"},
+		{Name: otelpb.EventPrefix + otelpb.EventNameField + ":1", Value: "event_1"},
+		{Name: otelpb.EventPrefix + otelpb.EventAttrPrefix + "event_attr_1:1", Value: "event_attr_1"},
+		{Name: otelpb.StatusMessageField, Value: "status_message_1"},
+		{Name: otelpb.StatusCodeField, Value: "2"},
+	}
+	sp = &span{
+		traceID:        "1234567890",
+		id:             "12345",
+		parentID:       "23456",
+		name:           "span_name_1",
+		kind:           "CLIENT",
+		timestamp:      0,
+		duration:       1000,
+		localEndpoint:  endpoint{serviceName: "service_name_1"},
+		remoteEndpoint: endpoint{serviceName: "remote_service_1"},
+		annotations: []annotation{
+			{timestamp: 1, value: "event_0"},
+			{timestamp: 2, value: `event_1: {"event_attr_1":"event_attr_1"}`},
+		},
+		tags: []keyValue{
+			{key: "otel.scope.name", value: "scope_name_1"},
+			{key: "otel.scope.version", value: "v1.0.0"},
+			{key: "span_attr_1", value: "span_attr_1"},
+			{key: "peer.service", value: "remote_service_1"},
+			{key: "otel.status_code", value: "ERROR"},
+			{key: "error", value: "status_message_1"},
+			{key: "resource_attr_1", value: "resource_attr_1"},
+		},
+	}
+	f(fields, sp, "")
+
+	// case 5: events with non-contiguous indexes are converted in the order of their indexes
+	fields = []logstorage.Field{
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+		{Name: otelpb.SpanIDField, Value: "12345"},
+		{Name: otelpb.EventPrefix + otelpb.EventNameField + ":10", Value: "event_10"},
+		{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":10", Value: "3000"},
+		{Name: otelpb.EventPrefix + otelpb.EventNameField + ":2", Value: "event_2"},
+		{Name: otelpb.EventPrefix + otelpb.EventTimeUnixNanoField + ":2", Value: "2000"},
+	}
+	sp = &span{
+		traceID: "1234567890",
+		id:      "12345",
+		annotations: []annotation{
+			{timestamp: 2, value: "event_2"},
+			{timestamp: 3, value: "event_10"},
+		},
+		tags: []keyValue{},
+	}
+	f(fields, sp, "")
+
+	// case 6: the prefix of scope attributes is stripped, and the tags with the same key are displayed once
+	// with span attributes taking precedence over scope attributes and resource attributes.
+	fields = []logstorage.Field{
+		{Name: otelpb.ResourceAttrPrefix + "host.name", Value: "resource_host"},
+		{Name: otelpb.ResourceAttrPrefix + "env", Value: "resource_env"},
+		{Name: otelpb.ResourceAttrPrefix + "region", Value: "resource_region"},
+		{Name: otelpb.InstrumentationScopeAttrPrefix + "env", Value: "scope_env"},
+		{Name: otelpb.InstrumentationScopeAttrPrefix + "library", Value: "scope_library"},
+		{Name: otelpb.TraceIDField, Value: "1234567890"},
+		{Name: otelpb.SpanIDField, Value: "12345"},
+		{Name: otelpb.SpanAttrPrefixField + "host.name", Value: "span_host"},
+	}
+	sp = &span{
+		traceID: "1234567890",
+		id:      "12345",
+		tags: []keyValue{
+			{key: "host.name", value: "span_host"},
+			{key: "env", value: "scope_env"},
+			{key: "library", value: "scope_library"},
+			{key: "region", value: "resource_region"},
+		},
+	}
+	f(fields, sp, "")
+}
@@ -0,0 +1,473 @@
+package zipkin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+	otelpb "github.com/VictoriaMetrics/VictoriaTraces/lib/protoparser/opentelemetry/pb"
+)
+
+const (
+	maxLimit = 1000
+)
+
+// Zipkin Query APIs metrics
+var (
+	zipkinServicesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/services"}`)
+	zipkinServicesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/services"}`)
+
+	zipkinSpansRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/spans"}`)
+	zipkinSpansDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/spans"}`)
+
+	zipkinRemoteServicesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/remoteServices"}`)
+	zipkinRemoteServicesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/remoteServices"}`)
+
+	zipkinTracesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/traces"}`)
+	zipkinTracesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/traces"}`)
+
+	zipkinTraceRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/trace/*"}`)
+	zipkinTraceDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/trace/*"}`)
+
+	zipkinTraceManyRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/traceMany"}`)
+	zipkinTraceManyDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/traceMany"}`)
+
+	zipkinDependenciesRequests = metrics.NewCounter(`vt_http_requests_total{path="/select/zipkin/api/v2/dependencies"}`)
+	zipkinDependenciesDuration = metrics.NewSummary(`vt_http_request_duration_seconds{path="/select/zipkin/api/v2/dependencies"}`)
+)
+
+// RequestHandler is the entry point for all Zipkin query APIs.
+//
+// See: https://zipkin.io/zipkin-api/#/
+func RequestHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	httpserver.EnableCORS(w, r)
+	startTime := time.Now()
+	path := r.URL.Path
+	switch {
+	case path == "/select/zipkin/api/v2/services":
+		zipkinServicesRequests.Inc()
+		processGetServicesRequest(ctx, w, r)
+		zipkinServicesDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/zipkin/api/v2/spans":
+		zipkinSpansRequests.Inc()
+		processGetSpansRequest(ctx, w, r)
+		zipkinSpansDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/zipkin/api/v2/remoteServices":
+		zipkinRemoteServicesRequests.Inc()
+		processGetRemoteServicesRequest(ctx, w, r)
+		zipkinRemoteServicesDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/zipkin/api/v2/traces":
+		zipkinTracesRequests.Inc()
+		processGetTracesRequest(ctx, w, r)
+		zipkinTracesDuration.UpdateDuration(startTime)
+		return true
+	case strings.HasPrefix(path, "/select/zipkin/api/v2/trace/") && len(path) > len("/select/zipkin/api/v2/trace/"):
+		zipkinTraceRequests.Inc()
+		processGetTraceRequest(ctx, w, r)
+		zipkinTraceDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/zipkin/api/v2/traceMany":
+		zipkinTraceManyRequests.Inc()
+		processGetTraceManyRequest(ctx, w, r)
+		zipkinTraceManyDuration.UpdateDuration(startTime)
+		return true
+	case path == "/select/zipkin/api/v2/dependencies":
+		zipkinDependenciesRequests.Inc()
+		processGetDependenciesRequest(ctx, w, r)
+		zipkinDependenciesDuration.UpdateDuration(startTime)
+		return true
+	}
+	return false
+}
+
+// processGetServicesRequest handle the Zipkin /api/v2/services API request.
+func processGetServicesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	serviceList, err := query.GetServiceNameList(ctx, cp)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get services list: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetStringListResponse(w, serviceList)
+}
+
+// processGetSpansRequest handle the Zipkin /api/v2/spans?serviceName=<service_name> API request.
+func processGetSpansRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	serviceName := r.URL.Query().Get("serviceName")
+	if serviceName == "" {
+		httpserver.Errorf(w, r, "serviceName is required")
+		return
+	}
+
+	spanNameList, err := query.GetSpanNameList(ctx, cp, serviceName)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get span name list: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetStringListResponse(w, spanNameList)
+}
+
+// processGetRemoteServicesRequest handle the Zipkin /api/v2/remoteServices?serviceName=<service_name> API request.
+func processGetRemoteServicesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	serviceName := r.URL.Query().Get("serviceName")
+	if serviceName == "" {
+		httpserver.Errorf(w, r, "serviceName is required")
+		return
+	}
+
+	remoteServiceList, err := query.GetRemoteServiceNameList(ctx, cp, serviceName)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get remote service list: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetStringListResponse(w, remoteServiceList)
+}
+
+// processGetTraceRequest handle the Zipkin /api/v2/trace/<trace_id> API request.
+func processGetTraceRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	// extract the `trace_id`.
+	// the path must be like `/select/zipkin/api/v2/trace/<trace_id>`.
+	traceID := strings.ToLower(r.URL.Path[len("/select/zipkin/api/v2/trace/"):])
+	if len(traceID) == 0 {
+		httpserver.Errorf(w, r, "incorrect query path [%s]", r.URL.Path)
+		return
+	}
+
+	rows, err := query.GetTrace(ctx, cp, traceID)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get trace: %s", err)
+		return
+	}
+
+	spans := rowsToSpans(rows)
+	if len(spans) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		WriteGetTraceResponse(w, nil)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetTraceResponse(w, spans)
+}
+
+// processGetTraceManyRequest handle the Zipkin /api/v2/traceMany?traceIds=<trace_id>,<trace_id> API request.
+func processGetTraceManyRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	traceIDsStr := r.URL.Query().Get("traceIds")
+	if traceIDsStr == "" {
+		httpserver.Errorf(w, r, "traceIds is required")
+		return
+	}
+
+	traceIDs := strings.Split(strings.ToLower(traceIDsStr), ",")
+	for i := range traceIDs {
+		traceIDs[i] = strings.TrimSpace(traceIDs[i])
+	}
+	if len(traceIDs) > maxLimit {
+		httpserver.Errorf(w, r, "the number of traceIds should be not higher than %d", maxLimit)
+		return
+	}
+
+	traceIDList, rows, err := query.GetTracesByIDs(ctx, cp, traceIDs)
+	if err != nil {
+		httpserver.Errorf(w, r, "cannot get traces: %s", err)
+		return
+	}
+	traces := groupSpansByTraceID(traceIDList, rows)
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetTracesResponse(w, traces)
+}
+
+// processGetTracesRequest handle the Zipkin /api/v2/traces API request.
+func processGetTracesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseZipkinTraceQueryParam(ctx, r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect trace query params: %s", err)
+		return
+	}
+
+	traceIDList, rows, _, err := query.GetTraceList(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get trace list error: %s", err)
+		return
+	}
+
+	traces := groupSpansByTraceID(traceIDList, rows)
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetTracesResponse(w, traces)
+}
+
+// processGetDependenciesRequest handle the Zipkin /api/v2/dependencies API request.
+func processGetDependenciesRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	cp, err := query.GetCommonParams(r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect query params: %s", err)
+		return
+	}
+
+	param, err := parseZipkinDependenciesQueryParam(ctx, r)
+	if err != nil {
+		httpserver.Errorf(w, r, "incorrect dependencies query params: %s", err)
+		return
+	}
+
+	edges, err := query.GetServiceGraphEdges(ctx, cp, param)
+	if err != nil {
+		httpserver.Errorf(w, r, "get dependencies error: %s", err)
+		return
+	}
+
+	// Write results
+	w.Header().Set("Content-Type", "application/json")
+	WriteGetDependenciesResponse(w, edges)
+}
+
+// groupSpansByTraceID converts rows to Zipkin spans, and groups them by trace_id in the order of traceIDList.
+// Traces without any span are omitted.
+func groupSpansByTraceID(traceIDList []string, rows []*query.Row) [][]*span {
+	tracesMap := make(map[string]int, len(traceIDList)) // trace_id -> idx in traces
+	traces := make([][]*span, len(traceIDList))
+	for i := range traceIDList {
+		tracesMap[traceIDList[i]] = i
+	}
+	for _, sp := range rowsToSpans(rows) {
+		idx, ok := tracesMap[sp.traceID]
+		if !ok {
+			continue
+		}
+		traces[idx] = append(traces[idx], sp)
+	}
+
+	result := traces[:0]
+	for _, trace := range traces {
+		if len(trace) > 0 {
+			result = append(result, trace)
+		}
+	}
+	return result
+}
+
+// rowsToSpans converts the rows of a trace to Zipkin spans.
+func rowsToSpans(rows []*query.Row) []*span {
+	spans := make([]*span, 0, len(rows))
+	for i := range rows {
+		sp, err := fieldsToSpan(rows[i].Fields)
+		if err != nil {
+			logger.Errorf("cannot unmarshal log fields [%v] to span: %s", rows[i].Fields, err)
+			continue
+		}
+		spans = append(spans, sp)
+	}
+	return spans
+}
+
+// parseZipkinTraceQueryParam parse Zipkin request to unified query.TraceQueryParam.
+//
+// See: https://zipkin.io/zipkin-api/#/default/get_traces
+func parseZipkinTraceQueryParam(_ context.Context, r *http.Request) (*query.TraceQueryParam, error) {
+	var err error
+
+	// default params
+	p := &query.TraceQueryParam{
+		StartTimeMin: time.Unix(0, 0),
+		StartTimeMax: time.Now(),
+		Limit:        10,
+	}
+	q := r.URL.Query()
+
+	p.ServiceName = q.Get("serviceName")
+	if spanName := q.Get("spanName"); spanName != "all" {
+		p.SpanName = spanName
+	}
+
+	p.Attributes, p.AttributeKeys, err = parseAnnotationQuery(q.Get("annotationQuery"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse annotationQuery: %w", err)
+	}
+	if remoteServiceName := q.Get("remoteServiceName"); remoteServiceName != "" {
+		p.Attributes[otelpb.SpanAttrPeerServiceField] = remoteServiceName
+	}
+
+	if minDuration := q.Get("minDuration"); minDuration != "" {
+		us, err := strconv.ParseInt(minDuration, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse minDuration [%s]: %w", minDuration, err)
+		}
+		p.DurationMin = time.Duration(us) * time.Microsecond
+	}
+
+	if maxDuration := q.Get("maxDuration"); maxDuration != "" {
+		us, err := strconv.ParseInt(maxDuration, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse maxDuration [%s]: %w", maxDuration, err)
+		}
+		p.DurationMax = time.Duration(us) * time.Microsecond
+	}
+
+	if endTs := q.Get("endTs"); endTs != "" {
+		unixMilli, err := strconv.ParseInt(endTs, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse endTs [%s]: %w", endTs, err)
+		}
+		p.StartTimeMax = time.UnixMilli(unixMilli)
+	}
+
+	if lookback := q.Get("lookback"); lookback != "" {
+		ms, err := strconv.ParseInt(lookback, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse lookback [%s]: %w", lookback, err)
+		}
+		p.StartTimeMin = p.StartTimeMax.Add(-time.Duration(ms) * time.Millisecond)
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		p.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse limit [%s]: %w", limit, err)
+		}
+		if p.Limit <= 0 || p.Limit > maxLimit {
+			return nil, fmt.Errorf("limit should be in the range [1, %d]", maxLimit)
+		}
+	}
+
+	return p, nil
+}
+
+// parseAnnotationQuery parses the Zipkin annotationQuery into attribute filters.
+//
+// The annotationQuery is a list of terms joined by ` and `. e.g.: `http.method=GET and error`:
+// - `key=value` term requires the span to contain the attribute with exact value.
+// - `key` term requires the span to contain the attribute with any value.
+//
+// `error` is a special key which is converted to the span status code filter.
+func parseAnnotationQuery(s string) (map[string]string, []string, error) {
+	attributes := make(map[string]string)
+	var attributeKeys []string
+	if strings.TrimSpace(s) == "" {
+		return attributes, attributeKeys, nil
+	}
+
+	for _, term := range strings.Split(s, " and ") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, nil, fmt.Errorf("empty term in %q", s)
+		}
+		key, value, hasValue := strings.Cut(term, "=")
+		if key == "" {
+			return nil, nil, fmt.Errorf("missing key in term %q", term)
+		}
+		if key == "error" {
+			// the `error` tag is generated from the span status.
+			attributes[otelpb.StatusCodeField] = "2"
+			continue
+		}
+		field := toAttributeField(key)
+		if !hasValue {
+			attributeKeys = append(attributeKeys, field)
+			continue
+		}
+		attributes[field] = value
+	}
+	return attributes, attributeKeys, nil
+}
+
+// toAttributeField converts the Zipkin tag key to the field name in storage.
+//
+// Zipkin tags come from both the span attributes and the resource attributes.
+// Resource attributes and instrumentation scope attributes could be filtered with `resource_attr:` and `scope_attr:` prefix.
+func toAttributeField(key string) string {
+	if strings.HasPrefix(key, otelpb.ResourceAttrPrefix) || strings.HasPrefix(key, otelpb.InstrumentationScopeAttrPrefix) {
+		return key
+	}
+	return otelpb.SpanAttrPrefixField + key
+}
+
+// parseZipkinDependenciesQueryParam parse Zipkin request to unified ServiceGraphQueryParameters.
+//
+// See: https://zipkin.io/zipkin-api/#/default/get_dependencies
+func parseZipkinDependenciesQueryParam(_ context.Context, r *http.Request) (*query.ServiceGraphQueryParameters, error) {
+	// default params
+	p := &query.ServiceGraphQueryParameters{
+		EndTs:    time.Now(),
+		Lookback: 24 * time.Hour,
+	}
+	q := r.URL.Query()
+
+	if endTs := q.Get("endTs"); endTs != "" {
+		unixMilli, err := strconv.ParseInt(endTs, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse endTs [%s]: %w", endTs, err)
+		}
+		p.EndTs = time.UnixMilli(unixMilli)
+	}
+
+	if lookback := q.Get("lookback"); lookback != "" {
+		ms, err := strconv.ParseInt(lookback, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse lookback [%s]: %w", lookback, err)
+		}
+		p.Lookback = time.Duration(ms) * time.Millisecond
+	}
+
+	return p, nil
+}
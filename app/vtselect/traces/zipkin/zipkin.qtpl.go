@@ -0,0 +1,519 @@
+// Code generated by qtc from "zipkin.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:1
+package zipkin
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:1
+import (
+	"sort"
+
+	"github.com/VictoriaMetrics/VictoriaTraces/app/vtselect/traces/query"
+)
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:9
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:9
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:9
+func StreamGetStringListResponse(qw422016 *qt422016.Writer, list []string) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:11
+	sort.Strings(list)
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:12
+	qw422016.N().S(`[`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:14
+	if len(list) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:15
+		qw422016.N().Q(list[0])
+//line app/vtselect/traces/zipkin/zipkin.qtpl:16
+		for _, v := range list[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:16
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:17
+			qw422016.N().Q(v)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:18
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:19
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:19
+	qw422016.N().S(`]`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+func WriteGetStringListResponse(qq422016 qtio422016.Writer, list []string) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	StreamGetStringListResponse(qw422016, list)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+func GetStringListResponse(list []string) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	WriteGetStringListResponse(qb422016, list)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:21
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:23
+func StreamGetTracesResponse(qw422016 *qt422016.Writer, traces [][]*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:23
+	qw422016.N().S(`[`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:25
+	if len(traces) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:26
+		streamtraceJson(qw422016, traces[0])
+//line app/vtselect/traces/zipkin/zipkin.qtpl:27
+		for _, trace := range traces[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:27
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:28
+			streamtraceJson(qw422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:29
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:30
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:30
+	qw422016.N().S(`]`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+func WriteGetTracesResponse(qq422016 qtio422016.Writer, traces [][]*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	StreamGetTracesResponse(qw422016, traces)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+func GetTracesResponse(traces [][]*span) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	WriteGetTracesResponse(qb422016, traces)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:32
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:34
+func StreamGetTraceResponse(qw422016 *qt422016.Writer, trace []*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:35
+	streamtraceJson(qw422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+func WriteGetTraceResponse(qq422016 qtio422016.Writer, trace []*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	StreamGetTraceResponse(qw422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+func GetTraceResponse(trace []*span) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	WriteGetTraceResponse(qb422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:36
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:38
+func StreamGetDependenciesResponse(qw422016 *qt422016.Writer, edges []*query.ServiceGraphEdge) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:38
+	qw422016.N().S(`[`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:40
+	if len(edges) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:41
+		streamdependencyJson(qw422016, edges[0])
+//line app/vtselect/traces/zipkin/zipkin.qtpl:42
+		for _, edge := range edges[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:42
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:43
+			streamdependencyJson(qw422016, edge)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:44
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:45
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:45
+	qw422016.N().S(`]`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+func WriteGetDependenciesResponse(qq422016 qtio422016.Writer, edges []*query.ServiceGraphEdge) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	StreamGetDependenciesResponse(qw422016, edges)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+func GetDependenciesResponse(edges []*query.ServiceGraphEdge) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	WriteGetDependenciesResponse(qb422016, edges)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:47
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:49
+func streamdependencyJson(qw422016 *qt422016.Writer, edge *query.ServiceGraphEdge) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:49
+	qw422016.N().S(`{"parent":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:51
+	qw422016.N().Q(edge.Parent)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:51
+	qw422016.N().S(`,"child":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:52
+	qw422016.N().Q(edge.Child)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:52
+	qw422016.N().S(`,"callCount":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:53
+	qw422016.N().DUL(edge.CallCount)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:54
+	if edge.FailedCount > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:54
+		qw422016.N().S(`,"errorCount":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:55
+		qw422016.N().DUL(edge.FailedCount)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:56
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:56
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+func writedependencyJson(qq422016 qtio422016.Writer, edge *query.ServiceGraphEdge) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	streamdependencyJson(qw422016, edge)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+func dependencyJson(edge *query.ServiceGraphEdge) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	writedependencyJson(qb422016, edge)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:58
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:60
+func streamtraceJson(qw422016 *qt422016.Writer, trace []*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:60
+	qw422016.N().S(`[`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:62
+	if len(trace) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:63
+		streamspanJson(qw422016, trace[0])
+//line app/vtselect/traces/zipkin/zipkin.qtpl:64
+		for _, v := range trace[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:64
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:65
+			streamspanJson(qw422016, v)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:66
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:67
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:67
+	qw422016.N().S(`]`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+func writetraceJson(qq422016 qtio422016.Writer, trace []*span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	streamtraceJson(qw422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+func traceJson(trace []*span) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	writetraceJson(qb422016, trace)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:69
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:71
+func streamspanJson(qw422016 *qt422016.Writer, span *span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:71
+	qw422016.N().S(`{"traceId":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:73
+	qw422016.N().Q(span.traceID)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:73
+	qw422016.N().S(`,"id":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:74
+	qw422016.N().Q(span.id)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:74
+	qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:75
+	if span.parentID != "" {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:75
+		qw422016.N().S(`"parentId":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:76
+		qw422016.N().Q(span.parentID)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:76
+		qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:77
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:78
+	if span.kind != "" {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:78
+		qw422016.N().S(`"kind":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:79
+		qw422016.N().Q(span.kind)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:79
+		qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:80
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:80
+	qw422016.N().S(`"name":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:81
+	qw422016.N().Q(span.name)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:81
+	qw422016.N().S(`,"timestamp":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:82
+	qw422016.N().DL(span.timestamp)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:82
+	qw422016.N().S(`,"duration":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:83
+	qw422016.N().DL(span.duration)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:83
+	qw422016.N().S(`,"localEndpoint":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:84
+	streamendpointJson(qw422016, span.localEndpoint)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:84
+	qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:85
+	if span.remoteEndpoint.serviceName != "" {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:85
+		qw422016.N().S(`"remoteEndpoint":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:86
+		streamendpointJson(qw422016, span.remoteEndpoint)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:86
+		qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:87
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:87
+	qw422016.N().S(`"annotations":[`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:89
+	if len(span.annotations) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:90
+		streamannotationJson(qw422016, span.annotations[0])
+//line app/vtselect/traces/zipkin/zipkin.qtpl:91
+		for _, v := range span.annotations[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:91
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:92
+			streamannotationJson(qw422016, v)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:93
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:94
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:94
+	qw422016.N().S(`],"tags":{`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:97
+	if len(span.tags) > 0 {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:98
+		qw422016.N().Q(span.tags[0].key)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:98
+		qw422016.N().S(`:`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:98
+		qw422016.N().Q(span.tags[0].value)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:99
+		for _, v := range span.tags[1:] {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:99
+			qw422016.N().S(`,`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:100
+			qw422016.N().Q(v.key)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:100
+			qw422016.N().S(`:`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:100
+			qw422016.N().Q(v.value)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:101
+		}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:102
+	}
+//line app/vtselect/traces/zipkin/zipkin.qtpl:102
+	qw422016.N().S(`}}`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+func writespanJson(qq422016 qtio422016.Writer, span *span) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	streamspanJson(qw422016, span)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+func spanJson(span *span) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	writespanJson(qb422016, span)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:105
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:107
+func streamendpointJson(qw422016 *qt422016.Writer, e endpoint) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:107
+	qw422016.N().S(`{"serviceName":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:109
+	qw422016.N().Q(e.serviceName)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:109
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+func writeendpointJson(qq422016 qtio422016.Writer, e endpoint) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	streamendpointJson(qw422016, e)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+func endpointJson(e endpoint) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	writeendpointJson(qb422016, e)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:111
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:113
+func streamannotationJson(qw422016 *qt422016.Writer, a annotation) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:113
+	qw422016.N().S(`{"timestamp":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:115
+	qw422016.N().DL(a.timestamp)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:115
+	qw422016.N().S(`,"value":`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:116
+	qw422016.N().Q(a.value)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:116
+	qw422016.N().S(`}`)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+func writeannotationJson(qq422016 qtio422016.Writer, a annotation) {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	streamannotationJson(qw422016, a)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	qt422016.ReleaseWriter(qw422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+}
+
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+func annotationJson(a annotation) string {
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	writeannotationJson(qb422016, a)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	qs422016 := string(qb422016.B)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+	return qs422016
+//line app/vtselect/traces/zipkin/zipkin.qtpl:118
+}
@@ -0,0 +1,77 @@
+package zipkin
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAnnotationQuery(t *testing.T) {
+	f := func(s string, attributesExpected map[string]string, attributeKeysExpected []string) {
+		t.Helper()
+
+		attributes, attributeKeys, err := parseAnnotationQuery(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(attributes, attributesExpected) {
+			t.Fatalf("unexpected attributes; got %v; want %v", attributes, attributesExpected)
+		}
+		if !reflect.DeepEqual(attributeKeys, attributeKeysExpected) {
+			t.Fatalf("unexpected attribute keys; got %v; want %v", attributeKeys, attributeKeysExpected)
+		}
+	}
+
+	f("", map[string]string{}, nil)
+	f("http.method=GET", map[string]string{"span_attr:http.method": "GET"}, nil)
+	f("error", map[string]string{"status_code": "2"}, nil)
+	f("http.method=GET and error and http.route", map[string]string{
+		"span_attr:http.method": "GET",
+		"status_code":           "2",
+	}, []string{"span_attr:http.route"})
+	f("resource_attr:host.name=foo and scope_attr:bar", map[string]string{
+		"resource_attr:host.name": "foo",
+	}, []string{"scope_attr:bar"})
+	f("http.url=/a=b", map[string]string{"span_attr:http.url": "/a=b"}, nil)
+
+	fError := func(s string) {
+		t.Helper()
+
+		if _, _, err := parseAnnotationQuery(s); err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+
+	fError("=GET")
+	fError("http.method=GET and ")
+}
+
+func TestParseZipkinTraceQueryParamLimit(t *testing.T) {
+	f := func(limit string, limitExpected int, errExpected bool) {
+		t.Helper()
+
+		r := httptest.NewRequest("GET", "/select/zipkin/api/v2/traces?limit="+limit, nil)
+		p, err := parseZipkinTraceQueryParam(context.Background(), r)
+		if errExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error for limit=%q", limit)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if p.Limit != limitExpected {
+			t.Fatalf("unexpected limit; got %d; want %d", p.Limit, limitExpected)
+		}
+	}
+
+	f("1", 1, false)
+	f("1000", 1000, false)
+
+	f("0", 0, true)
+	f("-1", 0, true)
+	f("1001", 0, true)
+	f("foo", 0, true)
+}
@@ -406,6 +406,7 @@ func (srt *streamRowsTracker) getNodeIdx(streamHash uint64, traceID string) uint
 		//
 		// This could potentially affect the service graph, which aggregates data within each vtstorage instance.
 		// However, since only a small number of traces are affected, the overall trend will remain consistent.
+		// The service graph task could be run at vtselect in order to join the spans of all the vtstorage nodes.
 		return xxhash.Sum64String(traceID) % uint64(srt.nodesCount)
 	}
 
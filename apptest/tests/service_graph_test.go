@@ -312,3 +312,116 @@ func getServiceGraphRowsInsertedTotal(t *testing.T, sut at.VictoriaTracesWriteQu
 	}
 	return 0
 }
+
+// TestSingleServiceGraphVirtualNodes verifies that virtual nodes are generated by the service graph task
+// for client spans without the matching server span only if -servicegraph.peerAttributes is set.
+func TestSingleServiceGraphVirtualNodes(t *testing.T) {
+	os.RemoveAll(t.Name())
+
+	tc := at.NewTestCase(t)
+	defer tc.Stop()
+
+	f := func(instance string, peerAttributes string, want []at.DependenciesResponseData) {
+		t.Helper()
+
+		sut := tc.MustStartVtsingle(instance, []string{
+			"-storageDataPath=" + tc.Dir() + "/" + instance,
+			"-retentionPeriod=100y",
+			"-servicegraph.enableTask=true",
+			"-servicegraph.taskInterval=1s",
+			"-servicegraph.peerAttributes=" + peerAttributes,
+		})
+		prepareVirtualNodeSpanData(tc, sut)
+
+		tc.Assert(&at.AssertOptions{
+			Msg: "unexpected /select/jaeger/api/dependencies response",
+			Got: func() any {
+				sut.ForceFlush(t)
+				return sut.JaegerAPIDependencies(t, at.JaegerDependenciesParam{
+					ServiceGraphQueryParameters: query.ServiceGraphQueryParameters{
+						EndTs:    time.Now(),
+						Lookback: time.Minute,
+					},
+				}, at.QueryOpts{})
+			},
+			Want: &at.JaegerAPIDependenciesResponse{
+				Data: want,
+			},
+			CmpOpts: []cmp.Option{
+				cmpopts.IgnoreFields(at.JaegerAPIDependenciesResponse{}, "Errors", "Limit", "Offset", "Total"),
+				cmpopts.IgnoreFields(at.DependenciesResponseData{}, "CallCount"),
+				cmpopts.SortSlices(func(a, b at.DependenciesResponseData) bool {
+					return a.Parent+"->"+a.Child < b.Parent+"->"+b.Child
+				}),
+			},
+			Retries: 20,
+			Period:  time.Second,
+		})
+	}
+
+	// virtual nodes are disabled by default, so only the client span with the matching server span generates the relation.
+	f("vtsingle-default", "", []at.DependenciesResponseData{
+		{Parent: "vnClient", Child: "vnServer"},
+	})
+
+	// the client span without the matching server span generates the relation to the virtual node named by the first non-empty peer attribute.
+	// the client span with the matching server span doesn't generate the relation to the virtual node, even if it has the peer attribute.
+	// the client span without the peer attributes is ignored.
+	f("vtsingle-peer-attributes", "peer.service,db.system", []at.DependenciesResponseData{
+		{Parent: "vnClient", Child: "redis"},
+		{Parent: "vnClient", Child: "vnServer"},
+	})
+}
+
+// prepareVirtualNodeSpanData ingests the following traces:
+// 1. vnClient client span with peer.service attribute calls vnServer server span.
+// 2. vnClient client span with db.system=redis attribute without the server span.
+// 3. vnClient client span without peer attributes and without the server span.
+func prepareVirtualNodeSpanData(tc *at.TestCase, sut at.VictoriaTracesWriteQuerier) {
+	t := tc.T()
+
+	spanTime := uint64(time.Now().UnixNano())
+	newResourceSpans := func(serviceName string, spans ...*otelpb.Span) *otelpb.ResourceSpans {
+		return &otelpb.ResourceSpans{
+			Resource: otelpb.Resource{
+				Attributes: []*otelpb.KeyValue{
+					{Key: "service.name", Value: &otelpb.AnyValue{StringValue: &serviceName}},
+				},
+			},
+			ScopeSpans: []*otelpb.ScopeSpans{
+				{Spans: spans},
+			},
+		}
+	}
+	newSpan := func(traceID, spanID, parentSpanID string, kind otelpb.SpanKind, attrs map[string]string) *otelpb.Span {
+		var attributes []*otelpb.KeyValue
+		for k, v := range attrs {
+			attributes = append(attributes, &otelpb.KeyValue{Key: k, Value: &otelpb.AnyValue{StringValue: &v}})
+		}
+		return &otelpb.Span{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      parentSpanID,
+			Name:              "virtualNodeSpan",
+			Kind:              kind,
+			StartTimeUnixNano: spanTime,
+			EndTimeUnixNano:   spanTime + 1e6,
+			Attributes:        attributes,
+		}
+	}
+
+	// the client and the server spans are ingested in a single request, so they are always visible to the service graph task together.
+	req := &otelpb.ExportTraceServiceRequest{
+		ResourceSpans: []*otelpb.ResourceSpans{
+			newResourceSpans("vnClient",
+				newSpan("11111111", "1111", "", otelpb.SpanKind(3), map[string]string{"peer.service": "vnServerPeer"}),
+				newSpan("22222222", "2222", "", otelpb.SpanKind(3), map[string]string{"db.system": "redis"}),
+				newSpan("33333333", "3333", "", otelpb.SpanKind(3), nil),
+			),
+			newResourceSpans("vnServer",
+				newSpan("11111111", "1112", "1111", otelpb.SpanKind(2), nil),
+			),
+		},
+	}
+	sut.OTLPHTTPExportTraces(t, req, at.QueryOpts{})
+}
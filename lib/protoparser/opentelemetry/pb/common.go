@@ -95,13 +95,13 @@ func (kv *KeyValue) unmarshalProtobuf(src []byte) (err error) {
 
 // AnyValue represents the corresponding OTEL protobuf message
 type AnyValue struct {
-	StringValue  *string       `json:"stringValue"`
-	BoolValue    *bool         `json:"boolValue"`
-	IntValue     *int64        `json:"intValue,string"`
-	DoubleValue  *float64      `json:"doubleValue"`
-	ArrayValue   *ArrayValue   `json:"arrayValue"`
-	KeyValueList *KeyValueList `json:"keyValueList"`
-	BytesValue   *[]byte       `json:"BytesValue"`
+	StringValue  *string       `json:"stringValue,omitempty"`
+	BoolValue    *bool         `json:"boolValue,omitempty"`
+	IntValue     *int64        `json:"intValue,string,omitempty"`
+	DoubleValue  *float64      `json:"doubleValue,omitempty"`
+	ArrayValue   *ArrayValue   `json:"arrayValue,omitempty"`
+	KeyValueList *KeyValueList `json:"keyValueList,omitempty"`
+	BytesValue   *[]byte       `json:"bytesValue,omitempty"`
 }
 
 func (av *AnyValue) marshalProtobuf(mm *easyproto.MessageMarshaler) {
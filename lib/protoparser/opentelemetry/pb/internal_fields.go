@@ -11,10 +11,53 @@ const (
 	TraceIDIndexPartitionCount = uint64(1024)
 )
 
+// SpanID index stream and fields
+//
+// The trace ID of the span is stored in SpanIDIndexTraceIDFieldName instead of trace_id field,
+// so the index entries aren't returned by trace_id queries.
+const (
+	SpanIDIndexStreamName       = "span_id_idx_stream"
+	SpanIDIndexFieldName        = "span_id_idx"
+	SpanIDIndexTraceIDFieldName = "span_id_idx_trace_id"
+	SpanIDIndexPartitionCount   = uint64(1024)
+)
+
+// Link index stream and fields
+//
+// Every span link is indexed by the linked trace ID, so the spans linking to the given trace could be found.
+const (
+	LinkIndexStreamName             = "link_idx_stream"
+	LinkIndexFieldName              = "link_idx"
+	LinkIndexSpanIDFieldName        = "link_idx_span_id"
+	LinkIndexSourceTraceIDFieldName = "link_idx_source_trace_id"
+	LinkIndexSourceSpanIDFieldName  = "link_idx_source_span_id"
+	LinkIndexPartitionCount         = uint64(1024)
+)
+
 // service graph stream and fields
+//
+// The parent is the client side of the call, and the child is the server side of the call.
 const (
-	ServiceGraphStreamName         = "trace_service_graph_stream"
-	ServiceGraphParentFieldName    = "parent"
-	ServiceGraphChildFieldName     = "child"
-	ServiceGraphCallCountFieldName = "callCount"
+	ServiceGraphStreamName                = "trace_service_graph_stream"
+	ServiceGraphParentFieldName           = "parent"
+	ServiceGraphChildFieldName            = "child"
+	ServiceGraphCallCountFieldName        = "callCount"
+	ServiceGraphFailedCountFieldName      = "failedCount"
+	ServiceGraphClientSecondsSumFieldName = "clientSecondsSum"
+	ServiceGraphServerSecondsSumFieldName = "serverSecondsSum"
+	// ServiceGraphClientSecondsBucketFieldPrefix and ServiceGraphServerSecondsBucketFieldPrefix are followed by the upper bound
+	// of the latency histogram bucket in seconds. The field contains the number of calls with the latency not exceeding the upper bound.
+	ServiceGraphClientSecondsBucketFieldPrefix = "clientSecondsBucket:"
+	ServiceGraphServerSecondsBucketFieldPrefix = "serverSecondsBucket:"
+	// ServiceGraphServerCallCountFieldName contains the number of calls with the server span.
+	// It is smaller than the callCount for the relations with virtual child nodes, such as databases or third-party APIs.
+	ServiceGraphServerCallCountFieldName = "serverCallCount"
+	// ServiceGraphClientFailedCountFieldName and ServiceGraphServerFailedCountFieldName contain the number of calls
+	// with the error status of the client span and the server span.
+	ServiceGraphClientFailedCountFieldName = "clientFailedCount"
+	ServiceGraphServerFailedCountFieldName = "serverFailedCount"
+	// ServiceGraphClientLatencyBucketFieldPrefix and ServiceGraphServerLatencyBucketFieldPrefix are the same as the `*SecondsBucket:` prefixes,
+	// but with finer buckets, which are used for estimating latency percentiles of the relation.
+	ServiceGraphClientLatencyBucketFieldPrefix = "clientLatencyBucket:"
+	ServiceGraphServerLatencyBucketFieldPrefix = "serverLatencyBucket:"
 )
@@ -39,6 +39,11 @@ const (
 	DurationField = "duration"
 )
 
+// Well-known span attributes
+const (
+	SpanAttrPeerServiceField = "span_attr:peer.service" // SpanAttrPeerServiceField is the remote service name of the client span.
+)
+
 // Span_Event
 const (
 	EventPrefix = "event:"
@@ -49,6 +54,25 @@ const (
 	EventDroppedAttributesCountField = "event_dropped_attributes_count"
 )
 
+// Span_Event search fields
+//
+// These fields are calculated from the span events during ingestion, since the `event:*` fields contain the event index
+// in their names and cannot be searched without knowing the index. They're not part of OTLP.
+//
+// The values of all the span events are joined with "\n", so the spans could be searched by words and phrases in them.
+const (
+	EventNamesField        = "event_names"
+	ExceptionTypesField    = "exception_types"
+	ExceptionMessagesField = "exception_messages"
+)
+
+// Well-known event attributes
+const (
+	EventAttrExceptionType       = "exception.type"
+	EventAttrExceptionMessage    = "exception.message"
+	EventAttrExceptionStacktrace = "exception.stacktrace"
+)
+
 // Span_Link
 const (
 	LinkPrefix = "link:"
@@ -202,7 +202,7 @@ func (rs *ResourceSpans) unmarshalProtobuf(src []byte) (err error) {
 type ScopeSpans struct {
 	Scope     InstrumentationScope `json:"scope"`
 	Spans     []*Span              `json:"spans"`
-	SchemaURL string               `json:"schemaURL"`
+	SchemaURL string               `json:"schemaUrl"`
 }
 
 func (ss *ScopeSpans) marshalProtobuf(mm *easyproto.MessageMarshaler) {
@@ -331,7 +331,7 @@ type Span struct {
 	TraceID                string       `json:"traceId"`
 	SpanID                 string       `json:"spanId"`
 	TraceState             string       `json:"traceState"`
-	ParentSpanID           string       `json:"parentSpanID"`
+	ParentSpanID           string       `json:"parentSpanId"`
 	Flags                  uint32       `json:"flags"`
 	Name                   string       `json:"name"`
 	Kind                   SpanKind     `json:"kind"`